@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// CreateAPIToken 创建访问令牌
+func CreateAPIToken(ctx context.Context, token *model.APIToken) error {
+	return mysql.GetDB().WithContext(ctx).Create(token).Error
+}
+
+// GetAPITokenByHash 根据令牌哈希查询未撤销的令牌，未找到时返回 nil
+func GetAPITokenByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := mysql.GetDB().WithContext(ctx).
+		Where("token_hash = ? AND delete_time = 0", tokenHash).
+		First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &token, err
+}
+
+// ListAPITokensByUser 查询用户未撤销的令牌
+func ListAPITokensByUser(ctx context.Context, userID int64) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+	err := mysql.GetDB().WithContext(ctx).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Order("id DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// CountAPITokensByUser 统计用户未撤销的令牌数量
+func CountAPITokensByUser(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.APIToken{}).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// TouchAPIToken 记录令牌最近一次使用的时间和来源 IP
+func TouchAPIToken(ctx context.Context, id int64, ip string) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.APIToken{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_time": time.Now(),
+			"last_used_ip":   ip,
+		}).Error
+}
+
+// RevokeAPIToken 撤销用户的令牌，返回是否找到该令牌
+func RevokeAPIToken(ctx context.Context, userID, id int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.APIToken{}).
+		Where("id = ? AND user_id = ? AND delete_time = 0", id, userID).
+		Update("delete_time", time.Now().Unix())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeAPITokensByUser 撤销用户的全部令牌
+func RevokeAPITokensByUser(ctx context.Context, userID int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.APIToken{}).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Update("delete_time", time.Now().Unix()).Error
+}
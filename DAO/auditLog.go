@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+)
+
+// AuditLogFilter 审计日志查询条件，零值字段不参与筛选
+type AuditLogFilter struct {
+	ActorID    int64
+	EntityType string
+	EntityID   string
+	From       time.Time
+	To         time.Time
+}
+
+// CreateAuditLogs 批量写入审计日志
+func CreateAuditLogs(ctx context.Context, logs []*model.AuditLog) error {
+	return mysql.GetDB().WithContext(ctx).CreateInBatches(logs, 100).Error
+}
+
+// SearchAuditLogs 按条件分页查询审计日志，按时间倒序
+func SearchAuditLogs(ctx context.Context, filter *AuditLogFilter, page, pageSize int) ([]*model.AuditLog, int64, error) {
+	var logs []*model.AuditLog
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.AuditLog{})
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("create_time >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("create_time < ?", filter.To)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Limit(pageSize).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, total, err
+	}
+	return logs, total, nil
+}
+
+// DeleteAuditLogsBefore 删除 before 之前的审计日志，每次最多删除 limit 条，返回删除的条数
+func DeleteAuditLogsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result := mysql.GetDB().WithContext(ctx).
+		Where("create_time < ?", before).
+		Limit(limit).
+		Delete(&model.AuditLog{})
+	return result.RowsAffected, result.Error
+}
@@ -31,6 +31,17 @@ func CountCellReferences(ctx context.Context, itemID int64) (int64, error) {
 	return refCount, err
 }
 
+// ListSheetIDsByItemID 查询包含指定课程的工作表ID（去重）
+func ListSheetIDsByItemID(ctx context.Context, itemID int64) ([]int64, error) {
+	var sheetIDs []int64
+	err := mysql.GetDB().WithContext(ctx).
+		Model(&model.Cell{}).
+		Where("item_id = ? AND delete_time = 0", itemID).
+		Distinct().
+		Pluck("sheet_id", &sheetIDs).Error
+	return sheetIDs, err
+}
+
 func CreateBatchCellsTx(tx *gorm.DB, ctx context.Context, cells []model.Cell) error {
 	if len(cells) == 0 {
 		return nil
@@ -69,6 +80,24 @@ func UpdateCellTx(ctx context.Context, tx *gorm.DB, cell *model.Cell) error {
 		}).Error
 }
 
+// UpdateCellWithVersionTx 仅当单元格版本号仍为 cell.Version 时更新并将版本号加一，返回是否更新成功（乐观锁）
+func UpdateCellWithVersionTx(ctx context.Context, tx *gorm.DB, cell *model.Cell) (bool, error) {
+	result := tx.WithContext(ctx).Model(&model.Cell{}).
+		Where("id = ? AND version = ?", cell.ID, cell.Version).
+		Updates(map[string]interface{}{
+			"item_id":          cell.ItemID,
+			"last_modified_by": cell.LastModifiedBy,
+			"version":          gorm.Expr("version + 1"),
+			"update_time":      time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateCellWithVersion 非事务版本的 UpdateCellWithVersionTx
+func UpdateCellWithVersion(ctx context.Context, cell *model.Cell) (bool, error) {
+	return UpdateCellWithVersionTx(ctx, mysql.GetDB(), cell)
+}
+
 func GetCellWithVersion(ctx context.Context, sheetID int64, row, column int) (*model.Cell, error) {
 	var cell model.Cell
 	err := mysql.GetDB().WithContext(ctx).
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// CreateChangeRequest 创建变更申请
+func CreateChangeRequest(ctx context.Context, req *model.ChangeRequest) error {
+	return mysql.GetDB().WithContext(ctx).Create(req).Error
+}
+
+// GetChangeRequestByID 根据ID获取变更申请，未找到时返回 nil
+func GetChangeRequestByID(ctx context.Context, id int64) (*model.ChangeRequest, error) {
+	var req model.ChangeRequest
+	err := mysql.GetDB().WithContext(ctx).
+		Where("id = ? AND delete_time = 0", id).
+		First(&req).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &req, err
+}
+
+// ListChangeRequestsByClass 分页查询班级下的变更申请，status 为空时查询全部状态
+func ListChangeRequestsByClass(ctx context.Context, classID int64, status string, page, pageSize int) ([]*model.ChangeRequest, int64, error) {
+	var reqs []*model.ChangeRequest
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.ChangeRequest{}).
+		Where("class_id = ? AND delete_time = 0", classID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Limit(pageSize).Offset(offset).Find(&reqs).Error; err != nil {
+		return nil, total, err
+	}
+	return reqs, total, nil
+}
+
+// ListChangeRequestsByProposer 分页查询某用户提交的变更申请
+func ListChangeRequestsByProposer(ctx context.Context, proposerID int64, status string, page, pageSize int) ([]*model.ChangeRequest, int64, error) {
+	var reqs []*model.ChangeRequest
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.ChangeRequest{}).
+		Where("proposer_id = ? AND delete_time = 0", proposerID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Limit(pageSize).Offset(offset).Find(&reqs).Error; err != nil {
+		return nil, total, err
+	}
+	return reqs, total, nil
+}
+
+// UpdateChangeRequestStatus 仅当申请仍处于 fromStatus 时更新其状态，返回是否更新成功，
+// 用于避免两个排课员同时审核同一申请
+func UpdateChangeRequestStatus(ctx context.Context, req *model.ChangeRequest, fromStatus string) (bool, error) {
+	return UpdateChangeRequestStatusTx(ctx, mysql.GetDB(), req, fromStatus)
+}
+
+// UpdateChangeRequestStatusTx 事务版本的 UpdateChangeRequestStatus
+func UpdateChangeRequestStatusTx(ctx context.Context, tx *gorm.DB, req *model.ChangeRequest, fromStatus string) (bool, error) {
+	result := tx.WithContext(ctx).
+		Model(&model.ChangeRequest{}).
+		Where("id = ? AND status = ? AND delete_time = 0", req.ID, fromStatus).
+		Updates(map[string]interface{}{
+			"status":        req.Status,
+			"reviewer_id":   req.ReviewerID,
+			"reject_reason": req.RejectReason,
+			"update_time":   req.UpdateTime,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm/clause"
+)
+
+// CreateClassStudents 批量将学生加入班级，已在班级中的学生会被忽略，返回实际新增的数量
+func CreateClassStudents(ctx context.Context, students []*model.ClassStudent) (int64, error) {
+	if len(students) == 0 {
+		return 0, nil
+	}
+	result := mysql.GetDB().WithContext(ctx).
+		Clauses(clause.Insert{Modifier: "IGNORE"}).
+		Create(&students)
+	return result.RowsAffected, result.Error
+}
+
+// IsClassStudent 判断用户是否为班级学生
+func IsClassStudent(ctx context.Context, classID, userID int64) (bool, error) {
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.ClassStudent{}).
+		Where("class_id = ? AND user_id = ? AND delete_time = 0", classID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListClassIDsByStudent 查询学生所在的全部班级ID
+func ListClassIDsByStudent(ctx context.Context, userID int64) ([]int64, error) {
+	var classIDs []int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.ClassStudent{}).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Order("class_id ASC").
+		Pluck("class_id", &classIDs).Error
+	return classIDs, err
+}
+
+// ListClassStudents 分页查询班级学生
+func ListClassStudents(ctx context.Context, classID int64, page, pageSize int) ([]*model.ClassStudent, int64, error) {
+	var students []*model.ClassStudent
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.ClassStudent{}).
+		Where("class_id = ? AND delete_time = 0", classID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id ASC").Limit(pageSize).Offset(offset).Find(&students).Error; err != nil {
+		return nil, total, err
+	}
+	return students, total, nil
+}
+
+// DeleteClassStudent 将学生移出班级，返回是否找到该学生
+func DeleteClassStudent(ctx context.Context, classID, userID int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.ClassStudent{}).
+		Where("class_id = ? AND user_id = ? AND delete_time = 0", classID, userID).
+		Update("delete_time", time.Now().Unix())
+	return result.RowsAffected > 0, result.Error
+}
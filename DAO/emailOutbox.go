@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+)
+
+// CreateOutboxEmail 将待发送邮件写入发件箱
+func CreateOutboxEmail(ctx context.Context, email *model.EmailOutbox) error {
+	return mysql.GetDB().WithContext(ctx).Create(email).Error
+}
+
+// ListDueOutboxEmails 查询已到投递时间的待发送邮件
+func ListDueOutboxEmails(ctx context.Context, limit int) ([]*model.EmailOutbox, error) {
+	var emails []*model.EmailOutbox
+	err := mysql.GetDB().WithContext(ctx).
+		Where("status = ? AND next_attempt_time <= ?", "pending", time.Now()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&emails).Error
+	return emails, err
+}
+
+// ClaimOutboxEmail 将邮件从 pending 改为 sending，多实例部署时只有一个实例能领取成功
+func ClaimOutboxEmail(ctx context.Context, id int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Updates(map[string]interface{}{
+			"status":      "sending",
+			"update_time": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateOutboxEmailResult 记录一次投递的结果
+func UpdateOutboxEmailResult(ctx context.Context, email *model.EmailOutbox) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ?", email.ID).
+		Updates(map[string]interface{}{
+			"status":            email.Status,
+			"attempts":          email.Attempts,
+			"last_error":        email.LastError,
+			"next_attempt_time": email.NextAttemptTime,
+			"update_time":       time.Now(),
+		}).Error
+}
+
+// ResetStaleOutboxEmails 将长时间停留在 sending 状态的邮件重置为 pending（如实例在投递中途退出）
+func ResetStaleOutboxEmails(ctx context.Context, before time.Time) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("status = ? AND update_time < ?", "sending", before).
+		Update("status", "pending").Error
+}
@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+)
+
+// CreateNotification 创建一条站内通知
+func CreateNotification(ctx context.Context, n *model.Notification) error {
+	return mysql.GetDB().WithContext(ctx).Create(n).Error
+}
+
+// ListNotifications 分页查询用户的通知，按时间倒序
+func ListNotifications(ctx context.Context, userID int64, unreadOnly bool, page, pageSize int) ([]*model.Notification, int64, error) {
+	var list []*model.Notification
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND delete_time = 0", userID)
+	if unreadOnly {
+		db = db.Where("is_read = ?", false)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+// CountUnreadNotifications 统计用户未读通知数
+func CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ? AND delete_time = 0", userID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead 将用户的某条通知标记为已读，返回是否找到该通知
+func MarkNotificationRead(ctx context.Context, userID, notificationID int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND delete_time = 0", notificationID, userID).
+		Updates(map[string]interface{}{
+			"is_read":     true,
+			"update_time": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	// 已读的通知不会产生更新行，需要再确认一次是否存在
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND delete_time = 0", notificationID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkAllNotificationsRead 将用户的全部通知标记为已读
+func MarkAllNotificationsRead(ctx context.Context, userID int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ? AND delete_time = 0", userID, false).
+		Updates(map[string]interface{}{
+			"is_read":     true,
+			"update_time": time.Now(),
+		}).Error
+}
@@ -2,6 +2,8 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	mysql "github.com/sztu/mutli-table/DAO/MySQL"
 	"github.com/sztu/mutli-table/model"
@@ -13,6 +15,11 @@ func CreatePermissionTx(tx *gorm.DB, permission *model.Permission) error {
 	return tx.Create(permission).Error
 }
 
+// CreatePermission 插入一条 Permission 记录
+func CreatePermission(ctx context.Context, permission *model.Permission) error {
+	return mysql.GetDB().WithContext(ctx).Create(permission).Error
+}
+
 // GetPermission 根据用户ID和工作表ID查询权限记录
 func GetPermission(ctx context.Context, userID, sheetID int64) (*model.Permission, error) {
 	var perm model.Permission
@@ -24,3 +31,64 @@ func GetPermission(ctx context.Context, userID, sheetID int64) (*model.Permissio
 	}
 	return &perm, err
 }
+
+// GetPermissionByID 根据ID查询权限记录，未找到时返回 nil
+func GetPermissionByID(ctx context.Context, id int64) (*model.Permission, error) {
+	var perm model.Permission
+	err := mysql.GetDB().WithContext(ctx).
+		Where("id = ? AND delete_time = 0", id).
+		First(&perm).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &perm, err
+}
+
+// GetGrant 查询用户在某个班级（sheetID 为 0）或工作表上的授权记录，未找到时返回 nil
+func GetGrant(ctx context.Context, userID, classID, sheetID int64) (*model.Permission, error) {
+	var perm model.Permission
+	err := mysql.GetDB().WithContext(ctx).
+		Where("user_id = ? AND class_id = ? AND sheet_id = ? AND delete_time = 0", userID, classID, sheetID).
+		First(&perm).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &perm, err
+}
+
+// ListUserGrantLevels 查询用户在班级上以及班级下某个工作表上的授权级别，sheetID 为 0 时只查询班级授权
+func ListUserGrantLevels(ctx context.Context, userID, classID, sheetID int64) ([]string, error) {
+	var levels []string
+	err := mysql.GetDB().WithContext(ctx).Model(&model.Permission{}).
+		Where("user_id = ? AND class_id = ? AND sheet_id IN ? AND delete_time = 0", userID, classID, []int64{0, sheetID}).
+		Pluck("access_level", &levels).Error
+	return levels, err
+}
+
+// ListGrantsByClass 查询班级及其工作表上的全部授权记录
+func ListGrantsByClass(ctx context.Context, classID int64) ([]*model.Permission, error) {
+	var perms []*model.Permission
+	err := mysql.GetDB().WithContext(ctx).
+		Where("class_id = ? AND delete_time = 0", classID).
+		Order("sheet_id ASC, id ASC").
+		Find(&perms).Error
+	return perms, err
+}
+
+// UpdatePermissionLevel 修改授权级别
+func UpdatePermissionLevel(ctx context.Context, id int64, level string, grantedBy int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.Permission{}).
+		Where("id = ? AND delete_time = 0", id).
+		Updates(map[string]interface{}{
+			"access_level": level,
+			"granted_by":   grantedBy,
+			"update_time":  time.Now(),
+		}).Error
+}
+
+// DeletePermission 逻辑删除授权记录
+func DeletePermission(ctx context.Context, id int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.Permission{}).
+		Where("id = ? AND delete_time = 0", id).
+		Update("delete_time", time.Now().Unix()).Error
+}
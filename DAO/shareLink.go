@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// CreateShareLink 创建分享链接
+func CreateShareLink(ctx context.Context, link *model.ShareLink) error {
+	return mysql.GetDB().WithContext(ctx).Create(link).Error
+}
+
+// GetShareLinkByTokenHash 根据 token 哈希查询未撤销的分享链接，未找到时返回 nil
+func GetShareLinkByTokenHash(ctx context.Context, tokenHash string) (*model.ShareLink, error) {
+	var link model.ShareLink
+	err := mysql.GetDB().WithContext(ctx).
+		Where("token_hash = ? AND delete_time = 0", tokenHash).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+// GetShareLinkByID 根据ID查询未撤销的分享链接，未找到时返回 nil
+func GetShareLinkByID(ctx context.Context, id int64) (*model.ShareLink, error) {
+	var link model.ShareLink
+	err := mysql.GetDB().WithContext(ctx).
+		Where("id = ? AND delete_time = 0", id).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+// ListShareLinksByClass 查询班级下未撤销的分享链接
+func ListShareLinksByClass(ctx context.Context, classID int64) ([]*model.ShareLink, error) {
+	var links []*model.ShareLink
+	err := mysql.GetDB().WithContext(ctx).
+		Where("class_id = ? AND delete_time = 0", classID).
+		Order("id DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// IncreaseShareLinkAccess 访问次数加一并记录访问时间
+func IncreaseShareLinkAccess(ctx context.Context, id int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"access_count":     gorm.Expr("access_count + 1"),
+			"last_access_time": time.Now(),
+		}).Error
+}
+
+// RevokeShareLink 撤销分享链接
+func RevokeShareLink(ctx context.Context, id int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ? AND delete_time = 0", id).
+		Update("delete_time", time.Now().Unix()).Error
+}
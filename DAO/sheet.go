@@ -34,6 +34,16 @@ func ListSheets(ctx context.Context, userID, classID int64, page, pageSize int)
 	return sheets, total, nil
 }
 
+// ListSheetsByClassID 按周次查询班级下的全部工作表
+func ListSheetsByClassID(ctx context.Context, classID int64) ([]*model.Sheet, error) {
+	var sheets []*model.Sheet
+	err := mysql.GetDB().WithContext(ctx).
+		Where("class_id = ? AND delete_time = 0", classID).
+		Order("week ASC").
+		Find(&sheets).Error
+	return sheets, err
+}
+
 // GetSheetByID 根据工作表 ID 查询记录
 func GetSheetByID(ctx context.Context, sheetID int64) (*model.Sheet, error) {
 	var sheet model.Sheet
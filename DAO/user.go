@@ -2,19 +2,21 @@ package dao
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	mysql "github.com/sztu/mutli-table/DAO/MySQL"
 	"github.com/sztu/mutli-table/model"
 )
 
 func CreateUser(ctx context.Context, user *model.User) error {
-	sqlStr := `INSERT INTO user (user_id, username, password, email) VALUES (?, ?, ?, ?)`
-	return mysql.GetDB().WithContext(ctx).Exec(sqlStr, user.UserID, user.Username, user.Password, user.Email).Error
+	sqlStr := `INSERT INTO user (user_id, username, display_name, password, email, email_verified, role) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	return mysql.GetDB().WithContext(ctx).Exec(sqlStr, user.UserID, user.Username, user.DisplayName, user.Password, user.Email, user.EmailVerified, user.Role).Error
 }
 
 func FindUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	sqlStr := `SELECT user_id, username, password FROM user WHERE username = ? AND delete_time = 0`
+	sqlStr := `SELECT user_id, username, display_name, password, email, email_verified, role, disabled, create_time FROM user WHERE username = ? AND delete_time = 0`
 	result := mysql.GetDB().WithContext(ctx).Raw(sqlStr, username).Scan(&user)
 	if result.Error != nil {
 		return nil, result.Error
@@ -27,7 +29,7 @@ func FindUserByUsername(ctx context.Context, username string) (*model.User, erro
 
 func FindUserByID(ctx context.Context, userID int64) (*model.User, error) {
 	var user model.User
-	sqlStr := `SELECT user_id, username, password FROM user WHERE user_id = ? AND delete_time = 0`
+	sqlStr := `SELECT user_id, username, display_name, password, email, email_verified, role, disabled, create_time FROM user WHERE user_id = ? AND delete_time = 0`
 	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&user).Error
 	if err != nil {
 		return nil, err
@@ -35,10 +37,62 @@ func FindUserByID(ctx context.Context, userID int64) (*model.User, error) {
 	return &user, nil
 }
 
+// FindUserByEmail 根据邮箱查询用户，不存在时返回 nil
+func FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	sqlStr := `SELECT user_id, username, display_name, password, email, email_verified, role, disabled, create_time FROM user WHERE email = ? AND delete_time = 0`
+	result := mysql.GetDB().WithContext(ctx).Raw(sqlStr, email).Scan(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// FindUsersByEmail 查询使用该邮箱的用户，最多返回 limit 个；注册时不校验邮箱唯一，可能存在多个用户使用同一邮箱
+func FindUsersByEmail(ctx context.Context, email string, limit int) ([]*model.User, error) {
+	var users []*model.User
+	sqlStr := `SELECT user_id, username, display_name, password, email, email_verified, role, disabled, create_time FROM user WHERE email = ? AND delete_time = 0 LIMIT ?`
+	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr, email, limit).Scan(&users).Error
+	return users, err
+}
+
+// MarkUserEmailVerified 将用户邮箱标记为已验证，email 与当前邮箱不一致时不更新
+func MarkUserEmailVerified(ctx context.Context, userID int64, email string) (bool, error) {
+	sqlStr := `UPDATE user SET email_verified = 1 WHERE user_id = ? AND email = ? AND delete_time = 0`
+	result := mysql.GetDB().WithContext(ctx).Exec(sqlStr, userID, email)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateUserProfile 更新用户的显示名称和邮箱，邮箱变更时需要同时传入 email_verified = false
+func UpdateUserProfile(ctx context.Context, user *model.User) error {
+	sqlStr := `UPDATE user SET display_name = ?, email = ?, email_verified = ? WHERE user_id = ? AND delete_time = 0`
+	return mysql.GetDB().WithContext(ctx).Exec(sqlStr, user.DisplayName, user.Email, user.EmailVerified, user.UserID).Error
+}
+
+// SoftDeleteUser 注销用户，delete_time 置为当前时间后用户名和邮箱可以被重新注册
+func SoftDeleteUser(ctx context.Context, userID int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.User{}).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Update("delete_time", time.Now().Unix())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUsersByRole 统计指定角色的用户数量
+func CountUsersByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.User{}).
+		Where("role = ? AND delete_time = 0", role).
+		Count(&count).Error
+	return count, err
+}
+
 // 获取所有用户
 func ListUsers(ctx context.Context) ([]*model.User, error) {
 	var users []*model.User
-	sqlStr := `SELECT user_id, username FROM user WHERE delete_time = 0`
+	sqlStr := `SELECT user_id, username, email, role FROM user WHERE delete_time = 0`
 	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr).Scan(&users).Error
 	if err != nil {
 		return nil, err
@@ -55,3 +109,118 @@ func GetUserNameByID(ctx context.Context, userID int64) (string, error) {
 	}
 	return username, nil
 }
+
+// GetUserRoleByID 获取用户角色，用户不存在时返回空字符串
+func GetUserRoleByID(ctx context.Context, userID int64) (string, error) {
+	var role string
+	sqlStr := `SELECT role FROM user WHERE user_id = ? AND delete_time = 0`
+	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&role).Error
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+// UpdateUserRole 修改用户角色
+func UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	sqlStr := `UPDATE user SET role = ? WHERE user_id = ? AND delete_time = 0`
+	return mysql.GetDB().WithContext(ctx).Exec(sqlStr, role, userID).Error
+}
+
+// UpdateUserPassword 更新用户密码哈希
+func UpdateUserPassword(ctx context.Context, userID int64, passwordHash string) error {
+	sqlStr := `UPDATE user SET password = ? WHERE user_id = ? AND delete_time = 0`
+	return mysql.GetDB().WithContext(ctx).Exec(sqlStr, passwordHash, userID).Error
+}
+
+// FindUsersByUsernames 根据用户名批量查询用户
+func FindUsersByUsernames(ctx context.Context, usernames []string) ([]*model.User, error) {
+	var users []*model.User
+	sqlStr := `SELECT user_id, username, email, role FROM user WHERE username IN ? AND delete_time = 0`
+	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr, usernames).Scan(&users).Error
+	return users, err
+}
+
+// FindUsersByIDs 根据用户ID批量查询用户
+func FindUsersByIDs(ctx context.Context, userIDs []int64) ([]*model.User, error) {
+	var users []*model.User
+	sqlStr := `SELECT user_id, username, email, role FROM user WHERE user_id IN ? AND delete_time = 0`
+	err := mysql.GetDB().WithContext(ctx).Raw(sqlStr, userIDs).Scan(&users).Error
+	return users, err
+}
+
+// 用户状态筛选条件
+const (
+	UserStatusActive   = "active"   // 未停用且未注销
+	UserStatusDisabled = "disabled" // 已停用且未注销
+	UserStatusDeleted  = "deleted"  // 已注销
+)
+
+// SearchUsers 分页查询用户，keyword 匹配用户名、显示名称和邮箱，role、status 为空时不筛选；
+// status 为空时只返回未注销的用户
+func SearchUsers(ctx context.Context, keyword, role, status string, page, pageSize int) ([]*model.User, int64, error) {
+	var users []*model.User
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.User{})
+	switch status {
+	case UserStatusActive:
+		db = db.Where("delete_time = 0 AND disabled = 0")
+	case UserStatusDisabled:
+		db = db.Where("delete_time = 0 AND disabled = 1")
+	case UserStatusDeleted:
+		db = db.Where("delete_time > 0")
+	default:
+		db = db.Where("delete_time = 0")
+	}
+	if keyword != "" {
+		like := "%" + escapeLike(keyword) + "%"
+		db = db.Where("(username LIKE ? OR display_name LIKE ? OR email LIKE ?)", like, like, like)
+	}
+	if role != "" {
+		db = db.Where("role = ?", role)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Select("user_id, username, display_name, email, email_verified, role, disabled, create_time, delete_time").
+		Order("id DESC").Limit(pageSize).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// SetUserDisabled 停用或启用用户，返回是否找到未注销的用户
+func SetUserDisabled(ctx context.Context, userID int64, disabled bool) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.User{}).
+		Where("user_id = ? AND delete_time = 0", userID).
+		Update("disabled", disabled)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindDeletedUserByID 查询已注销的用户，同一用户ID多次注销时返回最近一次，不存在时返回 nil
+func FindDeletedUserByID(ctx context.Context, userID int64) (*model.User, error) {
+	var user model.User
+	sqlStr := `SELECT user_id, username, display_name, email, email_verified, role, disabled, create_time, delete_time FROM user WHERE user_id = ? AND delete_time > 0 ORDER BY delete_time DESC LIMIT 1`
+	result := mysql.GetDB().WithContext(ctx).Raw(sqlStr, userID).Scan(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// RestoreUser 恢复指定时间注销的用户，返回是否恢复成功
+func RestoreUser(ctx context.Context, userID, deleteTime int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.User{}).
+		Where("user_id = ? AND delete_time = ?", userID, deleteTime).
+		Update("delete_time", 0)
+	return result.RowsAffected > 0, result.Error
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
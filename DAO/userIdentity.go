@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// GetUserIdentity 根据身份提供方和 subject 查询绑定关系，未找到时返回 nil
+func GetUserIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := mysql.GetDB().WithContext(ctx).
+		Where("provider = ? AND subject = ? AND delete_time = 0", provider, subject).
+		First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &identity, err
+}
+
+// CreateUserIdentity 绑定外部身份
+func CreateUserIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	return mysql.GetDB().WithContext(ctx).Create(identity).Error
+}
+
+// TouchUserIdentity 记录通过外部身份登录的时间和最新邮箱
+func TouchUserIdentity(ctx context.Context, id int64, email string) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.UserIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":           email,
+			"last_login_time": time.Now(),
+		}).Error
+}
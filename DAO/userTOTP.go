@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// GetUserTOTP 查询用户的两步验证密钥，未绑定时返回 nil
+func GetUserTOTP(ctx context.Context, userID int64) (*model.UserTOTP, error) {
+	var totp model.UserTOTP
+	err := mysql.GetDB().WithContext(ctx).
+		Where("user_id = ?", userID).
+		First(&totp).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &totp, err
+}
+
+// CreateUserTOTP 保存待确认的两步验证密钥
+func CreateUserTOTP(ctx context.Context, totp *model.UserTOTP) error {
+	return mysql.GetDB().WithContext(ctx).Create(totp).Error
+}
+
+// UpdatePendingTOTPSecret 替换尚未确认的密钥，已启用的两步验证不会被修改
+func UpdatePendingTOTPSecret(ctx context.Context, userID int64, secret string) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.UserTOTP{}).
+		Where("user_id = ? AND enabled = 0", userID).
+		Update("secret", secret)
+	return result.RowsAffected > 0, result.Error
+}
+
+// EnableUserTOTPTx 确认绑定两步验证，并记录本次使用的时间步
+func EnableUserTOTPTx(ctx context.Context, tx *gorm.DB, userID, step int64) (bool, error) {
+	result := tx.WithContext(ctx).Model(&model.UserTOTP{}).
+		Where("user_id = ? AND enabled = 0", userID).
+		Updates(map[string]interface{}{
+			"enabled":        true,
+			"enable_time":    time.Now(),
+			"last_used_step": step,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UseTOTPStep 记录通过校验的时间步，时间步不大于上次记录的值时返回 false（验证码被重复使用）
+func UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.UserTOTP{}).
+		Where("user_id = ? AND enabled = 1 AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteUserTOTPTx 解除两步验证
+func DeleteUserTOTPTx(ctx context.Context, tx *gorm.DB, userID int64) error {
+	return tx.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.UserTOTP{}).Error
+}
+
+// ReplaceRecoveryCodesTx 删除用户的全部恢复码并保存新的恢复码
+func ReplaceRecoveryCodesTx(ctx context.Context, tx *gorm.DB, userID int64, codeHashes []string) error {
+	if err := DeleteRecoveryCodesTx(ctx, tx, userID); err != nil {
+		return err
+	}
+	codes := make([]*model.UserRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, &model.UserRecoveryCode{
+			UserID:     userID,
+			CodeHash:   hash,
+			CreateTime: time.Now(),
+		})
+	}
+	return tx.WithContext(ctx).Create(&codes).Error
+}
+
+// DeleteRecoveryCodesTx 删除用户的全部恢复码
+func DeleteRecoveryCodesTx(ctx context.Context, tx *gorm.DB, userID int64) error {
+	return tx.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.UserRecoveryCode{}).Error
+}
+
+// UseRecoveryCode 使用一个恢复码，恢复码不存在或已被使用时返回 false
+func UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_time IS NULL", userID, codeHash).
+		Update("used_time", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// CountUnusedRecoveryCodes 统计用户剩余可用的恢复码数量
+func CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := mysql.GetDB().WithContext(ctx).Model(&model.UserRecoveryCode{}).
+		Where("user_id = ? AND used_time IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
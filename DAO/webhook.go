@@ -0,0 +1,141 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/model"
+	"gorm.io/gorm"
+)
+
+// CreateWebhookSubscription 创建 webhook 订阅
+func CreateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	return mysql.GetDB().WithContext(ctx).Create(sub).Error
+}
+
+// GetWebhookSubscriptionByID 根据 ID 查询订阅，不存在时返回 nil
+func GetWebhookSubscriptionByID(ctx context.Context, id int64) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := mysql.GetDB().WithContext(ctx).
+		Where("id = ? AND delete_time = 0", id).
+		First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListWebhookSubscriptionsByCreator 查询用户创建的全部订阅
+func ListWebhookSubscriptionsByCreator(ctx context.Context, creatorID int64) ([]*model.WebhookSubscription, error) {
+	var subs []*model.WebhookSubscription
+	err := mysql.GetDB().WithContext(ctx).
+		Where("creator_id = ? AND delete_time = 0", creatorID).
+		Order("id DESC").
+		Find(&subs).Error
+	return subs, err
+}
+
+// ListWebhookSubscriptionsForEvent 查询订阅了某类事件的启用中的订阅，classID 为 0 的订阅接收所有班级的事件
+func ListWebhookSubscriptionsForEvent(ctx context.Context, eventType string, classID int64) ([]*model.WebhookSubscription, error) {
+	var subs []*model.WebhookSubscription
+	err := mysql.GetDB().WithContext(ctx).
+		Where("is_active = ? AND delete_time = 0", true).
+		Where("class_id = 0 OR class_id = ?", classID).
+		Where("FIND_IN_SET(?, event_types) > 0", eventType).
+		Find(&subs).Error
+	return subs, err
+}
+
+// UpdateWebhookSubscription 更新订阅信息
+func UpdateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.WebhookSubscription{}).
+		Where("id = ? AND delete_time = 0", sub.ID).
+		Updates(map[string]interface{}{
+			"url":         sub.URL,
+			"secret":      sub.Secret,
+			"event_types": sub.EventTypes,
+			"class_id":    sub.ClassID,
+			"description": sub.Description,
+			"is_active":   sub.IsActive,
+			"update_time": time.Now(),
+		}).Error
+}
+
+// DeleteWebhookSubscription 软删除订阅
+func DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.WebhookSubscription{}).
+		Where("id = ? AND delete_time = 0", id).
+		Update("delete_time", time.Now().Unix()).Error
+}
+
+// CreateWebhookDelivery 写入一条待投递记录
+func CreateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return mysql.GetDB().WithContext(ctx).Create(d).Error
+}
+
+// ListWebhookDeliveries 分页查询订阅的投递记录，按时间倒序
+func ListWebhookDeliveries(ctx context.Context, subscriptionID int64, page, pageSize int) ([]*model.WebhookDelivery, int64, error) {
+	var list []*model.WebhookDelivery
+	var total int64
+
+	db := mysql.GetDB().WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("subscription_id = ?", subscriptionID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Limit(pageSize).Offset(offset).Find(&list).Error; err != nil {
+		return nil, total, err
+	}
+	return list, total, nil
+}
+
+// ListDueWebhookDeliveries 查询已到投递时间的记录
+func ListDueWebhookDeliveries(ctx context.Context, limit int) ([]*model.WebhookDelivery, error) {
+	var list []*model.WebhookDelivery
+	err := mysql.GetDB().WithContext(ctx).
+		Where("status = ? AND next_attempt_time <= ?", "pending", time.Now()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// ClaimWebhookDelivery 将投递记录从 pending 改为 sending，多实例部署时只有一个实例能领取成功
+func ClaimWebhookDelivery(ctx context.Context, id int64) (bool, error) {
+	result := mysql.GetDB().WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, "pending").
+		Updates(map[string]interface{}{
+			"status":      "sending",
+			"update_time": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateWebhookDeliveryResult 记录一次投递的结果
+func UpdateWebhookDeliveryResult(ctx context.Context, d *model.WebhookDelivery) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ?", d.ID).
+		Updates(map[string]interface{}{
+			"status":            d.Status,
+			"attempts":          d.Attempts,
+			"response_status":   d.ResponseStatus,
+			"response_body":     d.ResponseBody,
+			"last_error":        d.LastError,
+			"next_attempt_time": d.NextAttemptTime,
+			"update_time":       time.Now(),
+		}).Error
+}
+
+// ResetStaleWebhookDeliveries 将长时间停留在 sending 状态的记录重置为 pending
+func ResetStaleWebhookDeliveries(ctx context.Context, before time.Time) error {
+	return mysql.GetDB().WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("status = ? AND update_time < ?", "sending", before).
+		Update("status", "pending").Error
+}
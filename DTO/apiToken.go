@@ -0,0 +1,19 @@
+package DTO
+
+type CreateAPITokenRequestDTO struct {
+	Name      string   `json:"name" binding:"required,max=64"`
+	Scopes    []string `json:"scopes" binding:"required,min=1,dive,oneof=read export write"`
+	ExpiresIn int      `json:"expires_in" binding:"min=0,max=365"` // 有效期，单位天，0 表示永不过期
+}
+
+type APITokenDTO struct {
+	ID           int64    `json:"id"`
+	Token        string   `json:"token,omitempty"` // 仅在创建时返回，请妥善保存
+	Name         string   `json:"name"`
+	TokenPrefix  string   `json:"token_prefix"`
+	Scopes       []string `json:"scopes"`
+	ExpireTime   string   `json:"expire_time,omitempty"`
+	LastUsedTime string   `json:"last_used_time,omitempty"`
+	LastUsedIP   string   `json:"last_used_ip,omitempty"`
+	CreateTime   string   `json:"create_time"`
+}
@@ -0,0 +1,29 @@
+package DTO
+
+// AuditLogDTO 审计日志
+type AuditLogDTO struct {
+	ID         int64             `json:"id"`
+	ActorID    int64             `json:"actor_id"`
+	ActorName  string            `json:"actor_name"`
+	AuthType   string            `json:"auth_type"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	Path       string            `json:"path"`
+	EntityType string            `json:"entity_type"`
+	EntityID   string            `json:"entity_id"`
+	Targets    map[string]string `json:"targets"`
+	Summary    string            `json:"summary"`
+	Status     int32             `json:"status"`
+	ResultCode int32             `json:"result_code"`
+	ResultMsg  string            `json:"result_msg"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent"`
+	LatencyMs  int32             `json:"latency_ms"`
+	CreateTime string            `json:"create_time"`
+}
+
+// AuditLogListDTO 审计日志分页列表
+type AuditLogListDTO struct {
+	Total int64         `json:"total"`
+	List  []AuditLogDTO `json:"list"`
+}
@@ -10,11 +10,27 @@ type CellDTO struct {
 	WeekType  string `json:"week_type"`
 	ClassRoom string `json:"class_room"`
 	Teacher   string `json:"teacher"`
+	Version   int64  `json:"version"` // 修改时作为 expected_version 或 If-Match: "<version>" 传回
+}
+
+// CellConflictDTO 单元格版本冲突时返回的数据
+type CellConflictDTO struct {
+	Type            string   `json:"type"` // 固定为 CELL_CONFLICT
+	SheetID         int64    `json:"sheet_id"`
+	Row             int      `json:"row"`
+	Column          int      `json:"column"`
+	ExpectedVersion int64    `json:"expected_version"`
+	Current         *CellDTO `json:"current"`
 }
 
 type DeleteItemInCellRequest struct {
-	Row int `json:"row" binding:"required"`
-	Col int `json:"col" binding:"required"`
+	Row     int    `json:"row" binding:"required"`
+	Col     int    `json:"col" binding:"required"`
+	Comment string `json:"comment" binding:"max=255"` // 非排课员提交变更申请时的说明
+	// 客户端看到的单元格版本，与当前版本不一致时拒绝修改；也可通过 If-Match 请求头传递
+	ExpectedVersion *int64 `json:"expected_version"`
+
+	LockToken string `json:"-"` // 来自 X-Lock-Token 请求头
 }
 
 type CreateDragItemRequestDTO struct {
@@ -46,6 +62,11 @@ type DragItemResponseDTO struct {
 }
 
 type MoveDragItemRequest struct {
-	TargetRow int `json:"target_row" binding:"required"`
-	TargetCol int `json:"target_col" binding:"required"`
+	TargetRow int    `json:"target_row" binding:"required"`
+	TargetCol int    `json:"target_col" binding:"required"`
+	Comment   string `json:"comment" binding:"max=255"` // 非排课员提交变更申请时的说明
+	// 客户端看到的目标单元格版本，与当前版本不一致时拒绝修改；也可通过 If-Match 请求头传递
+	ExpectedVersion *int64 `json:"expected_version"`
+
+	LockToken string `json:"-"` // 来自 X-Lock-Token 请求头
 }
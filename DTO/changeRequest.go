@@ -0,0 +1,30 @@
+package DTO
+
+// ChangeRequestDTO 变更申请信息
+type ChangeRequestDTO struct {
+	ID           int64  `json:"id"`
+	ClassID      int64  `json:"class_id"`
+	SheetID      int64  `json:"sheet_id"`
+	ItemID       int64  `json:"item_id"`
+	Action       string `json:"action"` // move / remove
+	TargetRow    int    `json:"target_row"`
+	TargetCol    int    `json:"target_col"`
+	Comment      string `json:"comment"`
+	Status       string `json:"status"` // pending / approved / rejected / cancelled
+	ProposerID   int64  `json:"proposer_id"`
+	ReviewerID   int64  `json:"reviewer_id"`
+	RejectReason string `json:"reject_reason"`
+	CreateTime   string `json:"create_time"`
+	UpdateTime   string `json:"update_time"`
+}
+
+// ChangeRequestListDTO 变更申请分页列表
+type ChangeRequestListDTO struct {
+	Total int64              `json:"total"`
+	List  []ChangeRequestDTO `json:"list"`
+}
+
+// RejectChangeRequestDTO 驳回变更申请请求参数
+type RejectChangeRequestDTO struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
@@ -0,0 +1,18 @@
+package DTO
+
+type GrantRequestDTO struct {
+	UserID      int64  `json:"user_id" binding:"required"`
+	AccessLevel string `json:"access_level" binding:"required,oneof=read edit admin"`
+}
+
+type GrantDTO struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	ClassID     int64  `json:"class_id"`
+	SheetID     int64  `json:"sheet_id"` // 0 表示整个班级
+	AccessLevel string `json:"access_level"`
+	GrantedBy   int64  `json:"granted_by"`
+	CreateTime  string `json:"create_time"`
+	UpdateTime  string `json:"update_time"`
+}
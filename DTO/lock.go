@@ -0,0 +1,40 @@
+package DTO
+
+// LockRequestDTO 获取/续期/释放锁的请求
+// resource 为 drag_item 时需要 drag_item_id；为 cell 时需要 sheet_id、row、col
+type LockRequestDTO struct {
+	Resource   string `json:"resource" binding:"required,oneof=drag_item cell"`
+	DragItemID int64  `json:"drag_item_id"`
+	SheetID    int64  `json:"sheet_id"`
+	Row        int    `json:"row"`
+	Col        int    `json:"col"`
+	Token      string `json:"token" binding:"max=64"`                // 获取锁时可选，传入已有的 token 可让多个锁共用；续期和释放时必填
+	TTL        int    `json:"ttl" binding:"omitempty,min=5,max=120"` // 锁的有效期，单位秒，默认 30
+}
+
+// LockDTO 锁的信息
+type LockDTO struct {
+	Resource   string `json:"resource"`
+	DragItemID int64  `json:"drag_item_id,omitempty"`
+	SheetID    int64  `json:"sheet_id,omitempty"`
+	Row        int    `json:"row,omitempty"`
+	Col        int    `json:"col,omitempty"`
+	Token      string `json:"token"` // 修改被锁定的课程或单元格时，通过 X-Lock-Token 请求头携带
+	OwnerID    int64  `json:"owner_id"`
+	ExpireTime string `json:"expire_time"`
+}
+
+// LockMessage WebSocket 上的锁操作消息，type 为 LOCK_ACQUIRE / LOCK_RENEW / LOCK_RELEASE
+type LockMessage struct {
+	Type string `json:"type"`
+	LockRequestDTO
+}
+
+// LockResultMessage 锁操作结果
+type LockResultMessage struct {
+	Type    string   `json:"type"`
+	Action  string   `json:"action"`
+	Success bool     `json:"success"`
+	Message string   `json:"message,omitempty"`
+	Lock    *LockDTO `json:"lock,omitempty"`
+}
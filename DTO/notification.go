@@ -0,0 +1,21 @@
+package DTO
+
+// NotificationDTO 站内通知
+type NotificationDTO struct {
+	ID         int64  `json:"id"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	ClassID    int64  `json:"class_id"`
+	SheetID    int64  `json:"sheet_id"`
+	ItemID     int64  `json:"item_id"`
+	IsRead     bool   `json:"is_read"`
+	CreateTime string `json:"create_time"`
+}
+
+// NotificationListDTO 通知分页列表
+type NotificationListDTO struct {
+	Total  int64             `json:"total"`
+	Unread int64             `json:"unread"` // 未读总数，用于前端角标
+	List   []NotificationDTO `json:"list"`
+}
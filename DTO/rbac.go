@@ -0,0 +1,37 @@
+package DTO
+
+import "time"
+
+type AssignRoleRequestDTO struct {
+	Role string `json:"role" binding:"required,oneof=admin scheduler teacher student viewer"`
+}
+
+type UserRoleDTO struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+type AdminUserDTO struct {
+	UserID        int64     `json:"user_id"`
+	Username      string    `json:"username"`
+	DisplayName   string    `json:"display_name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	Disabled      bool      `json:"disabled"`
+	Deleted       bool      `json:"deleted"`
+	CreateTime    time.Time `json:"create_time"`
+	DeleteTime    string    `json:"delete_time,omitempty"`
+}
+
+type AdminUserListDTO struct {
+	Total int64          `json:"total"`
+	List  []AdminUserDTO `json:"list"`
+}
+
+// AdminResetPasswordRequestDTO 不传 password 时向用户邮箱发送重置密码链接
+type AdminResetPasswordRequestDTO struct {
+	Password string `json:"password" binding:"omitempty,min=8"`
+}
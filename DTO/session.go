@@ -0,0 +1,10 @@
+package DTO
+
+type SessionDTO struct {
+	SessionID  string `json:"session_id"`
+	Device     string `json:"device"` // 登录设备（User-Agent）
+	IP         string `json:"ip"`     // 最近一次访问的 IP
+	CreateTime string `json:"create_time,omitempty"`
+	LastSeen   string `json:"last_seen,omitempty"`
+	Current    bool   `json:"current"` // 是否为发起请求的会话
+}
@@ -0,0 +1,29 @@
+package DTO
+
+type CreateShareLinkRequestDTO struct {
+	SheetID     int64  `json:"sheet_id"`                            // 为 0 时分享整个班级
+	ExpiresIn   int    `json:"expires_in" binding:"min=0,max=8760"` // 有效期，单位小时，0 表示永不过期
+	Description string `json:"description" binding:"max=255"`
+}
+
+type ShareLinkDTO struct {
+	ID             int64  `json:"id"`
+	Token          string `json:"token,omitempty"` // 仅在创建时返回，请妥善保存
+	TokenPrefix    string `json:"token_prefix"`
+	ClassID        int64  `json:"class_id"`
+	SheetID        int64  `json:"sheet_id"`
+	CreatorID      int64  `json:"creator_id"`
+	Description    string `json:"description"`
+	ExpireTime     string `json:"expire_time,omitempty"`
+	AccessCount    int64  `json:"access_count"`
+	LastAccessTime string `json:"last_access_time,omitempty"`
+	CreateTime     string `json:"create_time"`
+}
+
+// PublicShareDTO 访客通过分享链接看到的班级信息及可查看的工作表
+type PublicShareDTO struct {
+	ClassID   int64                    `json:"class_id"`
+	ClassName string                   `json:"class_name"`
+	SheetID   int64                    `json:"sheet_id"` // 为 0 时可以查看班级下的全部工作表
+	Sheets    []SheetDetailResponseDTO `json:"sheets"`
+}
@@ -0,0 +1,32 @@
+package DTO
+
+// EnrollStudentsRequestDTO 批量加入班级，user_ids 与 usernames 可以同时使用
+type EnrollStudentsRequestDTO struct {
+	UserIDs   []int64  `json:"user_ids" binding:"max=500"`
+	Usernames []string `json:"usernames" binding:"max=500"`
+}
+
+type EnrollStudentsResponseDTO struct {
+	Enrolled int64    `json:"enrolled"`  // 新加入班级的学生数量，已在班级中的学生不计入
+	NotFound []string `json:"not_found"` // 不存在的用户ID或用户名
+}
+
+type ClassStudentDTO struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	JoinTime string `json:"join_time"`
+}
+
+type ClassStudentListDTO struct {
+	Total int64             `json:"total"`
+	List  []ClassStudentDTO `json:"list"`
+}
+
+// MyTimetableDTO 学生个人课表，按天查询时只包含当天（课表列号等于星期几）的课程
+type MyTimetableDTO struct {
+	Week    int          `json:"week"`
+	Date    string       `json:"date,omitempty"`
+	Weekday int          `json:"weekday,omitempty"` // 1~7 对应周一至周日
+	Cells   []CourseCell `json:"cells"`
+}
@@ -0,0 +1,32 @@
+package DTO
+
+type TwoFactorStatusDTO struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // 当前角色是否要求启用两步验证
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorEnrollDTO struct {
+	Secret     string `json:"secret"`      // 无法扫码时手动输入的密钥
+	OTPAuthURI string `json:"otpauth_uri"` // 渲染为二维码供验证器 App 扫描
+}
+
+type TwoFactorCodeRequestDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 只在生成时返回一次，每个只能使用一次
+}
+
+type DisableTwoFactorRequestDTO struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TwoFactorLoginRequestDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode"` // 验证器 App 中的 6 位验证码
+	RecoveryCode   string `json:"recovery_code"`                                // 丢失验证器时使用恢复码
+}
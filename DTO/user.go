@@ -1,14 +1,28 @@
 package DTO
 
+import "time"
+
 type LoginRequestDTO struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
 type LoginResponseDTO struct {
-	AccessToken string `json:"access_token"`
-	UserID      int64  `json:"user_id"`
-	Username    string `json:"username"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token 有效期，单位秒
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username"`
+
+	// 已启用两步验证时只返回以下字段，token 为空，需要携带 challenge_token 和验证码调用 /login/2fa
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// 角色要求启用两步验证但用户尚未启用，启用并重新登录前只能访问个人账号相关接口
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+type RefreshRequestDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type SignUpRequestDTO struct {
@@ -16,3 +30,65 @@ type SignUpRequestDTO struct {
 	Password string `json:"password" binding:"required,min=8"`
 	Email    string `json:"email" binding:"required,email"`
 }
+
+// RetryAfterDTO 登录被限制时返回，RetryAfter 为需要等待的秒数
+type RetryAfterDTO struct {
+	RetryAfter int `json:"retry_after"`
+}
+
+type UnlockUserResponseDTO struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	WasLocked bool   `json:"was_locked"`
+}
+
+type VerifyEmailRequestDTO struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequestDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequestDTO struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type ProfileDTO struct {
+	UserID        int64     `json:"user_id"`
+	Username      string    `json:"username"`
+	DisplayName   string    `json:"display_name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Role          string    `json:"role"`
+	CreateTime    time.Time `json:"create_time"`
+}
+
+// UpdateProfileRequestDTO 未传的字段保持不变
+type UpdateProfileRequestDTO struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=64"`
+	Email       *string `json:"email" binding:"omitempty,email,max=64"`
+}
+
+type ChangePasswordRequestDTO struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type DeleteAccountRequestDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// OIDCAuthorizeDTO code_verifier 只返回给发起登录的前端，由前端按 state 保存，回调时原样提交
+type OIDCAuthorizeDTO struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	CodeVerifier     string `json:"code_verifier"`
+}
+
+type OIDCCallbackRequestDTO struct {
+	Code         string `json:"code" binding:"required"`
+	State        string `json:"state" binding:"required"`
+	CodeVerifier string `json:"code_verifier" binding:"required"`
+}
@@ -0,0 +1,91 @@
+package DTO
+
+// CreateWebhookRequestDTO 创建 webhook 订阅请求
+type CreateWebhookRequestDTO struct {
+	URL         string   `json:"url" binding:"required,url,max=512"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=128"` // 为空时由服务端生成
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	ClassID     int64    `json:"class_id"` // 只接收指定班级的事件，0 表示全部
+	Description string   `json:"description" binding:"max=255"`
+}
+
+// UpdateWebhookRequestDTO 更新 webhook 订阅请求，未传的字段保持不变
+type UpdateWebhookRequestDTO struct {
+	URL         *string  `json:"url" binding:"omitempty,url,max=512"`
+	Secret      *string  `json:"secret" binding:"omitempty,min=16,max=128"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1"`
+	ClassID     *int64   `json:"class_id"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// WebhookDTO webhook 订阅，Secret 只在创建或更新密钥时返回
+type WebhookDTO struct {
+	ID          int64    `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	EventTypes  []string `json:"event_types"`
+	ClassID     int64    `json:"class_id"`
+	Description string   `json:"description"`
+	IsActive    bool     `json:"is_active"`
+	CreateTime  string   `json:"create_time"`
+	UpdateTime  string   `json:"update_time"`
+}
+
+// WebhookDeliveryDTO webhook 投递记录，接收方的响应内容只保存在数据库中，不通过接口返回
+type WebhookDeliveryDTO struct {
+	ID              int64  `json:"id"`
+	EventID         string `json:"event_id"`
+	EventType       string `json:"event_type"`
+	Payload         string `json:"payload"`
+	Status          string `json:"status"`
+	Attempts        int    `json:"attempts"`
+	ResponseStatus  int    `json:"response_status"`
+	LastError       string `json:"last_error"`
+	NextAttemptTime string `json:"next_attempt_time"`
+	CreateTime      string `json:"create_time"`
+	UpdateTime      string `json:"update_time"`
+}
+
+// WebhookDeliveryListDTO 投递记录分页列表
+type WebhookDeliveryListDTO struct {
+	Total int64                `json:"total"`
+	List  []WebhookDeliveryDTO `json:"list"`
+}
+
+// WebhookEventDTO 推送给订阅方的事件，作为 webhook 请求体
+type WebhookEventDTO struct {
+	ID         string      `json:"id"` // 事件ID，同一事件重试时保持不变，可用于去重
+	Type       string      `json:"type"`
+	CreateTime string      `json:"create_time"`
+	Data       interface{} `json:"data"`
+}
+
+// WebhookItemEventDTO item.placed / item.removed 事件的数据
+type WebhookItemEventDTO struct {
+	ClassID    int64               `json:"class_id"`
+	SheetID    int64               `json:"sheet_id"`
+	Week       int                 `json:"week"`
+	Row        int                 `json:"row"`
+	Col        int                 `json:"col"`
+	OperatorID int64               `json:"operator_id"`
+	Item       DragItemResponseDTO `json:"item"`
+}
+
+// WebhookSheetEventDTO sheet.created 事件的数据
+type WebhookSheetEventDTO struct {
+	OperatorID int64            `json:"operator_id"`
+	Sheet      SheetResponseDTO `json:"sheet"`
+}
+
+// WebhookClassEventDTO class.deleted 事件的数据
+type WebhookClassEventDTO struct {
+	ClassID    int64  `json:"class_id"`
+	Name       string `json:"name"`
+	OperatorID int64  `json:"operator_id"`
+}
+
+// WebhookPingEventDTO ping 事件的数据
+type WebhookPingEventDTO struct {
+	WebhookID int64 `json:"webhook_id"`
+}
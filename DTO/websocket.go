@@ -0,0 +1,65 @@
+package DTO
+
+// 服务端推送给工作表订阅者的消息类型
+const (
+	MessageDragItemMoved   = "DRAG_ITEM_MOVED"   // 课程被放入单元格
+	MessageCellUpdated     = "CELL_UPDATED"      // 单元格内容变化（如课程被移除）
+	MessageDragItemUpdated = "DRAG_ITEM_UPDATED" // 课程信息被修改
+	MessageOnlineUsers     = "ONLINE_USERS"      // 当前在线用户列表，响应 GET_USERS
+	MessageLockResult      = "LOCK_RESULT"       // 锁操作结果，响应 LOCK_ACQUIRE / LOCK_RENEW / LOCK_RELEASE
+	MessageError           = "ERROR"             // 客户端消息处理失败
+)
+
+// BaseMessage WebSocket 消息的公共部分，用于按 type 分发
+type BaseMessage struct {
+	Type string `json:"type"`
+}
+
+// DragItemMovedMessage 课程被放入某张工作表的单元格
+type DragItemMovedMessage struct {
+	Type       string `json:"type"`
+	SheetID    int64  `json:"sheet_id"`
+	DragItemID int64  `json:"drag_item_id"`
+	TargetRow  int    `json:"target_row"`
+	TargetCol  int    `json:"target_col"`
+	MovedBy    int64  `json:"moved_by"`
+	IsPlaced   bool   `json:"is_placed"`
+}
+
+// CellUpdatedMessage 单元格内容变化，ItemID 为 null 表示单元格被清空
+type CellUpdatedMessage struct {
+	Type      string `json:"type"`
+	SheetID   int64  `json:"sheet_id"`
+	Row       int    `json:"row"`
+	Column    int    `json:"column"`
+	ItemID    *int64 `json:"item_id"`
+	UpdatedBy int64  `json:"updated_by"`
+}
+
+// DragItemUpdatedMessage 课程信息被修改，推送给所有包含该课程的工作表
+type DragItemUpdatedMessage struct {
+	Type      string              `json:"type"`
+	SheetID   int64               `json:"sheet_id"`
+	Item      DragItemResponseDTO `json:"item"`
+	UpdatedBy int64               `json:"updated_by"`
+}
+
+// OnlineUser 正在查看工作表的用户
+type OnlineUser struct {
+	UserID      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	Connections int    `json:"connections"` // 同一用户可能有多个连接
+}
+
+// OnlineUsersMessage 在线用户列表
+type OnlineUsersMessage struct {
+	Type    string       `json:"type"`
+	SheetID int64        `json:"sheet_id"`
+	Users   []OnlineUser `json:"users"`
+}
+
+// ErrorMessage 客户端消息处理失败时返回
+type ErrorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
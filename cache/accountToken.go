@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// 一次性 token 的用途
+const (
+	TokenPurposeEmailVerify   = "email_verify"
+	TokenPurposePasswordReset = "password_reset"
+)
+
+// saveAccountTokenScript 保存新 token 并删除该用户同一用途下之前签发的 token，保证同一时间只有最新的链接有效
+//
+// KEYS: 用户当前 token 指针、新 token
+// ARGV: 新 token 哈希、token 内容、有效期（毫秒）、token key 前缀
+var saveAccountTokenScript = redis.NewScript(`
+local previous = redis.call("GET", KEYS[1])
+if previous then
+	redis.call("DEL", ARGV[4] .. previous)
+end
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1`)
+
+// SaveAccountToken 保存一次性 token，tokenHash 为 token 的哈希，value 为 token 关联的内容
+func SaveAccountToken(ctx context.Context, purpose string, userID int64, tokenHash, value string, ttl time.Duration) error {
+	keys := []string{
+		GenerateRedisKey(AccountTokenUserKeyTemplate, purpose, userID),
+		GenerateRedisKey(AccountTokenKeyTemplate, purpose, tokenHash),
+	}
+	prefix := GenerateRedisKey(AccountTokenKeyTemplate, purpose, "")
+	return saveAccountTokenScript.Run(ctx, Redis.GetRedisClient(), keys, tokenHash, value, ttl.Milliseconds(), prefix).Err()
+}
+
+// ConsumeAccountToken 读取并删除一次性 token，token 不存在或已被使用时返回 ok = false
+func ConsumeAccountToken(ctx context.Context, purpose, tokenHash string) (string, bool, error) {
+	value, err := Redis.GetRedisClient().GetDel(ctx, GenerateRedisKey(AccountTokenKeyTemplate, purpose, tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// AcquireAccountMailCooldown 冷却期内返回 false，否则开始新的冷却期并返回 true
+func AcquireAccountMailCooldown(ctx context.Context, purpose string, userID int64, cooldown time.Duration) (bool, error) {
+	key := GenerateRedisKey(AccountMailCooldownTemplate, purpose, userID)
+	return Redis.GetRedisClient().SetNX(ctx, key, "1", cooldown).Result()
+}
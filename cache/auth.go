@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/sztu/mutli-table/DAO/Redis"
 )
 
@@ -23,3 +24,87 @@ func AddTokenToBlacklist(ctx context.Context, token string, expiration time.Dura
 
 	return err
 }
+
+// rotateRefreshScript 家族当前的 refresh token ID 与旧 ID 一致时替换为新 ID，并延长用户家族集合和会话信息的有效期；
+// 返回 1 表示轮换成功，0 表示旧 token 已被使用过（重复使用），-1 表示家族不存在（已过期或已撤销）或 token 代数已失效
+//
+// KEYS: 家族 key、用户家族集合、会话信息、用户 token 代数
+// ARGV: 旧 token ID、新 token ID、有效期（毫秒）、token 代数
+var rotateRefreshScript = redis.NewScript(`
+local gen = tonumber(redis.call("GET", KEYS[4]) or "0")
+if tonumber(ARGV[4]) < gen then
+	return -1
+end
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+redis.call("PEXPIRE", KEYS[3], ARGV[3])
+return 1`)
+
+// revokeUserFamiliesScript 撤销用户名下除 ARGV[2] 以外的全部 token 家族及其会话信息，返回撤销的数量
+//
+// KEYS: 用户家族集合
+// ARGV: 家族 key 前缀、需要保留的家族ID（可为空）、会话信息 key 前缀
+var revokeUserFamiliesScript = redis.NewScript(`
+local revoked = 0
+for _, family in ipairs(redis.call("SMEMBERS", KEYS[1])) do
+	if family ~= ARGV[2] then
+		redis.call("DEL", ARGV[1] .. family, ARGV[3] .. family)
+		redis.call("SREM", KEYS[1], family)
+		revoked = revoked + 1
+	end
+end
+return revoked`)
+
+// SetRefreshFamily 登录时创建 refresh token 家族和会话信息，并记录到用户的家族集合中
+func SetRefreshFamily(ctx context.Context, userID int64, familyID, tokenID string, session *SessionInfo, ttl time.Duration) error {
+	userKey := GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID)
+	sessionKey := GenerateRedisKey(SessionKeyTemplate, familyID)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.Set(ctx, GenerateRedisKey(RefreshFamilyKeyTemplate, familyID), tokenID, ttl)
+	pipe.HSet(ctx, sessionKey,
+		"user_id", userID,
+		"device", session.Device,
+		"ip", session.IP,
+		"created_at", session.CreatedAt,
+		"last_seen", session.LastSeen)
+	pipe.PExpire(ctx, sessionKey, ttl)
+	pipe.SAdd(ctx, userKey, familyID)
+	pipe.PExpire(ctx, userKey, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RotateRefreshFamily 原子地将家族中的 refresh token 从 oldTokenID 轮换为 newTokenID，返回值含义见 rotateRefreshScript
+func RotateRefreshFamily(ctx context.Context, userID int64, familyID, oldTokenID, newTokenID string, generation int64, ttl time.Duration) (int, error) {
+	keys := []string{
+		GenerateRedisKey(RefreshFamilyKeyTemplate, familyID),
+		GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID),
+		GenerateRedisKey(SessionKeyTemplate, familyID),
+		GenerateRedisKey(TokenGenerationKeyTemplate, userID),
+	}
+	return rotateRefreshScript.Run(ctx, Redis.GetRedisClient(), keys, oldTokenID, newTokenID, ttl.Milliseconds(), generation).Int()
+}
+
+// RevokeUserRefreshFamilies 撤销用户名下除 keepFamilyID 以外的全部 token 家族，keepFamilyID 为空时全部撤销
+func RevokeUserRefreshFamilies(ctx context.Context, userID int64, keepFamilyID string) (int, error) {
+	key := GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID)
+	prefix := GenerateRedisKey(RefreshFamilyKeyTemplate, "")
+	sessionPrefix := GenerateRedisKey(SessionKeyTemplate, "")
+	return revokeUserFamiliesScript.Run(ctx, Redis.GetRedisClient(), []string{key}, prefix, keepFamilyID, sessionPrefix).Int()
+}
+
+// RevokeRefreshFamily 撤销整个 token 家族及其会话信息
+func RevokeRefreshFamily(ctx context.Context, userID int64, familyID string) error {
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.Del(ctx, GenerateRedisKey(RefreshFamilyKeyTemplate, familyID), GenerateRedisKey(SessionKeyTemplate, familyID))
+	pipe.SRem(ctx, GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID), familyID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -3,7 +3,28 @@ package cache
 import "fmt"
 
 const (
-	BlackListTokenKeyTemplate = "blacklist:token:%v"
+	BlackListTokenKeyTemplate      = "blacklist:token:%v"
+	RefreshFamilyKeyTemplate       = "refresh:family:%v"  // refresh token 家族，值为当前有效的 refresh token ID
+	UserRefreshFamiliesKeyTemplate = "refresh:user:%v"    // 用户名下的全部 token 家族ID集合，用于撤销其他会话
+	SessionKeyTemplate             = "session:%v"         // 登录会话信息（设备、IP、创建和最后活跃时间），参数为 token 家族ID
+	TokenGenerationKeyTemplate     = "token:gen:%v"       // 用户的 token 代数，小于该值的 token 全部失效
+	UserDisabledKeyTemplate        = "user:disabled:%v"   // 用户已被停用，认证中间件据此拒绝请求，启用后删除
+	DragItemLockKeyTemplate        = "lock:drag_item:%v"  // 课程拖动锁，参数为课程ID
+	CellLockKeyTemplate            = "lock:cell:%v:%v:%v" // 单元格锁，参数为工作表ID、行、列
+
+	LoginFailAccountKeyTemplate = "login:fail:account:%v" // 账号登录失败次数，参数为小写用户名
+	LoginFailIPKeyTemplate      = "login:fail:ip:%v"      // IP 登录失败次数
+	LoginDelayKeyTemplate       = "login:delay:%v"        // 账号下次允许尝试前的等待期，参数为小写用户名
+	LoginLockAccountKeyTemplate = "login:lock:account:%v" // 账号临时锁定
+	LoginLockIPKeyTemplate      = "login:lock:ip:%v"      // IP 临时锁定
+
+	AccountTokenKeyTemplate     = "account:token:%v:%v"         // 邮箱验证、重置密码等一次性 token，参数为用途、token 哈希
+	AccountTokenUserKeyTemplate = "account:token:%v:user:%v"    // 用户当前有效的一次性 token 哈希，参数为用途、用户ID
+	AccountMailCooldownTemplate = "account:mail_cooldown:%v:%v" // 账号邮件发送冷却，参数为用途、用户ID
+
+	OIDCStateKeyTemplate = "oidc:state:%v" // OIDC 授权请求，值为 nonce 和 PKCE code_verifier 的哈希
+
+	TwoFactorChallengeKeyTemplate = "login:2fa:%v" // 登录第二步的挑战，参数为挑战 token 哈希，值为用户ID和输错次数
 )
 
 // GenerateRedisKey 通过格式化给定的模板字符串和提供的参数生成一个 Redis key。
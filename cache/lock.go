@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// 锁的值为持有者标识（owner），只有持有者本人可以续期或释放，避免误删他人在过期后重新获得的锁
+var (
+	// acquireLockScript 锁不存在时加锁；已由同一持有者持有时视为续期
+	acquireLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+
+	renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// AcquireLock 尝试获取锁，返回是否成功
+func AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := acquireLockScript.Run(ctx, Redis.GetRedisClient(), []string{key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// RenewLock 延长锁的有效期，锁已过期或不属于 owner 时返回 false
+func RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewLockScript.Run(ctx, Redis.GetRedisClient(), []string{key}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// ReleaseLock 释放锁，锁已过期或不属于 owner 时返回 false
+func ReleaseLock(ctx context.Context, key, owner string) (bool, error) {
+	n, err := releaseLockScript.Run(ctx, Redis.GetRedisClient(), []string{key}, owner).Int()
+	return n == 1, err
+}
+
+// GetLockOwner 查询锁的持有者，锁不存在时返回空字符串
+func GetLockOwner(ctx context.Context, key string) (string, error) {
+	owner, err := Redis.GetRedisClient().Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return owner, err
+}
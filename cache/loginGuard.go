@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// LoginGuardPolicy 登录失败限制策略，各时长均为毫秒精度
+type LoginGuardPolicy struct {
+	Window             time.Duration
+	FreeAttempts       int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	MaxAccountAttempts int
+	MaxIPAttempts      int
+	LockoutDuration    time.Duration
+}
+
+// recordLoginFailureScript 累加账号与 IP 的失败次数，并按次数设置递增的等待期或临时锁定
+//
+// KEYS: 账号失败计数、IP 失败计数、账号等待期、账号锁定、IP 锁定
+// ARGV: 统计窗口、无延迟次数、首次等待、等待上限、账号锁定阈值、IP 锁定阈值、锁定时长（时长单位均为毫秒）
+// 返回账号在窗口内的失败次数
+var recordLoginFailureScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local accountFails = redis.call("INCR", KEYS[1])
+if accountFails == 1 then
+	redis.call("PEXPIRE", KEYS[1], window)
+end
+local ipFails = redis.call("INCR", KEYS[2])
+if ipFails == 1 then
+	redis.call("PEXPIRE", KEYS[2], window)
+end
+
+local lockout = tonumber(ARGV[7])
+if accountFails >= tonumber(ARGV[5]) then
+	redis.call("SET", KEYS[4], "1", "PX", lockout)
+	redis.call("DEL", KEYS[1], KEYS[3])
+elseif accountFails > tonumber(ARGV[2]) then
+	local delay = tonumber(ARGV[3]) * math.pow(2, accountFails - tonumber(ARGV[2]) - 1)
+	delay = math.min(delay, tonumber(ARGV[4]))
+	redis.call("SET", KEYS[3], "1", "PX", math.floor(delay))
+end
+if ipFails >= tonumber(ARGV[6]) then
+	redis.call("SET", KEYS[5], "1", "PX", lockout)
+	redis.call("DEL", KEYS[2])
+end
+return accountFails`)
+
+// LoginRetryAfter 返回账号或 IP 还需要等待多久才能再次尝试登录，0 表示可以立即尝试
+func LoginRetryAfter(ctx context.Context, account, ip string) (time.Duration, error) {
+	pipe := Redis.GetRedisClient().Pipeline()
+	cmds := []*redis.DurationCmd{
+		pipe.PTTL(ctx, GenerateRedisKey(LoginLockAccountKeyTemplate, account)),
+		pipe.PTTL(ctx, GenerateRedisKey(LoginLockIPKeyTemplate, ip)),
+		pipe.PTTL(ctx, GenerateRedisKey(LoginDelayKeyTemplate, account)),
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	var wait time.Duration
+	for _, cmd := range cmds {
+		// key 不存在时 PTTL 返回负值
+		wait = max(wait, cmd.Val())
+	}
+	return wait, nil
+}
+
+// RecordLoginFailure 记录一次登录失败，返回账号在统计窗口内的失败次数
+func RecordLoginFailure(ctx context.Context, account, ip string, policy LoginGuardPolicy) (int, error) {
+	keys := []string{
+		GenerateRedisKey(LoginFailAccountKeyTemplate, account),
+		GenerateRedisKey(LoginFailIPKeyTemplate, ip),
+		GenerateRedisKey(LoginDelayKeyTemplate, account),
+		GenerateRedisKey(LoginLockAccountKeyTemplate, account),
+		GenerateRedisKey(LoginLockIPKeyTemplate, ip),
+	}
+	return recordLoginFailureScript.Run(ctx, Redis.GetRedisClient(), keys,
+		policy.Window.Milliseconds(), policy.FreeAttempts, policy.BaseDelay.Milliseconds(), policy.MaxDelay.Milliseconds(),
+		policy.MaxAccountAttempts, policy.MaxIPAttempts, policy.LockoutDuration.Milliseconds()).Int()
+}
+
+// ClearLoginFailures 清除账号的失败次数、等待期和锁定状态，用于登录成功或管理员解锁
+func ClearLoginFailures(ctx context.Context, account string) error {
+	return Redis.GetRedisClient().Del(ctx,
+		GenerateRedisKey(LoginFailAccountKeyTemplate, account),
+		GenerateRedisKey(LoginDelayKeyTemplate, account),
+		GenerateRedisKey(LoginLockAccountKeyTemplate, account),
+	).Err()
+}
+
+// IsLoginLocked 判断账号当前是否处于锁定状态
+func IsLoginLocked(ctx context.Context, account string) (bool, time.Duration, error) {
+	ttl, err := Redis.GetRedisClient().PTTL(ctx, GenerateRedisKey(LoginLockAccountKeyTemplate, account)).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	return ttl > 0, max(ttl, 0), nil
+}
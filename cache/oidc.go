@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// OIDCState 发起授权时保存、回调时取出的数据。
+// code_verifier 由发起登录的前端保存，这里只保存其哈希，用于确认回调来自同一个前端
+type OIDCState struct {
+	Nonce        string `json:"nonce"`
+	VerifierHash string `json:"verifier_hash"`
+}
+
+// SaveOIDCState 保存授权请求，state 只能在 ttl 内使用一次
+func SaveOIDCState(ctx context.Context, state string, data *OIDCState, ttl time.Duration) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return Redis.GetRedisClient().Set(ctx, GenerateRedisKey(OIDCStateKeyTemplate, state), b, ttl).Err()
+}
+
+// ConsumeOIDCState 取出并删除授权请求，state 不存在或已被使用时返回 nil
+func ConsumeOIDCState(ctx context.Context, state string) (*OIDCState, error) {
+	b, err := Redis.GetRedisClient().GetDel(ctx, GenerateRedisKey(OIDCStateKeyTemplate, state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data OIDCState
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestConsumeOIDCState(t *testing.T) {
+	ctx := context.Background()
+	want := &OIDCState{Nonce: "nonce", VerifierHash: "hash"}
+	if err := SaveOIDCState(ctx, "state-1", want, time.Minute); err != nil {
+		t.Fatalf("SaveOIDCState() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		state string
+		want  *OIDCState
+	}{
+		{name: "第一次回调取出授权请求", state: "state-1", want: want},
+		{name: "重复回调时 state 已被使用", state: "state-1"},
+		{name: "未知 state", state: "state-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConsumeOIDCState(ctx, tt.state)
+			if err != nil {
+				t.Fatalf("ConsumeOIDCState() error = %v", err)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("ConsumeOIDCState() = %+v，期望 nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("ConsumeOIDCState() = %+v，期望 %+v", got, *tt.want)
+			}
+		})
+	}
+}
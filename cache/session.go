@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// SessionInfo 登录会话信息，与 token 家族一一对应
+type SessionInfo struct {
+	FamilyID  string
+	Device    string // 登录设备（User-Agent）
+	IP        string // 最近一次访问的 IP
+	CreatedAt int64  // 登录时间，Unix 秒
+	LastSeen  int64  // 最后活跃时间，Unix 秒
+}
+
+// touchSessionScript 校验用户状态、token 代数和 token 家族，通过后按间隔更新会话的最后活跃时间和 IP；
+// 返回 1 表示有效，0 表示家族已撤销或过期，-1 表示 token 代数已失效，-2 表示用户已被停用
+//
+// KEYS: 家族 key、会话信息、用户 token 代数、用户停用标记
+// ARGV: token 代数、当前时间（秒）、客户端 IP、更新间隔（秒）
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[4]) == 1 then
+	return -2
+end
+local gen = tonumber(redis.call("GET", KEYS[3]) or "0")
+if tonumber(ARGV[1]) < gen then
+	return -1
+end
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	local last = tonumber(redis.call("HGET", KEYS[2], "last_seen") or "0")
+	if tonumber(ARGV[2]) - last >= tonumber(ARGV[4]) then
+		redis.call("HSET", KEYS[2], "last_seen", ARGV[2], "ip", ARGV[3])
+	end
+end
+return 1`)
+
+// revokeUserSessionScript 撤销属于该用户的单个会话，会话不属于该用户时返回 0
+//
+// KEYS: 用户家族集合、家族 key、会话信息
+// ARGV: 家族ID
+var revokeUserSessionScript = redis.NewScript(`
+if redis.call("SREM", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("DEL", KEYS[2], KEYS[3])
+return 1`)
+
+// TouchSession 校验会话是否仍然有效并记录活跃时间，返回值含义见 touchSessionScript
+func TouchSession(ctx context.Context, userID int64, familyID string, generation int64, ip string, interval time.Duration) (int, error) {
+	keys := []string{
+		GenerateRedisKey(RefreshFamilyKeyTemplate, familyID),
+		GenerateRedisKey(SessionKeyTemplate, familyID),
+		GenerateRedisKey(TokenGenerationKeyTemplate, userID),
+		GenerateRedisKey(UserDisabledKeyTemplate, userID),
+	}
+	return touchSessionScript.Run(ctx, Redis.GetRedisClient(), keys,
+		generation, time.Now().Unix(), ip, int64(interval.Seconds())).Int()
+}
+
+// GetTokenGeneration 获取用户当前的 token 代数，未设置时为 0
+func GetTokenGeneration(ctx context.Context, userID int64) (int64, error) {
+	gen, err := Redis.GetRedisClient().Get(ctx, GenerateRedisKey(TokenGenerationKeyTemplate, userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// SetUserDisabledFlag 设置或清除用户停用标记
+func SetUserDisabledFlag(ctx context.Context, userID int64, disabled bool) error {
+	key := GenerateRedisKey(UserDisabledKeyTemplate, userID)
+	if disabled {
+		return Redis.GetRedisClient().Set(ctx, key, "1", 0).Err()
+	}
+	return Redis.GetRedisClient().Del(ctx, key).Err()
+}
+
+// IsUserDisabled 判断用户是否已被停用
+func IsUserDisabled(ctx context.Context, userID int64) (bool, error) {
+	n, err := Redis.GetRedisClient().Exists(ctx, GenerateRedisKey(UserDisabledKeyTemplate, userID)).Result()
+	return n > 0, err
+}
+
+// IncrTokenGeneration 递增用户的 token 代数，此前签发的全部 token 立即失效
+func IncrTokenGeneration(ctx context.Context, userID int64) (int64, error) {
+	return Redis.GetRedisClient().Incr(ctx, GenerateRedisKey(TokenGenerationKeyTemplate, userID)).Result()
+}
+
+// ListUserSessions 查询用户名下的全部有效会话，已过期的家族顺带从集合中清理
+func ListUserSessions(ctx context.Context, userID int64) ([]*SessionInfo, error) {
+	client := Redis.GetRedisClient()
+	userKey := GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID)
+	families, err := client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(families) == 0 {
+		return nil, nil
+	}
+
+	pipe := client.Pipeline()
+	exists := make([]*redis.IntCmd, len(families))
+	infos := make([]*redis.StringStringMapCmd, len(families))
+	for i, family := range families {
+		exists[i] = pipe.Exists(ctx, GenerateRedisKey(RefreshFamilyKeyTemplate, family))
+		infos[i] = pipe.HGetAll(ctx, GenerateRedisKey(SessionKeyTemplate, family))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	var sessions []*SessionInfo
+	var expired []any
+	for i, family := range families {
+		if exists[i].Val() == 0 {
+			expired = append(expired, family)
+			continue
+		}
+		fields := infos[i].Val()
+		createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+		lastSeen, _ := strconv.ParseInt(fields["last_seen"], 10, 64)
+		sessions = append(sessions, &SessionInfo{
+			FamilyID:  family,
+			Device:    fields["device"],
+			IP:        fields["ip"],
+			CreatedAt: createdAt,
+			LastSeen:  lastSeen,
+		})
+	}
+	if len(expired) > 0 {
+		if err := client.SRem(ctx, userKey, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession 撤销用户的单个会话，会话不存在或不属于该用户时返回 false
+func RevokeUserSession(ctx context.Context, userID int64, familyID string) (bool, error) {
+	keys := []string{
+		GenerateRedisKey(UserRefreshFamiliesKeyTemplate, userID),
+		GenerateRedisKey(RefreshFamilyKeyTemplate, familyID),
+		GenerateRedisKey(SessionKeyTemplate, familyID),
+	}
+	n, err := revokeUserSessionScript.Run(ctx, Redis.GetRedisClient(), keys, familyID).Int()
+	return n == 1, err
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sztu/mutli-table/DAO/Redis"
+)
+
+// SaveTwoFactorChallenge 密码校验通过后保存登录挑战，用户需要在 ttl 内提交验证码完成登录
+func SaveTwoFactorChallenge(ctx context.Context, tokenHash string, userID int64, ttl time.Duration) error {
+	key := GenerateRedisKey(TwoFactorChallengeKeyTemplate, tokenHash)
+	pipe := Redis.GetRedisClient().TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID, "attempts", 0)
+	pipe.PExpire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetTwoFactorChallenge 查询登录挑战对应的用户ID，挑战不存在或已过期时返回 0
+func GetTwoFactorChallenge(ctx context.Context, tokenHash string) (int64, error) {
+	value, err := Redis.GetRedisClient().HGet(ctx, GenerateRedisKey(TwoFactorChallengeKeyTemplate, tokenHash), "user_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// incrChallengeAttemptsScript 挑战仍然存在时增加输错次数并返回新值，挑战已过期时返回 -1，避免重新创建没有有效期的 key
+var incrChallengeAttemptsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+return redis.call("HINCRBY", KEYS[1], "attempts", 1)`)
+
+// IncrTwoFactorChallengeAttempts 记录一次验证码输错，返回已输错的次数，挑战已过期时返回 -1
+func IncrTwoFactorChallengeAttempts(ctx context.Context, tokenHash string) (int64, error) {
+	key := GenerateRedisKey(TwoFactorChallengeKeyTemplate, tokenHash)
+	return incrChallengeAttemptsScript.Run(ctx, Redis.GetRedisClient(), []string{key}).Int64()
+}
+
+// DeleteTwoFactorChallenge 作废登录挑战，返回挑战此前是否存在；并发提交时只有一个请求会得到 true
+func DeleteTwoFactorChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	n, err := Redis.GetRedisClient().Del(ctx, GenerateRedisKey(TwoFactorChallengeKeyTemplate, tokenHash)).Result()
+	return n > 0, err
+}
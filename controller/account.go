@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// VerifyEmailHandler 验证邮箱
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的 token 完成邮箱验证，token 只能使用一次
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param token body string true "邮件中的 token"
+// @Success 200 {object} Response
+// @Router /api/v1/email/verify [post]
+func VerifyEmailHandler(c *gin.Context) {
+	var req DTO.VerifyEmailRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("VerifyEmailHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.VerifyEmail(ctx, req.Token); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.VerifyEmail() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "邮箱验证成功")
+}
+
+// ResendEmailVerificationHandler 重新发送邮箱验证邮件
+// @Summary 重新发送邮箱验证邮件
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/email/verification [post]
+func ResendEmailVerificationHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.ResendEmailVerification(ctx, currentUserID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.ResendEmailVerification() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "验证邮件已发送")
+}
+
+// ForgotPasswordHandler 忘记密码
+// @Summary 忘记密码
+// @Description 向邮箱发送重置密码链接，无论邮箱是否已注册都返回成功
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param email body string true "注册邮箱"
+// @Success 200 {object} Response
+// @Router /api/v1/password/forgot [post]
+func ForgotPasswordHandler(c *gin.Context) {
+	var req DTO.ForgotPasswordRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ForgotPasswordHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.ForgotPassword(ctx, req.Email); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	ResponseSuccess(c, "如果该邮箱已注册，重置密码邮件将很快送达")
+}
+
+// ResetPasswordHandler 重置密码
+// @Summary 重置密码
+// @Description 使用重置密码邮件中的 token 设置新密码，token 只能使用一次
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param token body string true "邮件中的 token"
+// @Param new_password body string true "新密码"
+// @Success 200 {object} Response
+// @Router /api/v1/password/reset [post]
+func ResetPasswordHandler(c *gin.Context) {
+	var req DTO.ResetPasswordRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ResetPasswordHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.ResetPassword(ctx, &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.ResetPassword() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "密码已重置")
+}
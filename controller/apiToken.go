@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// CreateAPITokenHandler 创建个人访问令牌
+// @Summary 创建个人访问令牌
+// @Description 令牌明文只在响应中返回一次；调用接口时使用 Authorization: Bearer {token}
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param name body string true "令牌名称"
+// @Param scopes body []string true "权限范围：read、export、write"
+// @Param expires_in body int false "有效期，单位天，0 表示永不过期"
+// @Success 201 {object} Response
+// @Router /api/v1/me/tokens [post]
+func CreateAPITokenHandler(c *gin.Context) {
+	var req DTO.CreateAPITokenRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("CreateAPITokenHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	token, apiErr := service.CreateAPIToken(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.CreateAPIToken() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseCreated(c, token)
+}
+
+// ListAPITokensHandler 查询个人访问令牌
+// @Summary 查询个人访问令牌
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/tokens [get]
+func ListAPITokensHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	tokens, apiErr := service.ListAPITokens(ctx, currentUserID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.ListAPITokens() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, tokens)
+}
+
+// RevokeAPITokenHandler 撤销个人访问令牌
+// @Summary 撤销个人访问令牌
+// @Tags 账号
+// @Produce json
+// @Param token_id path int true "令牌ID"
+// @Success 200 {object} Response
+// @Router /api/v1/me/tokens/{token_id} [delete]
+func RevokeAPITokenHandler(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("token_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid token_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RevokeAPIToken(ctx, currentUserID, tokenID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.RevokeAPIToken() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// auditResponseLimit 最多缓存多少字节的响应体用于解析业务码和提示信息
+const auditResponseLimit = 4096
+
+// auditResponseWriter 在写出响应的同时缓存响应体开头的部分
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(data []byte) {
+	if remain := auditResponseLimit - w.body.Len(); remain > 0 {
+		if len(data) > remain {
+			data = data[:remain]
+		}
+		w.body.Write(data)
+	}
+}
+
+// errorReader 读完已缓存的请求体后返回读取时遇到的错误（如请求体超过大小限制），保证处理函数看到的结果不变
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// AuditMiddleware 审计日志中间件，记录所有非 GET 请求的操作人、目标对象、请求摘要和结果。
+// 需要放在 TimeoutMiddleware 之前，才能记录超时的请求
+func AuditMiddleware() gin.HandlerFunc {
+	if !service.AuditEnabled() {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		summary := readAuditSummary(c)
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		entry := &model.AuditLog{
+			AuthType:   "anonymous",
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			Path:       c.Request.URL.Path,
+			Summary:    summary,
+			Status:     int32(writer.Status()),
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			LatencyMs:  int32(time.Since(start).Milliseconds()),
+			CreateTime: start,
+		}
+		// 登录、注册等公开接口没有操作人，由请求摘要中的用户名区分
+		if userID := c.GetInt64(ContextUserIDKey); userID != 0 {
+			entry.ActorID = userID
+			entry.ActorName = c.GetString(ContextUsernameKey)
+			entry.AuthType = "session"
+			if _, ok := c.Get(ContextTokenScopesKey); ok {
+				entry.AuthType = "token"
+			}
+		}
+		fillAuditTargets(c, entry)
+		entry.ResultCode, entry.ResultMsg = parseAuditResult(writer.body.Bytes())
+		service.RecordAudit(entry)
+	}
+}
+
+// readAuditSummary 读取请求体生成摘要，并把请求体放回去供后续处理函数使用
+func readAuditSummary(c *gin.Context) string {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return ""
+	}
+	contentType := c.ContentType()
+	if !strings.Contains(contentType, "json") {
+		// 上传的文件等不读取内容，只记录大小
+		return service.SummarizeAuditBody(contentType, nil, c.Request.ContentLength)
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	var rest io.Reader = bytes.NewReader(body)
+	if err != nil {
+		rest = io.MultiReader(rest, errorReader{err: err})
+	}
+	c.Request.Body = io.NopCloser(rest)
+	return service.SummarizeAuditBody(contentType, body, int64(len(body)))
+}
+
+// fillAuditTargets 记录路由参数，并把最后一个 *_id 参数作为主要目标对象，
+// 如 /classes/:class_id/sheets/:sheet_id 的目标对象为 sheet
+func fillAuditTargets(c *gin.Context, entry *model.AuditLog) {
+	if len(c.Params) == 0 {
+		return
+	}
+	targets := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		value := param.Value
+		if service.IsAuditSensitiveKey(param.Key) {
+			entry.Path = strings.Replace(entry.Path, value, "***", 1)
+			value = "***"
+		}
+		targets[param.Key] = value
+		if strings.HasSuffix(param.Key, "_id") {
+			entry.EntityType = strings.TrimSuffix(param.Key, "_id")
+			entry.EntityID = value
+		}
+	}
+	data, err := json.Marshal(targets)
+	if err != nil {
+		zap.L().Warn("序列化审计日志目标对象失败", zap.Error(err))
+		return
+	}
+	entry.Targets = string(data)
+}
+
+// parseAuditResult 从响应体中解析业务码和提示信息。响应体可能被截断，
+// 因此逐个读取字段，code 和 msg 位于 data 之前，截断通常不影响解析
+func parseAuditResult(body []byte) (int32, string) {
+	var resultCode int32
+	var msg string
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return 0, ""
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		key, _ := token.(string)
+		switch key {
+		case "code":
+			var value int32
+			if err := decoder.Decode(&value); err != nil {
+				return resultCode, msg
+			}
+			resultCode = value
+		case "msg":
+			if err := decoder.Decode(&msg); err != nil {
+				return resultCode, msg
+			}
+		default:
+			return resultCode, msg
+		}
+	}
+	return resultCode, msg
+}
+
+// SearchAuditLogsHandler 管理员查询审计日志，可按操作人、目标对象和时间范围筛选，时间使用 RFC3339 格式
+func SearchAuditLogsHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	filter := &dao.AuditLogFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		if filter.ActorID, err = strconv.ParseInt(actorID, 10, 64); err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "invalid actor_id")
+			return
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "invalid from")
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "invalid to")
+			return
+		}
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.SearchAuditLogs(ctx, filter, page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("SearchAuditLogsHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
@@ -3,13 +3,17 @@ package controller
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
 	"github.com/sztu/mutli-table/DAO/Redis"
 	"github.com/sztu/mutli-table/cache"
 	"github.com/sztu/mutli-table/pkg/jwt"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/service"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +22,12 @@ const (
 	ContextUserIDKey = "user_id"
 	// ContextUsernameKey 是上下文中用户名的key
 	ContextUsernameKey = "username"
+	// ContextFamilyIDKey 是上下文中当前登录会话（token 家族）ID的key
+	ContextFamilyIDKey = "family_id"
+	// ContextTokenScopesKey 是上下文中访问令牌权限范围的key，只有使用访问令牌认证的请求才会设置
+	ContextTokenScopesKey = "token_scopes"
+	// ContextTwoFactorKey 是上下文中当前会话是否通过两步验证的key
+	ContextTwoFactorKey = "two_factor"
 )
 
 // JWTAuthMiddleware 是一个 Gin 的中间件函数, 用于处理 JWT 认证。
@@ -27,6 +37,10 @@ const (
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.Request.Header.Get("Authorization")
+		// 浏览器无法为 WebSocket 握手和 EventSource 设置请求头，允许通过 query 参数携带 token
+		if authHeader == "" && isStreamRequest(c) && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			ResponseUnAuthorized(c, "请求未携带 token")
 			zap.L().Info("请求未携带 token")
@@ -41,6 +55,10 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 		token := parts[1]
+		if service.IsAPIToken(token) {
+			authenticateAPIToken(c, token)
+			return
+		}
 		myClaims, err := jwt.ParseToken(token)
 		if err != nil {
 			ResponseUnAuthorized(c, "token 解析失败")
@@ -60,14 +78,102 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// 用户已被停用，或所属的登录会话已被撤销（登出、在其他设备上被移除或检测到 refresh token 被重复使用），
+		// 或用户已退出全部设备
+		if apiErr := service.CheckSession(c.Request.Context(), myClaims.UserID, myClaims.FamilyID, myClaims.Generation, c.ClientIP()); apiErr != nil {
+			ResponseErrorWithApiError(c, apiErr)
+			c.Abort()
+			return
+		}
 
 		c.Set(ContextUserIDKey, myClaims.UserID)
 		c.Set(ContextUsernameKey, myClaims.Username)
+		c.Set(ContextFamilyIDKey, myClaims.FamilyID)
+		c.Set(ContextTwoFactorKey, myClaims.TwoFactor)
 		c.Next()
 		return
 	}
 }
 
+// authenticateAPIToken 使用个人访问令牌认证，只读范围的令牌只能发起 GET 请求
+func authenticateAPIToken(c *gin.Context, token string) {
+	user, scopes, apiErr := service.AuthenticateAPIToken(c.Request.Context(), token, c.ClientIP())
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("访问令牌认证失败", zap.Error(apiErr))
+		c.Abort()
+		return
+	}
+	if !rbac.ScopeAllowsMethod(scopes, c.Request.Method) {
+		ResponseForbidden(c, "访问令牌的权限范围不允许修改数据")
+		c.Abort()
+		return
+	}
+	c.Set(ContextUserIDKey, user.UserID)
+	c.Set(ContextUsernameKey, user.Username)
+	c.Set(ContextTokenScopesKey, scopes)
+	c.Next()
+}
+
+// RequireSession 拒绝使用访问令牌调用，用于账号管理、令牌管理等只允许用户本人在登录状态下操作的接口
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(ContextTokenScopesKey); ok {
+			ResponseForbidden(c, "该接口不支持使用访问令牌调用")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope 使用访问令牌调用时校验令牌的权限范围包含 perm，不校验角色和两步验证，
+// 用于所有用户都能访问、且未启用两步验证时也必须能访问的个人接口
+func RequireScope(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, ok := c.Get(ContextTokenScopesKey); ok && !rbac.ScopeAllows(scopes.([]string), perm) {
+			ResponseForbidden(c, "访问令牌的权限范围不足")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTwoFactorPolicy 角色要求两步验证时，只允许通过两步验证的会话访问，必须注册在 JWTAuthMiddleware 之后
+func RequireTwoFactorPolicy() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64(ContextUserIDKey)
+		role, apiErr := service.GetUserRole(c.Request.Context(), userID)
+		if apiErr != nil {
+			ResponseErrorWithApiError(c, apiErr)
+			c.Abort()
+			return
+		}
+		if !twoFactorSatisfied(c, role) {
+			ResponseForbidden(c, "当前角色需要启用两步验证，请启用后重新登录")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// twoFactorSatisfied 判断当前请求是否满足角色的两步验证要求；
+// 访问令牌只能在满足要求的会话中创建，因此不再重复校验
+func twoFactorSatisfied(c *gin.Context, role string) bool {
+	if _, ok := c.Get(ContextTokenScopesKey); ok {
+		return true
+	}
+	return !service.TwoFactorRequired(role) || c.GetBool(ContextTwoFactorKey)
+}
+
+// isStreamRequest 判断是否为 WebSocket 握手或 SSE 请求
+func isStreamRequest(c *gin.Context) bool {
+	return websocket.IsWebSocketUpgrade(c.Request) ||
+		strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
 func CheckTokenBlacklist(ctx context.Context, token string) error {
 	key := cache.GenerateRedisKey(cache.BlackListTokenKeyTemplate, token)
 	err := Redis.GetRedisClient().Get(ctx, key).Err()
@@ -82,3 +188,15 @@ func CheckTokenBlacklist(ctx context.Context, token string) error {
 	// 查到了，说明在黑名单
 	return errors.New("token is blacklisted")
 }
+
+// JWKSHandler 以 JWKS 标准格式返回 token 签名公钥
+func JWKSHandler(c *gin.Context) {
+	set, err := jwt.JWKS()
+	if err != nil {
+		ResponseInternalServerError(c, "获取签名公钥失败")
+		zap.L().Error("jwt.JWKS() 失败", zap.Error(err))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sztu/mutli-table/DTO"
@@ -44,6 +45,15 @@ func DeleteItemInCellHandler(c *gin.Context) {
 		zap.L().Error("UpdateCellHandler.ShouldBindJSON() 失败", zap.Error(err))
 		return
 	}
+	req.LockToken = c.GetHeader(LockTokenHeader)
+	if req.ExpectedVersion == nil {
+		version, err := parseIfMatchVersion(c)
+		if err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "无效的 If-Match 请求头")
+			return
+		}
+		req.ExpectedVersion = version
+	}
 	userIDValue, exists := c.Get("user_id")
 	if !exists {
 		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
@@ -55,11 +65,31 @@ func DeleteItemInCellHandler(c *gin.Context) {
 		return
 	}
 	ctx := c.Request.Context()
-	if err := service.DeleteItemInCell(ctx, currentUserID, classID, sheetID, req); err != nil {
+	changeReq, err := service.DeleteItemInCell(ctx, currentUserID, classID, sheetID, req)
+	if err != nil {
 		ResponseErrorWithApiError(c, err)
 		zap.L().Error("UpdateCell 失败", zap.Error(err))
 		return
 	}
+	// 非排课员的移除不会直接生效，返回待审核的变更申请
+	if changeReq != nil {
+		ResponseSuccess(c, changeReq)
+		return
+	}
 
 	ResponseSuccess(c, "更新成功")
 }
+
+// parseIfMatchVersion 从 If-Match 请求头解析单元格版本号，格式为 "<version>" 或 W/"<version>"，未携带时返回 nil
+func parseIfMatchVersion(c *gin.Context) (*int64, error) {
+	etag := strings.TrimSpace(c.GetHeader("If-Match"))
+	if etag == "" || etag == "*" {
+		return nil, nil
+	}
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), "\"")
+	version, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// ListClassChangeRequestsHandler 查询班级下的变更申请（支持按状态过滤和分页）
+func ListClassChangeRequestsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.ListClassChangeRequests(ctx, currentUserID, classID, c.Query("status"), page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListClassChangeRequests 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// ListMyChangeRequestsHandler 查询当前用户提交的变更申请
+func ListMyChangeRequestsHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.ListMyChangeRequests(ctx, currentUserID, c.Query("status"), page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListMyChangeRequests 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// ApproveChangeRequestHandler 排课员通过变更申请，审核人自己持有相关锁时通过 X-Lock-Token 携带锁 token
+func ApproveChangeRequestHandler(c *gin.Context) {
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "无效的申请ID")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.ApproveChangeRequest(ctx, currentUserID, requestID, c.GetHeader(LockTokenHeader))
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ApproveChangeRequest 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// RejectChangeRequestHandler 排课员驳回变更申请，需填写驳回原因
+func RejectChangeRequestHandler(c *gin.Context) {
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "无效的申请ID")
+		return
+	}
+	var req DTO.RejectChangeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("RejectChangeRequestHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.RejectChangeRequest(ctx, currentUserID, requestID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("RejectChangeRequest 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// CancelChangeRequestHandler 申请人撤回变更申请
+func CancelChangeRequestHandler(c *gin.Context) {
+	requestID, err := strconv.ParseInt(c.Param("request_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "无效的申请ID")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.CancelChangeRequest(ctx, currentUserID, requestID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("CancelChangeRequest 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "撤回成功")
+}
@@ -194,6 +194,15 @@ func MoveDragItemHandler(c *gin.Context) {
 		zap.L().Error("MoveDragItemHandler.ShouldBindJSON() 失败", zap.Error(err))
 		return
 	}
+	req.LockToken = c.GetHeader(LockTokenHeader)
+	if req.ExpectedVersion == nil {
+		version, err := parseIfMatchVersion(c)
+		if err != nil {
+			ResponseErrorWithMsg(c, code.InvalidParam, "无效的 If-Match 请求头")
+			return
+		}
+		req.ExpectedVersion = version
+	}
 	userIDValue, exists := c.Get("user_id")
 	if !exists {
 		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
@@ -205,9 +214,15 @@ func MoveDragItemHandler(c *gin.Context) {
 		return
 	}
 	ctx := c.Request.Context()
-	apiErr := service.MoveDragItem(ctx, classID, currentUserID, sheetID, dragItemID, &req)
+	changeReq, apiErr := service.MoveDragItem(ctx, classID, currentUserID, sheetID, dragItemID, &req)
 	if apiErr != nil {
-		ResponseErrorWithMsg(c, code.ServerError, apiErr.Msg)
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("MoveDragItem 失败", zap.Error(apiErr))
+		return
+	}
+	// 非排课员的拖拽不会直接生效，返回待审核的变更申请
+	if changeReq != nil {
+		ResponseSuccess(c, changeReq)
 		return
 	}
 	ResponseSuccess(c, "拖拽成功")
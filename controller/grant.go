@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// ListClassGrantsHandler 查询班级及其工作表上的授权
+func ListClassGrantsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	grants, apiErr := service.ListClassGrants(ctx, currentUserID, classID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListClassGrantsHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, grants)
+}
+
+// ShareClassHandler 授予用户班级权限
+func ShareClassHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	var req DTO.GrantRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ShareClassHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	grant, apiErr := service.ShareClass(ctx, currentUserID, classID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ShareClassHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, grant)
+}
+
+// ShareSheetHandler 授予用户单个工作表的权限
+func ShareSheetHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	sheetID, err := strconv.ParseInt(c.Param("sheet_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	var req DTO.GrantRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ShareSheetHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	grant, apiErr := service.ShareSheet(ctx, currentUserID, classID, sheetID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ShareSheetHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, grant)
+}
+
+// RevokeGrantHandler 撤销授权
+func RevokeGrantHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	grantID, err := strconv.ParseInt(c.Param("grant_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid grant_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RevokeGrant(ctx, currentUserID, classID, grantID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("RevokeGrantHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "撤销成功")
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// LockTokenHeader 修改被锁定的课程或单元格时携带锁 token 的请求头
+const LockTokenHeader = "X-Lock-Token"
+
+// AcquireLockHandler 获取课程或单元格的锁
+func AcquireLockHandler(c *gin.Context) {
+	var req DTO.LockRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("AcquireLockHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.AcquireLock(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("AcquireLock 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// RenewLockHandler 延长锁的有效期
+func RenewLockHandler(c *gin.Context) {
+	var req DTO.LockRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("RenewLockHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.RenewLock(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("RenewLock 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// ReleaseLockHandler 释放锁
+func ReleaseLockHandler(c *gin.Context) {
+	var req DTO.LockRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ReleaseLockHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.ReleaseLock(ctx, currentUserID, &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ReleaseLock 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "释放成功")
+}
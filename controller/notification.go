@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// ListNotificationsHandler 查询当前用户的站内通知，unread_only=true 时只返回未读
+func ListNotificationsHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	unreadOnly := c.Query("unread_only") == "true"
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.ListNotifications(ctx, currentUserID, unreadOnly, page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListNotifications 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// MarkNotificationReadHandler 将单条通知标记为已读
+func MarkNotificationReadHandler(c *gin.Context) {
+	notificationID, err := strconv.ParseInt(c.Param("notification_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "无效的通知ID")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.MarkNotificationRead(ctx, currentUserID, notificationID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("MarkNotificationRead 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "标记成功")
+}
+
+// MarkAllNotificationsReadHandler 将全部通知标记为已读
+func MarkAllNotificationsReadHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.MarkAllNotificationsRead(ctx, currentUserID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("MarkAllNotificationsRead 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "标记成功")
+}
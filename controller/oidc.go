@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// OIDCAuthorizeHandler 发起单点登录
+// @Summary 发起单点登录
+// @Description 返回身份提供方的授权地址和 code_verifier，前端按 state 保存 code_verifier（如 sessionStorage）后跳转，由身份提供方重定向回前端回调页
+// @Tags 登录
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/oidc/authorize [get]
+func OIDCAuthorizeHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	resp, apiErr := service.StartOIDCLogin(ctx)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.StartOIDCLogin() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// OIDCCallbackHandler 完成单点登录
+// @Summary 完成单点登录
+// @Description 前端回调页将身份提供方返回的 code、state 和发起登录时保存的 code_verifier 提交到该接口，换取本系统的 token
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param code body string true "授权码"
+// @Param state body string true "发起登录时返回的 state"
+// @Param code_verifier body string true "发起登录时返回的 code_verifier"
+// @Success 200 {object} Response
+// @Router /api/v1/oidc/callback [post]
+func OIDCCallbackHandler(c *gin.Context) {
+	var req DTO.OIDCCallbackRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("OIDCCallbackHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.FinishOIDCLogin(ctx, &req, c.ClientIP(), c.Request.UserAgent())
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.FinishOIDCLogin() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
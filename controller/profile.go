@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// GetProfileHandler 查询个人资料
+// @Summary 查询个人资料
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me [get]
+func GetProfileHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	profile, apiErr := service.GetProfile(ctx, currentUserID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.GetProfile() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, profile)
+}
+
+// UpdateProfileHandler 修改个人资料
+// @Summary 修改个人资料
+// @Description 修改显示名称和邮箱，未传的字段保持不变；修改邮箱后需要重新验证
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param display_name body string false "显示名称"
+// @Param email body string false "邮箱"
+// @Success 200 {object} Response
+// @Router /api/v1/me [put]
+func UpdateProfileHandler(c *gin.Context) {
+	var req DTO.UpdateProfileRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("UpdateProfileHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	profile, apiErr := service.UpdateProfile(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.UpdateProfile() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, profile)
+}
+
+// ChangePasswordHandler 修改密码
+// @Summary 修改密码
+// @Description 校验原密码后设置新密码，当前会话以外的登录状态全部失效
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param old_password body string true "原密码"
+// @Param new_password body string true "新密码"
+// @Success 200 {object} Response
+// @Router /api/v1/me/password [put]
+func ChangePasswordHandler(c *gin.Context) {
+	var req DTO.ChangePasswordRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ChangePasswordHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.ChangePassword(ctx, currentUserID, c.GetString(ContextFamilyIDKey), c.ClientIP(), &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.ChangePassword() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "密码已修改")
+}
+
+// DeleteAccountHandler 注销账号
+// @Summary 注销账号
+// @Description 校验密码后注销当前账号，全部登录状态随即失效
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param password body string true "当前密码"
+// @Success 200 {object} Response
+// @Router /api/v1/me [delete]
+func DeleteAccountHandler(c *gin.Context) {
+	var req DTO.DeleteAccountRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("DeleteAccountHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.DeleteAccount(ctx, currentUserID, c.ClientIP(), &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.DeleteAccount() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "账号已注销")
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// ContextRoleKey 是上下文中用户角色的key
+const ContextRoleKey = "role"
+
+// RequirePermission 校验当前用户是否拥有路由声明的权限，必须注册在 JWTAuthMiddleware 之后
+func RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDValue, exists := c.Get(ContextUserIDKey)
+		if !exists {
+			ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+			c.Abort()
+			return
+		}
+		userID, ok := userIDValue.(int64)
+		if !ok {
+			ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+			c.Abort()
+			return
+		}
+		role, apiErr := service.CheckPermission(c.Request.Context(), userID, perm)
+		if apiErr != nil {
+			ResponseErrorWithApiError(c, apiErr)
+			zap.L().Info("权限校验未通过",
+				zap.Int64("userID", userID),
+				zap.String("role", role),
+				zap.String("permission", string(perm)),
+				zap.String("path", c.FullPath()))
+			c.Abort()
+			return
+		}
+		if !twoFactorSatisfied(c, role) {
+			ResponseForbidden(c, "当前角色需要启用两步验证，请启用后重新登录")
+			c.Abort()
+			return
+		}
+		if scopes, ok := c.Get(ContextTokenScopesKey); ok && !rbac.ScopeAllows(scopes.([]string), perm) {
+			ResponseForbidden(c, "访问令牌的权限范围不足")
+			c.Abort()
+			return
+		}
+		c.Set(ContextRoleKey, role)
+		c.Next()
+	}
+}
+
+// AssignUserRoleHandler 为用户分配角色
+func AssignUserRoleHandler(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	var req DTO.AssignRoleRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("AssignUserRoleHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	user, apiErr := service.AssignUserRole(ctx, currentUserID, userID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("AssignUserRoleHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, user)
+}
+
+// UnlockUserHandler 解除用户的登录锁定
+func UnlockUserHandler(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.UnlockUserLogin(ctx, userID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("UnlockUserHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// SearchUsersHandler 管理员分页查询用户
+// 查询参数：q 匹配用户名、显示名称和邮箱；role 角色；status 为 active、disabled 或 deleted，不传时返回全部未注销的用户
+func SearchUsersHandler(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.SearchUsers(ctx, c.Query("q"), c.Query("role"), c.Query("status"), page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("SearchUsersHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// DisableUserHandler 停用用户
+func DisableUserHandler(c *gin.Context) {
+	setUserDisabled(c, true)
+}
+
+// EnableUserHandler 启用用户
+func EnableUserHandler(c *gin.Context) {
+	setUserDisabled(c, false)
+}
+
+func setUserDisabled(c *gin.Context, disabled bool) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	user, apiErr := service.SetUserDisabled(ctx, currentUserID, userID, disabled)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.SetUserDisabled() 失败", zap.Bool("disabled", disabled), zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, user)
+}
+
+// AdminResetPasswordHandler 管理员重置用户密码，不传 password 时向用户邮箱发送重置链接
+func AdminResetPasswordHandler(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	// 请求体可以为空，表示发送重置密码邮件
+	var req DTO.AdminResetPasswordRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("AdminResetPasswordHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.AdminResetPassword(ctx, currentUserID, userID, &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("AdminResetPasswordHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RestoreUserHandler 恢复已注销的用户
+func RestoreUserHandler(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	user, apiErr := service.RestoreUser(ctx, currentUserID, userID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("RestoreUserHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, user)
+}
@@ -2,8 +2,10 @@ package controller
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
 )
@@ -42,6 +44,12 @@ func ResponseErrorWithMsg(c *gin.Context, respCode code.RespCode, msg string) {
 	case code.ServerError:
 		ResponseInternalServerError(c, msg)
 		return
+	case code.NoPermission:
+		ResponseForbidden(c, msg)
+		return
+	case code.Locked:
+		ResponseLocked(c, msg)
+		return
 	default:
 		c.JSON(http.StatusBadRequest, Response{
 			Code: respCode,
@@ -66,6 +74,18 @@ func ResponseErrorWithApiError(c *gin.Context, apiError *apiError.ApiError) {
 	case code.ServerError:
 		ResponseInternalServerError(c, apiError.Msg)
 		return
+	case code.NoPermission:
+		ResponseForbidden(c, apiError.Msg)
+		return
+	case code.Locked:
+		ResponseLocked(c, apiError.Msg)
+		return
+	case code.CellConflict:
+		ResponseConflict(c, apiError.Code, apiError.Msg, apiError.Data)
+		return
+	case code.TooManyRequests:
+		ResponseTooManyRequests(c, apiError.Msg, apiError.Data)
+		return
 	default:
 		c.JSON(http.StatusBadRequest, Response{
 			Code: apiError.Code,
@@ -130,3 +150,46 @@ func ResponseInternalServerError(c *gin.Context, msg string) {
 		Data: nil,
 	})
 }
+
+// ResponseForbidden 没有权限响应
+// 返回 403 状态码
+func ResponseForbidden(c *gin.Context, msg string) {
+	c.JSON(http.StatusForbidden, Response{
+		Code: code.NoPermission,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponseLocked 资源被他人锁定响应
+// 返回 423 状态码
+func ResponseLocked(c *gin.Context, msg string) {
+	c.JSON(http.StatusLocked, Response{
+		Code: code.Locked,
+		Msg:  msg,
+		Data: nil,
+	})
+}
+
+// ResponseConflict 数据版本冲突响应，data 中携带服务端当前的数据
+// 返回 409 状态码
+func ResponseConflict(c *gin.Context, respCode code.RespCode, msg string, data interface{}) {
+	c.JSON(http.StatusConflict, Response{
+		Code: respCode,
+		Msg:  msg,
+		Data: data,
+	})
+}
+
+// ResponseTooManyRequests 请求过于频繁响应，data 为 DTO.RetryAfterDTO 时同时设置 Retry-After 头
+// 返回 429 状态码
+func ResponseTooManyRequests(c *gin.Context, msg string, data interface{}) {
+	if retry, ok := data.(*DTO.RetryAfterDTO); ok && retry.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(retry.RetryAfter))
+	}
+	c.JSON(http.StatusTooManyRequests, Response{
+		Code: code.TooManyRequests,
+		Msg:  msg,
+		Data: data,
+	})
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// ListSessionsHandler 查询登录会话
+// @Summary 查询登录会话
+// @Description 返回当前用户在各设备上的登录会话，current 为 true 的是发起请求的会话
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/sessions [get]
+func ListSessionsHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	sessions, apiErr := service.ListSessions(ctx, currentUserID, c.GetString(ContextFamilyIDKey))
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.ListSessions() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, sessions)
+}
+
+// RevokeSessionHandler 移除登录会话
+// @Summary 移除登录会话
+// @Description 该会话签发的 access token 和 refresh token 立即失效
+// @Tags 账号
+// @Produce json
+// @Param session_id path string true "会话ID"
+// @Success 200 {object} Response
+// @Router /api/v1/me/sessions/{session_id} [delete]
+func RevokeSessionHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RevokeSession(ctx, currentUserID, c.Param("session_id")); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.RevokeSession() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RevokeAllSessionsHandler 退出全部设备
+// @Summary 退出全部设备
+// @Description 撤销当前用户的全部登录会话（包括当前会话），此前签发的所有 token 立即失效
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/sessions [delete]
+func RevokeAllSessionsHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RevokeAllSessions(ctx, currentUserID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.RevokeAllSessions() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// CreateShareLinkHandler 创建公开分享链接
+func CreateShareLinkHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	var req DTO.CreateShareLinkRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("CreateShareLinkHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	link, apiErr := service.CreateShareLink(ctx, currentUserID, classID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("CreateShareLinkHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseCreated(c, link)
+}
+
+// ListShareLinksHandler 查询班级下的分享链接
+func ListShareLinksHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	links, apiErr := service.ListShareLinks(ctx, currentUserID, classID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListShareLinksHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, links)
+}
+
+// RevokeShareLinkHandler 撤销分享链接
+func RevokeShareLinkHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	linkID, err := strconv.ParseInt(c.Param("link_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid link_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RevokeShareLink(ctx, currentUserID, classID, linkID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("RevokeShareLinkHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "撤销成功")
+}
+
+// ExportSheetHandler 导出工作表为 CSV
+func ExportSheetHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	sheetID, err := strconv.ParseInt(c.Param("sheet_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	filename, data, apiErr := service.ExportSheetCSV(ctx, currentUserID, classID, sheetID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ExportSheetHandler 失败", zap.Error(apiErr))
+		return
+	}
+	responseCSV(c, filename, data)
+}
+
+// PublicShareHandler 访客通过分享链接查看班级信息
+func PublicShareHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	share, apiErr := service.GetPublicShare(ctx, c.Param("token"))
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	ResponseSuccess(c, share)
+}
+
+// PublicCellsHandler 访客通过分享链接查看工作表单元格
+func PublicCellsHandler(c *gin.Context) {
+	sheetID, err := strconv.ParseInt(c.Param("sheet_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	ctx := c.Request.Context()
+	cells, apiErr := service.GetPublicCells(ctx, c.Param("token"), sheetID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	ResponseSuccess(c, cells)
+}
+
+// PublicExportHandler 访客通过分享链接导出工作表
+func PublicExportHandler(c *gin.Context) {
+	sheetID, err := strconv.ParseInt(c.Param("sheet_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	ctx := c.Request.Context()
+	filename, data, apiErr := service.ExportPublicSheetCSV(ctx, c.Param("token"), sheetID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	responseCSV(c, filename, data)
+}
+
+// responseCSV 以附件形式返回 CSV 文件
+func responseCSV(c *gin.Context, filename string, data []byte) {
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
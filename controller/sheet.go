@@ -152,14 +152,29 @@ func UpdateSheetHandler(c *gin.Context) {
 
 // DeleteSheetHandler 删除工作表（逻辑删除）
 func DeleteSheetHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
 	sheetIDStr := c.Param("sheet_id")
 	sheetID, err := strconv.ParseInt(sheetIDStr, 10, 64)
 	if err != nil {
-		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
 		return
 	}
 	ctx := c.Request.Context()
-	apiErr := service.DeleteSheet(ctx, sheetID)
+	apiErr := service.DeleteSheet(ctx, currentUserID, classID, sheetID)
 	if apiErr != nil {
 		ResponseErrorWithApiError(c, apiErr)
 		zap.L().Error("DeleteSheetHandler failed", zap.Error(apiErr))
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/pkg/broadcast"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+)
+
+const (
+	sseHeartbeatPeriod = 25 * time.Second // 定期发送注释行，避免代理因连接空闲而断开
+	sseRetry           = 3000             // 建议客户端断线重连间隔，单位毫秒
+	sseResyncEvent     = "RESYNC"         // Last-Event-ID 已不在服务端缓冲区时发送，客户端需重新拉取全量数据
+)
+
+// SheetEventsHandler 以 Server-Sent Events 推送单张工作表的变更，事件与 WebSocket 推送的消息一致
+func SheetEventsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	sheetID, err := strconv.ParseInt(c.Param("sheet_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid sheet_id")
+		return
+	}
+	currentUserID, ok := sseUserID(c)
+	if !ok {
+		return
+	}
+	if apiErr := service.AuthorizeSheetSubscription(c.Request.Context(), currentUserID, classID, sheetID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	streamEvents(c, broadcast.SheetTopic(sheetID))
+}
+
+// ClassEventsHandler 以 Server-Sent Events 推送班级下所有工作表的变更
+func ClassEventsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	currentUserID, ok := sseUserID(c)
+	if !ok {
+		return
+	}
+	if apiErr := service.AuthorizeClassSubscription(c.Request.Context(), currentUserID, classID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		return
+	}
+	streamEvents(c, broadcast.ClassTopic(classID))
+}
+
+// sseUserID 读取当前登录用户，失败时已写回错误响应
+func sseUserID(c *gin.Context) (int64, bool) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return 0, false
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return 0, false
+	}
+	return currentUserID, true
+}
+
+// streamEvents 订阅主题并持续写出事件，直到客户端断开。
+// 客户端重连时携带 Last-Event-ID 请求头（或 last_event_id 查询参数），服务端从缓冲区补发之后的事件；
+// 主题空闲超过 10 分钟后缓冲区会被清空，此时发送 RESYNC 事件
+func streamEvents(c *gin.Context, topic string) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	sub, backlog, ok := broadcast.GetBus().SubscribeFrom(topic, lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	c.Status(200)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	if !ok {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", sseResyncEvent)
+	}
+	// 订阅后、读取历史前发布的消息会同时出现在 backlog 和订阅通道中，只发送一次
+	sent := make(map[string]struct{}, len(backlog))
+	for _, msg := range backlog {
+		writeEvent(c, msg)
+		sent[msg.ID] = struct{}{}
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(sseHeartbeatPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-sub.C():
+			if !ok {
+				// 订阅被关闭（消息积压过多），断开后由客户端携带 Last-Event-ID 重连
+				return
+			}
+			if _, ok := sent[msg.ID]; ok {
+				delete(sent, msg.ID)
+				continue
+			}
+			writeEvent(c, msg)
+			w.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, msg *broadcast.Message) {
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, msg.Data)
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// EnrollStudentsHandler 批量将学生加入班级
+func EnrollStudentsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	var req DTO.EnrollStudentsRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("EnrollStudentsHandler binding 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.EnrollStudents(ctx, currentUserID, classID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("EnrollStudentsHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// ListClassStudentsHandler 分页查询班级学生
+func ListClassStudentsHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page")
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid page_size")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.ListClassStudents(ctx, currentUserID, classID, page, pageSize)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListClassStudentsHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
+
+// RemoveClassStudentHandler 将学生移出班级
+func RemoveClassStudentHandler(c *gin.Context) {
+	classID, err := strconv.ParseInt(c.Param("class_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid class_id")
+		return
+	}
+	studentID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid user_id")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.RemoveClassStudent(ctx, currentUserID, classID, studentID); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("RemoveClassStudentHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, "移出成功")
+}
+
+// ListMyClassesHandler 查询当前用户所在的班级
+func ListMyClassesHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	classes, apiErr := service.ListMyClasses(ctx, currentUserID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("ListMyClassesHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, classes)
+}
+
+// MyTimetableHandler 查询学生个人课表
+// 传入 week 时返回整周课表，否则返回 date（默认今天）当天的课程
+func MyTimetableHandler(c *gin.Context) {
+	week, err := strconv.Atoi(c.DefaultQuery("week", "0"))
+	if err != nil || week < 0 {
+		ResponseErrorWithMsg(c, code.InvalidParam, "invalid week")
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	result, apiErr := service.GetMyTimetable(ctx, currentUserID, week, c.Query("date"))
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("MyTimetableHandler 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, result)
+}
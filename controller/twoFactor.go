@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/service"
+	"go.uber.org/zap"
+)
+
+// TwoFactorLoginHandler 登录第二步
+// @Summary 登录第二步
+// @Description 登录接口返回 two_factor_required 时，携带 challenge_token 和验证器中的验证码（或恢复码）换取 token
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param challenge_token body string true "登录接口返回的挑战 token"
+// @Param code body string false "6 位验证码"
+// @Param recovery_code body string false "恢复码，丢失验证器时使用"
+// @Success 200 {object} Response
+// @Router /api/v1/login/2fa [post]
+func TwoFactorLoginHandler(c *gin.Context) {
+	var req DTO.TwoFactorLoginRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("TwoFactorLoginHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.VerifyTwoFactorLogin(ctx, &req, c.ClientIP(), c.Request.UserAgent())
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Info("service.VerifyTwoFactorLogin() 失败", zap.String("ip", c.ClientIP()), zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// GetTwoFactorStatusHandler 查询两步验证状态
+// @Summary 查询两步验证状态
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/2fa [get]
+func GetTwoFactorStatusHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	status, apiErr := service.GetTwoFactorStatus(ctx, currentUserID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.GetTwoFactorStatus() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, status)
+}
+
+// EnrollTwoFactorHandler 获取两步验证密钥
+// @Summary 获取两步验证密钥
+// @Description 返回 TOTP 密钥和 otpauth 地址，使用验证器扫码后调用确认接口才会启用
+// @Tags 账号
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v1/me/2fa/enroll [post]
+func EnrollTwoFactorHandler(c *gin.Context) {
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.EnrollTwoFactor(ctx, currentUserID)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.EnrollTwoFactor() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// ConfirmTwoFactorHandler 确认启用两步验证
+// @Summary 确认启用两步验证
+// @Description 提交验证器生成的验证码，成功后返回恢复码（只返回一次）；需要重新登录才能获得通过两步验证的会话
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param code body string true "6 位验证码"
+// @Success 200 {object} Response
+// @Router /api/v1/me/2fa/confirm [post]
+func ConfirmTwoFactorHandler(c *gin.Context) {
+	var req DTO.TwoFactorCodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("ConfirmTwoFactorHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.ConfirmTwoFactor(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.ConfirmTwoFactor() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
+// DisableTwoFactorHandler 关闭两步验证
+// @Summary 关闭两步验证
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param password body string true "当前密码"
+// @Param code body string false "6 位验证码"
+// @Param recovery_code body string false "恢复码"
+// @Success 200 {object} Response
+// @Router /api/v1/me/2fa [delete]
+func DisableTwoFactorHandler(c *gin.Context) {
+	var req DTO.DisableTwoFactorRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("DisableTwoFactorHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	if apiErr := service.DisableTwoFactor(ctx, currentUserID, c.ClientIP(), &req); apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.DisableTwoFactor() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, nil)
+}
+
+// RegenerateRecoveryCodesHandler 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 之前的恢复码全部作废
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param code body string true "6 位验证码"
+// @Success 200 {object} Response
+// @Router /api/v1/me/2fa/recovery-codes [post]
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var req DTO.TwoFactorCodeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("RegenerateRecoveryCodesHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	userIDValue, exists := c.Get("user_id")
+	if !exists {
+		ResponseErrorWithMsg(c, code.InvalidAuth, "用户未登录")
+		return
+	}
+	currentUserID, ok := userIDValue.(int64)
+	if !ok {
+		ResponseErrorWithMsg(c, code.ServerError, "用户ID解析错误")
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiErr := service.RegenerateRecoveryCodes(ctx, currentUserID, &req)
+	if apiErr != nil {
+		ResponseErrorWithApiError(c, apiErr)
+		zap.L().Error("service.RegenerateRecoveryCodes() 失败", zap.Error(apiErr))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
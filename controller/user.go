@@ -58,16 +58,42 @@ func LoginHandler(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	resp, apiError := service.LoginService(ctx, &loginDTO)
+	resp, apiError := service.LoginService(ctx, &loginDTO, c.ClientIP(), c.Request.UserAgent())
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
-		zap.L().Error("AuthServiceInterface.LoginService() 失败", zap.Error(apiError))
+		zap.L().Info("AuthServiceInterface.LoginService() 失败", zap.String("ip", c.ClientIP()), zap.Error(apiError))
 		return
 	}
 	ResponseSuccess(c, resp)
 	return
 }
 
+// RefreshHandler 刷新 token
+// @Summary 刷新 token
+// @Description 使用 refresh token 换取新的 access token 和 refresh token，旧的 refresh token 随即失效
+// @Tags 登录
+// @Accept json
+// @Produce json
+// @Param refresh_token body string true "refresh token"
+// @Success 200 {object} Response
+// @Router /api/v1/refresh [post]
+func RefreshHandler(c *gin.Context) {
+	var req DTO.RefreshRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ResponseErrorWithMsg(c, code.InvalidParam, err.Error())
+		zap.L().Error("RefreshHandler.ShouldBindJSON() 失败", zap.Error(err))
+		return
+	}
+	ctx := c.Request.Context()
+	resp, apiError := service.RefreshService(ctx, req.RefreshToken)
+	if apiError != nil {
+		ResponseErrorWithApiError(c, apiError)
+		zap.L().Info("RefreshService() 失败", zap.Error(apiError))
+		return
+	}
+	ResponseSuccess(c, resp)
+}
+
 // LogoutHandler 退出登录
 // @Summary 退出登录
 // @Description 退出登录
@@ -81,7 +107,12 @@ func LoginHandler(c *gin.Context) {
 func LogoutHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	accessToken := c.Query("access_token")
-	apiError := service.LogoutService(ctx, accessToken)
+	refreshToken := c.Query("refresh_token")
+	if accessToken == "" && refreshToken == "" {
+		ResponseErrorWithMsg(c, code.InvalidParam, "请携带 access_token 或 refresh_token")
+		return
+	}
+	apiError := service.LogoutService(ctx, accessToken, refreshToken)
 	if apiError != nil {
 		ResponseErrorWithApiError(c, apiError)
 		return
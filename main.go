@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	mysql "github.com/sztu/mutli-table/DAO/MySQL"
 	"github.com/sztu/mutli-table/DAO/Redis"
 	"github.com/sztu/mutli-table/logger"
+	"github.com/sztu/mutli-table/pkg/broadcast"
+	"github.com/sztu/mutli-table/pkg/jwt"
 	"github.com/sztu/mutli-table/pkg/snowflake"
 	"github.com/sztu/mutli-table/router"
+	"github.com/sztu/mutli-table/service"
 	"github.com/sztu/mutli-table/settings"
 )
 
@@ -20,8 +24,20 @@ func main() {
 		fmt.Printf("初始化日志库失败,错误原因: %v\n", err)
 	}
 
+	if err := jwt.LoadKeys(); err != nil {
+		log.Fatalf("加载 JWT 密钥失败,错误原因: %v", err)
+	}
+
 	defer mysql.Close()
 	defer Redis.Close()
+	defer broadcast.Close()
+
+	// 启动后台任务
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.StartEmailOutboxWorker(ctx)
+	service.StartWebhookWorker(ctx)
+	service.StartAuditWorker(ctx)
 
 	// 初始化路由
 	r := router.SetupRouter()
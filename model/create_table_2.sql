@@ -8,8 +8,12 @@ CREATE TABLE `user`
     `id`          bigint(20)                             NOT NULL AUTO_INCREMENT COMMENT '自增主键，唯一标识用户记录',
     `user_id`     bigint(20)                             NOT NULL COMMENT '用户ID，用于业务中的用户唯一标识',
     `username`    varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户名，唯一且不区分大小写',
-    `password`    varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户密码，存储的是哈希值',
+    `display_name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '显示名称，为空时使用用户名',
+    `password`    varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户密码，存储的是带算法标识的哈希值',
     `email`       varchar(64) COLLATE utf8mb4_general_ci COMMENT '用户邮箱，可为空',
+    `email_verified` tinyint(1)                          NOT NULL DEFAULT 0 COMMENT '邮箱是否已验证',
+    `role`        ENUM('admin', 'scheduler', 'teacher', 'student', 'viewer') NOT NULL DEFAULT 'teacher' COMMENT '用户角色',
+    `disabled`    tinyint(1)                             NOT NULL DEFAULT 0 COMMENT '是否已被管理员停用，停用后不能登录',
     `create_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP COMMENT '记录的创建时间',
     `update_time` timestamp                              NULL     DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '记录的最后更新时间',
     `delete_time` bigint                           NULL DEFAULT 0 COMMENT '逻辑删除时间，NULL表示未删除',
@@ -25,6 +29,70 @@ CREATE TABLE `user`
   COLLATE = utf8mb4_general_ci
     COMMENT = '用户信息表：存储用户基本信息及状态';
 
+-- 外部身份表：记录 OIDC 等外部身份提供方的账号与本地用户的绑定关系
+DROP TABLE IF EXISTS `user_identity`;
+CREATE TABLE `user_identity` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '本地用户ID',
+  `provider` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '身份提供方标识，OIDC 为 issuer',
+  `subject` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '用户在身份提供方的唯一标识（sub）',
+  `email` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '最近一次登录时身份提供方返回的邮箱',
+  `last_login_time` timestamp NULL DEFAULT NULL COMMENT '最近一次通过该身份登录的时间',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0 COMMENT '解绑时间，0 表示有效',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_provider_subject` (`provider`, `subject`, `delete_time`),
+  INDEX `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='外部身份绑定表';
+
+-- 个人访问令牌表：供脚本和第三方集成调用接口
+DROP TABLE IF EXISTS `api_token`;
+CREATE TABLE `api_token` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '所属用户ID',
+  `name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '令牌名称，如“夜间导出脚本”',
+  `token_hash` char(64) NOT NULL COMMENT '令牌的 SHA-256，明文只在创建时返回一次',
+  `token_prefix` varchar(16) NOT NULL COMMENT '令牌前几位，用于在列表中辨认令牌',
+  `scopes` varchar(255) NOT NULL COMMENT '权限范围，逗号分隔：read、export、write',
+  `expire_time` timestamp NULL DEFAULT NULL COMMENT '过期时间，NULL 表示永不过期',
+  `last_used_time` timestamp NULL DEFAULT NULL COMMENT '最近一次使用时间',
+  `last_used_ip` varchar(64) NOT NULL DEFAULT '' COMMENT '最近一次使用的来源 IP',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0 COMMENT '撤销时间，0 表示有效',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_token_hash` (`token_hash`),
+  INDEX `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='个人访问令牌表';
+
+-- 两步验证表：每个用户一个 TOTP 密钥，确认绑定后才生效
+DROP TABLE IF EXISTS `user_totp`;
+CREATE TABLE `user_totp` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '用户ID',
+  `secret` varchar(64) NOT NULL COMMENT 'base32 编码的 TOTP 密钥',
+  `enabled` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已确认绑定，未确认的密钥不参与登录校验',
+  `last_used_step` bigint NOT NULL DEFAULT 0 COMMENT '最近一次通过校验的时间步，同一验证码不能重复使用',
+  `enable_time` timestamp NULL DEFAULT NULL COMMENT '确认绑定时间',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='两步验证表';
+
+-- 两步验证恢复码表：丢失验证器时使用，每个恢复码只能使用一次
+DROP TABLE IF EXISTS `user_recovery_code`;
+CREATE TABLE `user_recovery_code` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '用户ID',
+  `code_hash` char(64) NOT NULL COMMENT '恢复码的 SHA-256，明文只在生成时返回一次',
+  `used_time` timestamp NULL DEFAULT NULL COMMENT '使用时间，NULL 表示未使用',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='两步验证恢复码表';
+
 -- 班级表
 DROP TABLE IF EXISTS `class`;
 CREATE TABLE `class` (
@@ -100,16 +168,180 @@ CREATE TABLE `draggable_class_sheet` (
   PRIMARY KEY (`item_id`, `class_id`),
   INDEX `idx_sheet` (`class_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='元素-课表关联表';
--- 权限管理表
+-- 权限管理表：sheet_id 为 0 时表示授予整个班级的权限，班级下的工作表继承该权限
 DROP TABLE IF EXISTS `permission`;
 CREATE TABLE `permission` (
   `id` bigint(20) NOT NULL AUTO_INCREMENT,
   `user_id` bigint(20) NOT NULL COMMENT '用户ID',
-  `sheet_id` bigint(20) NOT NULL COMMENT '工作表ID',
-  -- `access_level` enum('READ','WRITE','ADMIN') NOT NULL DEFAULT 'READ',
+  `class_id` bigint(20) NOT NULL COMMENT '班级ID',
+  `sheet_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '工作表ID，0 表示整个班级',
+  `access_level` enum('read','edit','admin') NOT NULL DEFAULT 'read' COMMENT '权限级别',
+  `granted_by` bigint(20) NOT NULL DEFAULT 0 COMMENT '授权人ID',
   `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `delete_time` bigint NULL DEFAULT 0,
   PRIMARY KEY (`id`),
-  UNIQUE INDEX `idx_user_sheet` (`user_id`, `sheet_id`, `delete_time`)
+  UNIQUE INDEX `idx_user_class_sheet` (`user_id`, `class_id`, `sheet_id`, `delete_time`),
+  INDEX `idx_class` (`class_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='权限控制表';
+
+-- 班级学生表：学生可以同时属于多个班级，并因此获得这些班级课表的查看权限
+DROP TABLE IF EXISTS `class_student`;
+CREATE TABLE `class_student` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `class_id` bigint(20) NOT NULL COMMENT '班级ID',
+  `user_id` bigint(20) NOT NULL COMMENT '学生用户ID',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_class_user` (`class_id`, `user_id`, `delete_time`),
+  INDEX `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='班级学生表';
+
+-- 公开分享链接表：持有 token 的访客无需登录即可查看班级或单个工作表的课表
+DROP TABLE IF EXISTS `share_link`;
+CREATE TABLE `share_link` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `token_hash` char(64) NOT NULL COMMENT 'token 的 SHA-256，明文 token 只在创建时返回一次',
+  `token_prefix` varchar(16) NOT NULL COMMENT 'token 前几位，用于在列表中辨认链接',
+  `class_id` bigint(20) NOT NULL COMMENT '班级ID',
+  `sheet_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '工作表ID，0 表示整个班级',
+  `creator_id` bigint(20) NOT NULL COMMENT '创建者ID',
+  `description` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '备注',
+  `expire_time` timestamp NULL DEFAULT NULL COMMENT '过期时间，NULL 表示永不过期',
+  `access_count` bigint NOT NULL DEFAULT 0 COMMENT '访问次数',
+  `last_access_time` timestamp NULL DEFAULT NULL COMMENT '最近一次访问时间',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0 COMMENT '撤销时间，0 表示有效',
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_token_hash` (`token_hash`),
+  INDEX `idx_class` (`class_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='公开分享链接表';
+
+
+-- 变更申请表：非排课员对课表的移动/移除操作需经排课员审核
+DROP TABLE IF EXISTS `change_request`;
+CREATE TABLE `change_request` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `class_id` bigint(20) NOT NULL COMMENT '班级ID',
+  `sheet_id` bigint(20) NOT NULL COMMENT '工作表ID',
+  `item_id` bigint(20) NOT NULL COMMENT '可拖放元素ID',
+  `action` ENUM('move', 'remove') NOT NULL COMMENT '操作类型：移动/移除',
+  `target_row` int NOT NULL COMMENT '目标行号（移除时为所在行号）',
+  `target_col` int NOT NULL COMMENT '目标列号（移除时为所在列号）',
+  `comment` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '申请说明',
+  `status` ENUM('pending', 'approved', 'rejected', 'cancelled') NOT NULL DEFAULT 'pending' COMMENT '状态',
+  `proposer_id` bigint(20) NOT NULL COMMENT '申请人ID',
+  `reviewer_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '审核人ID',
+  `reject_reason` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '驳回原因',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  INDEX `idx_class_status` (`class_id`, `status`),
+  INDEX `idx_proposer` (`proposer_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='变更申请表';
+
+-- 站内通知表
+DROP TABLE IF EXISTS `notification`;
+CREATE TABLE `notification` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `user_id` bigint(20) NOT NULL COMMENT '接收者ID',
+  `type` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '通知类型：item_placed/item_removed',
+  `title` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '标题',
+  `content` varchar(1024) COLLATE utf8mb4_general_ci NOT NULL COMMENT '内容',
+  `class_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '关联班级ID',
+  `sheet_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '关联工作表ID',
+  `item_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '关联可拖放元素ID',
+  `is_read` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已读',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  INDEX `idx_user_read` (`user_id`, `is_read`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='站内通知表';
+
+-- 邮件发件箱：通知邮件先落库，再由后台协程异步投递
+DROP TABLE IF EXISTS `email_outbox`;
+CREATE TABLE `email_outbox` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `to_address` varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '收件人邮箱',
+  `subject` varchar(255) COLLATE utf8mb4_general_ci NOT NULL COMMENT '邮件主题',
+  `body` text COLLATE utf8mb4_general_ci NOT NULL COMMENT '邮件正文',
+  `status` ENUM('pending', 'sending', 'sent', 'failed') NOT NULL DEFAULT 'pending' COMMENT '投递状态',
+  `attempts` int NOT NULL DEFAULT 0 COMMENT '已投递次数',
+  `last_error` varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '最近一次投递错误',
+  `next_attempt_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '下次投递时间',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_status_next` (`status`, `next_attempt_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='邮件发件箱';
+
+-- webhook 订阅表
+DROP TABLE IF EXISTS `webhook_subscription`;
+CREATE TABLE `webhook_subscription` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `creator_id` bigint(20) NOT NULL COMMENT '创建者ID',
+  `url` varchar(512) COLLATE utf8mb4_general_ci NOT NULL COMMENT '回调地址',
+  `secret` varchar(128) COLLATE utf8mb4_general_ci NOT NULL COMMENT '签名密钥',
+  `event_types` varchar(512) COLLATE utf8mb4_general_ci NOT NULL COMMENT '订阅的事件类型，逗号分隔',
+  `class_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '只订阅指定班级的事件，0表示全部',
+  `description` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '备注',
+  `is_active` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `delete_time` bigint NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  INDEX `idx_creator` (`creator_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='webhook订阅表';
+
+-- webhook 投递记录表
+DROP TABLE IF EXISTS `webhook_delivery`;
+CREATE TABLE `webhook_delivery` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `subscription_id` bigint(20) NOT NULL COMMENT '订阅ID',
+  `event_id` varchar(32) COLLATE utf8mb4_general_ci NOT NULL COMMENT '事件ID，同一事件投递到多个订阅时相同',
+  `event_type` varchar(64) COLLATE utf8mb4_general_ci NOT NULL COMMENT '事件类型',
+  `payload` text COLLATE utf8mb4_general_ci NOT NULL COMMENT '请求体',
+  `status` ENUM('pending', 'sending', 'success', 'failed') NOT NULL DEFAULT 'pending' COMMENT '投递状态',
+  `attempts` int NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+  `response_status` int NOT NULL DEFAULT 0 COMMENT '最近一次响应状态码',
+  `response_body` varchar(1024) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '最近一次响应内容（截断）',
+  `last_error` varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '最近一次错误',
+  `next_attempt_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '下次尝试时间',
+  `create_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `update_time` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_subscription` (`subscription_id`),
+  INDEX `idx_status_next` (`status`, `next_attempt_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='webhook投递记录表';
+
+-- 审计日志表：记录每个非 GET 请求的操作人、路由、目标对象和结果，超过保留期限后由后台任务清理
+DROP TABLE IF EXISTS `audit_log`;
+CREATE TABLE `audit_log` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `actor_id` bigint(20) NOT NULL DEFAULT 0 COMMENT '操作人用户ID，未登录的请求为0',
+  `actor_name` varchar(64) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '操作人用户名',
+  `auth_type` ENUM('anonymous', 'session', 'token') NOT NULL DEFAULT 'anonymous' COMMENT '认证方式：未登录、登录会话、访问令牌',
+  `method` varchar(8) NOT NULL COMMENT 'HTTP 方法',
+  `route` varchar(255) NOT NULL COMMENT '路由模板，如 /api/v1/classes/:class_id',
+  `path` varchar(512) COLLATE utf8mb4_general_ci NOT NULL COMMENT '实际请求路径',
+  `entity_type` varchar(32) NOT NULL DEFAULT '' COMMENT '主要目标对象类型，取路由中最后一个 *_id 参数，如 class、sheet',
+  `entity_id` varchar(64) NOT NULL DEFAULT '' COMMENT '主要目标对象ID',
+  `targets` varchar(512) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '路由中全部参数，JSON 对象',
+  `summary` text COLLATE utf8mb4_general_ci NOT NULL COMMENT '请求摘要：敏感字段已脱敏、超长时截断的请求体',
+  `status` int NOT NULL DEFAULT 0 COMMENT 'HTTP 状态码',
+  `result_code` int NOT NULL DEFAULT 0 COMMENT '响应中的业务码',
+  `result_msg` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '响应中的提示信息',
+  `ip` varchar(64) NOT NULL DEFAULT '' COMMENT '客户端 IP',
+  `user_agent` varchar(255) COLLATE utf8mb4_general_ci NOT NULL DEFAULT '' COMMENT '客户端 User-Agent',
+  `latency_ms` int NOT NULL DEFAULT 0 COMMENT '处理耗时，单位毫秒',
+  `create_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_create_time` (`create_time`),
+  INDEX `idx_actor_time` (`actor_id`, `create_time`),
+  INDEX `idx_entity_time` (`entity_type`, `entity_id`, `create_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='审计日志表';
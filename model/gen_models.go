@@ -19,12 +19,24 @@ func Genretate() {
 	// 生成所有表对应的模型
 	g.ApplyBasic(
 		g.GenerateModel("user"),
+		g.GenerateModel("user_identity"),
+		g.GenerateModel("api_token"),
+		g.GenerateModel("user_totp"),
+		g.GenerateModel("user_recovery_code"),
 		g.GenerateModel("class"),
 		g.GenerateModel("sheet"),
 		g.GenerateModel("cell"),
 		g.GenerateModel("draggable_item"),
 		g.GenerateModel("draggable_class_sheet"),
 		g.GenerateModel("permission"),
+		g.GenerateModel("class_student"),
+		g.GenerateModel("share_link"),
+		g.GenerateModel("change_request"),
+		g.GenerateModel("notification"),
+		g.GenerateModel("email_outbox"),
+		g.GenerateModel("webhook_subscription"),
+		g.GenerateModel("webhook_delivery"),
+		g.GenerateModel("audit_log"),
 	)
 
 	g.Execute()
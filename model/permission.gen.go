@@ -12,12 +12,15 @@ const TableNamePermission = "permission"
 
 // Permission 权限控制表
 type Permission struct {
-	ID         int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	UserID     int64     `gorm:"column:user_id;not null;comment:用户ID" json:"user_id"`    // 用户ID
-	SheetID    int64     `gorm:"column:sheet_id;not null;comment:工作表ID" json:"sheet_id"` // 工作表ID
-	CreateTime time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP" json:"create_time"`
-	UpdateTime time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP" json:"update_time"`
-	DeleteTime int64     `gorm:"column:delete_time" json:"delete_time"`
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	UserID      int64     `gorm:"column:user_id;not null;comment:用户ID" json:"user_id"`                        // 用户ID
+	ClassID     int64     `gorm:"column:class_id;not null;comment:班级ID" json:"class_id"`                      // 班级ID
+	SheetID     int64     `gorm:"column:sheet_id;not null;comment:工作表ID，0 表示整个班级" json:"sheet_id"`            // 工作表ID，0 表示整个班级
+	AccessLevel string    `gorm:"column:access_level;not null;default:read;comment:权限级别" json:"access_level"` // 权限级别
+	GrantedBy   int64     `gorm:"column:granted_by;not null;comment:授权人ID" json:"granted_by"`                 // 授权人ID
+	CreateTime  time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP" json:"create_time"`
+	UpdateTime  time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP" json:"update_time"`
+	DeleteTime  int64     `gorm:"column:delete_time" json:"delete_time"`
 }
 
 // TableName Permission's table name
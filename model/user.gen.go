@@ -15,8 +15,12 @@ type User struct {
 	ID         int64     `gorm:"column:id;primaryKey;autoIncrement:true;comment:自增主键，唯一标识用户记录" json:"id"`           // 自增主键，唯一标识用户记录
 	UserID     int64     `gorm:"column:user_id;not null;comment:用户ID，用于业务中的用户唯一标识" json:"user_id"`                  // 用户ID，用于业务中的用户唯一标识
 	Username   string    `gorm:"column:username;not null;comment:用户名，唯一且不区分大小写" json:"username"`                    // 用户名，唯一且不区分大小写
-	Password   string    `gorm:"column:password;not null;comment:用户密码，存储的是哈希值" json:"password"`                     // 用户密码，存储的是哈希值
+	DisplayName string   `gorm:"column:display_name;not null;comment:显示名称，为空时使用用户名" json:"display_name"`            // 显示名称，为空时使用用户名
+	Password   string    `gorm:"column:password;not null;comment:用户密码，存储的是带算法标识的哈希值" json:"password"`                     // 用户密码，存储的是带算法标识的哈希值
 	Email      string    `gorm:"column:email;comment:用户邮箱，可为空" json:"email"`                                        // 用户邮箱，可为空
+	EmailVerified bool   `gorm:"column:email_verified;not null;comment:邮箱是否已验证" json:"email_verified"`                // 邮箱是否已验证
+	Role       string    `gorm:"column:role;not null;default:teacher;comment:用户角色" json:"role"`                      // 用户角色
+	Disabled   bool      `gorm:"column:disabled;not null;comment:是否已被管理员停用，停用后不能登录" json:"disabled"`           // 是否已被管理员停用，停用后不能登录
 	CreateTime time.Time `gorm:"column:create_time;default:CURRENT_TIMESTAMP;comment:记录的创建时间" json:"create_time"`   // 记录的创建时间
 	UpdateTime time.Time `gorm:"column:update_time;default:CURRENT_TIMESTAMP;comment:记录的最后更新时间" json:"update_time"` // 记录的最后更新时间
 	DeleteTime int64     `gorm:"column:delete_time;comment:逻辑删除时间，NULL表示未删除" json:"delete_time"`                    // 逻辑删除时间，NULL表示未删除
@@ -5,6 +5,7 @@ import "github.com/sztu/mutli-table/pkg/code"
 type ApiError struct {
 	Code code.RespCode `json:"code"`
 	Msg  string        `json:"msg"`
+	Data interface{}   `json:"data,omitempty"` // 附加信息，如冲突时的最新数据
 }
 
 func (e ApiError) Error() string {
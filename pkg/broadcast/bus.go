@@ -0,0 +1,118 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sztu/mutli-table/DAO/Redis"
+	"github.com/sztu/mutli-table/pkg/snowflake"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+const (
+	// subscriptionBuffer 每个订阅者的缓冲区大小，缓冲区写满说明订阅者处理过慢，将被断开
+	subscriptionBuffer = 256
+	// historySize 每个主题保留的最近消息数，用于 SSE 断线后按 Last-Event-ID 补发
+	historySize = 200
+	// historyTTL 主题没有订阅者、也没有新消息超过该时长后丢弃其历史消息，断线超过该时长的客户端需要重新拉取全量数据
+	historyTTL = 10 * time.Minute
+)
+
+var (
+	bus  Bus
+	once sync.Once
+)
+
+// Message 广播消息，ID 全局唯一，Data 为推送给客户端的 JSON
+type Message struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewMessage 生成带唯一 ID 的消息
+func NewMessage(msgType string, v interface{}) (*Message, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	id, err := snowflake.GetID()
+	if err != nil {
+		return nil, err
+	}
+	return &Message{ID: strconv.FormatInt(id, 10), Type: msgType, Data: data}, nil
+}
+
+// Bus 按主题（如某张工作表）发布/订阅消息。
+// 单实例部署使用 MemoryBus；多实例部署使用 RedisBus，任一实例发布的消息会送达所有实例上的订阅者
+type Bus interface {
+	// Publish 向主题的所有订阅者发送消息，不会因订阅者处理慢而阻塞
+	Publish(ctx context.Context, topic string, msg *Message) error
+	// Subscribe 订阅主题，使用完毕后需调用 Subscription.Close
+	Subscribe(topic string) *Subscription
+	// SubscribeFrom 订阅主题，并返回 lastEventID 之后的历史消息；
+	// lastEventID 为空时不返回历史，ok 为 false 表示 lastEventID 已不在缓冲区中，客户端需要重新拉取全量数据。
+	// 订阅与读取历史之间发布的消息可能同时出现在 backlog 和订阅通道中，调用方需按 ID 去重
+	SubscribeFrom(topic, lastEventID string) (sub *Subscription, backlog []*Message, ok bool)
+	// Close 关闭消息总线，释放底层连接
+	Close() error
+}
+
+// Subscription 一个主题订阅，消息从 C() 读取；被关闭后 C() 返回的通道也会关闭
+type Subscription struct {
+	ch     chan *Message
+	once   sync.Once
+	cancel func()
+}
+
+// C 返回接收消息的通道
+func (s *Subscription) C() <-chan *Message {
+	return s.ch
+}
+
+// Close 取消订阅并关闭消息通道，可重复调用
+func (s *Subscription) Close() {
+	s.once.Do(s.cancel)
+}
+
+// GetBus 返回全局唯一的消息总线，实现由配置 broadcast.driver 决定
+func GetBus() Bus {
+	once.Do(func() {
+		cfg := settings.GetConfig().BroadcastConfig
+		switch cfg.Driver {
+		case "redis":
+			bus = NewRedisBus(Redis.GetRedisClient(), cfg.ChannelPrefix)
+		case "memory", "":
+			bus = NewMemoryBus()
+		default:
+			zap.L().Warn("未知的广播驱动，使用进程内广播", zap.String("driver", cfg.Driver))
+			bus = NewMemoryBus()
+		}
+	})
+	return bus
+}
+
+// Close 关闭全局消息总线
+func Close() {
+	if bus == nil {
+		return
+	}
+	if err := bus.Close(); err != nil {
+		zap.L().Error("关闭消息总线失败", zap.Error(err))
+	}
+}
+
+// SheetTopic 返回工作表对应的主题名
+func SheetTopic(sheetID int64) string {
+	return fmt.Sprintf("sheet:%d", sheetID)
+}
+
+// ClassTopic 返回班级对应的主题名，班级下所有工作表的消息都会发布到该主题
+func ClassTopic(classID int64) string {
+	return fmt.Sprintf("class:%d", classID)
+}
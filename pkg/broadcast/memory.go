@@ -0,0 +1,162 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBus 进程内的消息总线，只能送达同一进程中的订阅者。
+// 每个主题保留最近 historySize 条消息，供断线重连的客户端补发；
+// 主题没有订阅者、也没有新消息超过 historyTTL 后丢弃其历史，避免长期运行时积累所有出现过的主题
+type MemoryBus struct {
+	mu          sync.Mutex
+	topics      map[string]map[*Subscription]struct{}
+	keepHistory bool
+	history     map[string][]*Message
+	idleSince   map[string]time.Time // 没有订阅者的主题及其变为空闲的时间，只记录有历史消息的主题
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+// NewMemoryBus 创建进程内消息总线
+func NewMemoryBus() *MemoryBus {
+	return newMemoryBus(true)
+}
+
+func newMemoryBus(keepHistory bool) *MemoryBus {
+	return &MemoryBus{
+		topics:      make(map[string]map[*Subscription]struct{}),
+		keepHistory: keepHistory,
+		history:     make(map[string][]*Message),
+		idleSince:   make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Subscribe 订阅主题
+func (b *MemoryBus) Subscribe(topic string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.subscribeLocked(topic)
+}
+
+// SubscribeFrom 订阅主题并返回 lastEventID 之后的历史消息
+func (b *MemoryBus) SubscribeFrom(topic, lastEventID string) (*Subscription, []*Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub := b.subscribeLocked(topic)
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	backlog, ok := messagesAfter(b.history[topic], lastEventID)
+	return sub, backlog, ok
+}
+
+// messagesAfter 返回 history 中 lastEventID 之后的消息，找不到 lastEventID 时 ok 为 false
+func messagesAfter(history []*Message, lastEventID string) ([]*Message, bool) {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ID == lastEventID {
+			backlog := make([]*Message, len(history)-i-1)
+			copy(backlog, history[i+1:])
+			return backlog, true
+		}
+	}
+	return nil, false
+}
+
+func (b *MemoryBus) subscribeLocked(topic string) *Subscription {
+	b.sweepLocked()
+	sub := &Subscription{ch: make(chan *Message, subscriptionBuffer)}
+	sub.cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if subs, ok := b.topics[topic]; ok {
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(b.topics, topic)
+				b.markIdleLocked(topic)
+			}
+		}
+		close(sub.ch)
+	}
+
+	subs, ok := b.topics[topic]
+	if !ok {
+		subs = make(map[*Subscription]struct{})
+		b.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	delete(b.idleSince, topic)
+	return sub
+}
+
+// Publish 记录到主题历史并发送给所有订阅者；缓冲区已满的订阅者会被关闭，由客户端重连后重新同步
+func (b *MemoryBus) Publish(_ context.Context, topic string, msg *Message) error {
+	var slow []*Subscription
+	b.mu.Lock()
+	b.sweepLocked()
+	if b.keepHistory {
+		history := append(b.history[topic], msg)
+		if len(history) > historySize {
+			history = history[len(history)-historySize:]
+		}
+		b.history[topic] = history
+		if _, ok := b.topics[topic]; !ok {
+			b.markIdleLocked(topic)
+		}
+	}
+
+	for sub := range b.topics[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range slow {
+		sub.Close()
+	}
+	return nil
+}
+
+// markIdleLocked 记录主题变为空闲的时间，新消息会重新计时
+func (b *MemoryBus) markIdleLocked(topic string) {
+	if _, ok := b.history[topic]; ok {
+		b.idleSince[topic] = b.now()
+	}
+}
+
+// sweepLocked 丢弃空闲超过 historyTTL 的主题历史，最多每分钟检查一次
+func (b *MemoryBus) sweepLocked() {
+	now := b.now()
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+	b.lastSweep = now
+	for topic, since := range b.idleSince {
+		if now.Sub(since) >= historyTTL {
+			delete(b.history, topic)
+			delete(b.idleSince, topic)
+		}
+	}
+}
+
+// Close 关闭所有订阅
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	var subs []*Subscription
+	for _, topicSubs := range b.topics {
+		for sub := range topicSubs {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testMessage(id string) *Message {
+	return &Message{ID: id, Type: "TEST", Data: []byte(`{}`)}
+}
+
+// receive 读取订阅通道中当前已有的消息 ID
+func receive(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case msg, ok := <-sub.C():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, msg.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryBusFanOut(t *testing.T) {
+	tests := []struct {
+		name    string
+		subs    []string // 每个订阅者订阅的主题
+		publish []string // 依次向这些主题发布消息，消息 ID 为序号
+		want    [][]string
+	}{
+		{
+			name:    "单个订阅者",
+			subs:    []string{"sheet:1"},
+			publish: []string{"sheet:1", "sheet:1"},
+			want:    [][]string{{"0", "1"}},
+		},
+		{
+			name:    "同一主题的所有订阅者都收到",
+			subs:    []string{"sheet:1", "sheet:1", "sheet:1"},
+			publish: []string{"sheet:1"},
+			want:    [][]string{{"0"}, {"0"}, {"0"}},
+		},
+		{
+			name:    "只收到订阅主题的消息",
+			subs:    []string{"sheet:1", "sheet:2", "class:1"},
+			publish: []string{"sheet:1", "class:1", "sheet:2", "sheet:1"},
+			want:    [][]string{{"0", "3"}, {"2"}, {"1"}},
+		},
+		{
+			name:    "没有消息",
+			subs:    []string{"sheet:1"},
+			publish: nil,
+			want:    [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryBus()
+			defer bus.Close()
+			subs := make([]*Subscription, len(tt.subs))
+			for i, topic := range tt.subs {
+				subs[i] = bus.Subscribe(topic)
+			}
+			for i, topic := range tt.publish {
+				if err := bus.Publish(context.Background(), topic, testMessage(string(rune('0'+i)))); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+			for i, sub := range subs {
+				if got := receive(sub); !equalIDs(got, tt.want[i]) {
+					t.Errorf("订阅者 %d 收到 %v，期望 %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryBusUnsubscribe(t *testing.T) {
+	bus := NewMemoryBus()
+	defer bus.Close()
+	kept := bus.Subscribe("sheet:1")
+	closed := bus.Subscribe("sheet:1")
+
+	closed.Close()
+	closed.Close() // 可重复调用
+	if _, ok := <-closed.C(); ok {
+		t.Fatal("取消订阅后通道应被关闭")
+	}
+
+	if err := bus.Publish(context.Background(), "sheet:1", testMessage("1")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got := receive(kept); !equalIDs(got, []string{"1"}) {
+		t.Errorf("未取消的订阅者收到 %v，期望 [1]", got)
+	}
+
+	kept.Close()
+	bus.mu.Lock()
+	_, ok := bus.topics["sheet:1"]
+	bus.mu.Unlock()
+	if ok {
+		t.Error("最后一个订阅者取消后主题应被删除")
+	}
+}
+
+func TestMemoryBusSlowSubscriberClosed(t *testing.T) {
+	bus := NewMemoryBus()
+	defer bus.Close()
+	slow := bus.Subscribe("sheet:1")
+	for i := 0; i <= subscriptionBuffer; i++ {
+		if err := bus.Publish(context.Background(), "sheet:1", testMessage("x")); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	count := 0
+	for range slow.C() {
+		count++
+	}
+	if count != subscriptionBuffer {
+		t.Errorf("缓冲区写满后订阅应被关闭，收到 %d 条，期望 %d 条", count, subscriptionBuffer)
+	}
+}
+
+func TestMemoryBusSubscribeFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventID string
+		wantBacklog []string
+		wantOK      bool
+	}{
+		{name: "不带 Last-Event-ID", lastEventID: "", wantBacklog: nil, wantOK: true},
+		{name: "补发之后的消息", lastEventID: "1", wantBacklog: []string{"2", "3"}, wantOK: true},
+		{name: "已是最新", lastEventID: "3", wantBacklog: []string{}, wantOK: true},
+		{name: "不在缓冲区中", lastEventID: "9", wantBacklog: nil, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryBus()
+			defer bus.Close()
+			for _, id := range []string{"1", "2", "3"} {
+				if err := bus.Publish(context.Background(), "sheet:1", testMessage(id)); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+			sub, backlog, ok := bus.SubscribeFrom("sheet:1", tt.lastEventID)
+			defer sub.Close()
+			var got []string
+			if backlog != nil {
+				got = []string{}
+				for _, msg := range backlog {
+					got = append(got, msg.ID)
+				}
+			}
+			if ok != tt.wantOK || !equalIDs(got, tt.wantBacklog) || (got == nil) != (tt.wantBacklog == nil) {
+				t.Errorf("SubscribeFrom() = %v, %v，期望 %v, %v", got, ok, tt.wantBacklog, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMemoryBusHistoryExpires(t *testing.T) {
+	now := time.Now()
+	bus := NewMemoryBus()
+	bus.now = func() time.Time { return now }
+	defer bus.Close()
+
+	if err := bus.Publish(context.Background(), "sheet:1", testMessage("1")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	sub := bus.Subscribe("sheet:2")
+
+	// 有订阅者时历史不会过期
+	watching := bus.Subscribe("sheet:1")
+	now = now.Add(2 * historyTTL)
+	if _, _, ok := bus.SubscribeFrom("sheet:3", ""); !ok {
+		t.Fatal("SubscribeFrom() ok = false")
+	}
+	if s, _, ok := bus.SubscribeFrom("sheet:1", "1"); !ok {
+		t.Fatal("有订阅者的主题历史不应过期")
+	} else {
+		s.Close()
+	}
+
+	// 最后一个订阅者离开并空闲超过 historyTTL 后历史被丢弃
+	watching.Close()
+	now = now.Add(historyTTL + time.Minute)
+	s, _, ok := bus.SubscribeFrom("sheet:1", "1")
+	s.Close()
+	if ok {
+		t.Error("空闲超过 historyTTL 的主题历史应被丢弃")
+	}
+	sub.Close()
+}
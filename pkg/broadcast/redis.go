@@ -0,0 +1,121 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// RedisBus 基于 Redis pub/sub 的消息总线。
+// 每个实例只持有一个 PSUBSCRIBE 连接，收到的消息再通过进程内的 MemoryBus 分发给本实例的订阅者，
+// 因此订阅者数量不会增加 Redis 连接数。
+// 历史消息保存在 Redis 中每个主题一个的定长 stream 里，客户端重连到任一实例都能按 Last-Event-ID 补发
+type RedisBus struct {
+	client *redis.Client
+	prefix string
+	local  *MemoryBus
+	pubsub *redis.PubSub
+}
+
+// NewRedisBus 创建 Redis 消息总线，prefix 为 Redis 频道名和历史 stream 键名的前缀，用于与其他业务隔离
+func NewRedisBus(client *redis.Client, prefix string) *RedisBus {
+	b := &RedisBus{
+		client: client,
+		prefix: prefix,
+		local:  newMemoryBus(false),
+	}
+	b.pubsub = client.PSubscribe(context.Background(), prefix+"*")
+	go b.forward(b.pubsub.Channel())
+	return b
+}
+
+// forward 将 Redis 收到的消息转发给本实例的订阅者，连接断开时 go-redis 会自动重连并重新订阅
+func (b *RedisBus) forward(ch <-chan *redis.Message) {
+	for m := range ch {
+		topic := strings.TrimPrefix(m.Channel, b.prefix)
+		var msg Message
+		if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+			zap.L().Error("解析广播消息失败", zap.String("topic", topic), zap.Error(err))
+			continue
+		}
+		if err := b.local.Publish(context.Background(), topic, &msg); err != nil {
+			zap.L().Error("转发广播消息失败", zap.String("topic", topic), zap.Error(err))
+		}
+	}
+}
+
+// historyKey 主题历史 stream 的键名
+func (b *RedisBus) historyKey(topic string) string {
+	return b.prefix + "history:" + topic
+}
+
+// Publish 写入主题历史并发布到 Redis，由所有实例（包括本实例）的 forward 分发
+func (b *RedisBus) Publish(ctx context.Context, topic string, msg *Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	key := b.historyKey(topic)
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: historySize,
+			Approx: true,
+			Values: map[string]interface{}{"msg": payload},
+		})
+		pipe.Expire(ctx, key, historyTTL)
+		pipe.Publish(ctx, b.prefix+topic, payload)
+		return nil
+	})
+	return err
+}
+
+// Subscribe 订阅主题
+func (b *RedisBus) Subscribe(topic string) *Subscription {
+	return b.local.Subscribe(topic)
+}
+
+// SubscribeFrom 订阅主题并返回 Redis 中保存的 lastEventID 之后的历史消息。
+// 先订阅再读取历史，两者之间发布的消息可能重复出现，由调用方按 ID 去重
+func (b *RedisBus) SubscribeFrom(topic, lastEventID string) (*Subscription, []*Message, bool) {
+	sub := b.local.Subscribe(topic)
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	history, err := b.readHistory(context.Background(), topic)
+	if err != nil {
+		zap.L().Error("读取广播历史失败", zap.String("topic", topic), zap.Error(err))
+		return sub, nil, false
+	}
+	backlog, ok := messagesAfter(history, lastEventID)
+	return sub, backlog, ok
+}
+
+// readHistory 按发布顺序读取主题的历史消息
+func (b *RedisBus) readHistory(ctx context.Context, topic string) ([]*Message, error) {
+	entries, err := b.client.XRange(ctx, b.historyKey(topic), "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*Message, 0, len(entries))
+	for _, entry := range entries {
+		payload, _ := entry.Values["msg"].(string)
+		var msg Message
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			zap.L().Warn("解析广播历史失败", zap.String("topic", topic), zap.String("entryID", entry.ID), zap.Error(err))
+			continue
+		}
+		history = append(history, &msg)
+	}
+	return history, nil
+}
+
+// Close 取消 Redis 订阅并关闭本实例的所有订阅
+func (b *RedisBus) Close() error {
+	err := b.pubsub.Close()
+	b.local.Close()
+	return err
+}
@@ -0,0 +1,80 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisBusForward(t *testing.T) {
+	payload := func(id string) string {
+		data, err := json.Marshal(testMessage(id))
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		return string(data)
+	}
+	tests := []struct {
+		name     string
+		messages []*redis.Message
+		want     map[string][]string // 主题 => 本地订阅者收到的消息 ID
+	}{
+		{
+			name: "按频道名去掉前缀后转发到对应主题",
+			messages: []*redis.Message{
+				{Channel: "test:sheet:1", Payload: payload("1")},
+				{Channel: "test:class:1", Payload: payload("2")},
+				{Channel: "test:sheet:1", Payload: payload("3")},
+			},
+			want: map[string][]string{"sheet:1": {"1", "3"}, "class:1": {"2"}, "sheet:2": nil},
+		},
+		{
+			name: "跳过无法解析的消息",
+			messages: []*redis.Message{
+				{Channel: "test:sheet:1", Payload: "not json"},
+				{Channel: "test:sheet:1", Payload: payload("2")},
+			},
+			want: map[string][]string{"sheet:1": {"2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &RedisBus{prefix: "test:", local: newMemoryBus(false)}
+			defer bus.local.Close()
+			subs := make(map[string]*Subscription)
+			for topic := range tt.want {
+				subs[topic] = bus.Subscribe(topic)
+			}
+
+			ch := make(chan *redis.Message, len(tt.messages))
+			for _, m := range tt.messages {
+				ch <- m
+			}
+			close(ch)
+			done := make(chan struct{})
+			go func() {
+				bus.forward(ch)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("forward 未退出")
+			}
+
+			for topic, want := range tt.want {
+				if got := receive(subs[topic]); !equalIDs(got, want) {
+					t.Errorf("主题 %s 收到 %v，期望 %v", topic, got, want)
+				}
+			}
+			bus.local.mu.Lock()
+			historyLen := len(bus.local.history)
+			bus.local.mu.Unlock()
+			if historyLen != 0 {
+				t.Errorf("历史消息保存在 Redis 中，本地不应保留历史，实际有 %d 个主题", historyLen)
+			}
+		})
+	}
+}
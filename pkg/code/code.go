@@ -15,6 +15,9 @@ const (
 	TimeOut
 	NoPermission
 	NotFound
+	Locked
+	CellConflict
+	TooManyRequests
 )
 
 var codeMsg = map[RespCode]string{
@@ -30,6 +33,9 @@ var codeMsg = map[RespCode]string{
 	TimeOut:               "超时",
 	NoPermission:          "没有权限",
 	NotFound:              "无法找到对应资源",
+	Locked:                "资源已被锁定",
+	CellConflict:          "单元格已被修改",
+	TooManyRequests:       "请求过于频繁",
 }
 
 func (c RespCode) GetMsg() string {
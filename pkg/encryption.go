@@ -8,6 +8,8 @@ import (
 )
 
 // EncryptPassword 用于加密密码
+//
+// Deprecated: 仅用于校验旧版 MD5 密码哈希，新密码请使用 password.Hash。
 func EncryptPassword(password string) string {
 	secret := settings.GetConfig().PasswordSecret
 	h := md5.New()
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"time"
@@ -8,8 +10,6 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var mySecret = "111"
-
 const (
 	// AccessTokenName 是访问令牌的key
 	AccessTokenName = "access"
@@ -21,11 +21,41 @@ type MyClaims struct {
 	UserID    int64  `json:"user_id"`
 	Username  string `json:"username"`
 	TokenType string `json:"token_type"`
+	// FamilyID 同一次登录签发的所有 token 属于同一个家族，刷新时轮换 refresh token 但家族不变，
+	// 家族被撤销后其中所有 token 立即失效
+	FamilyID string `json:"family_id,omitempty"`
+	// Generation 签发时用户的 token 代数，用户“退出全部设备”后代数递增，旧代数的 token 全部失效
+	Generation int64 `json:"gen,omitempty"`
+	// TwoFactor 本次登录是否通过了两步验证，刷新后保持不变
+	TwoFactor bool `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成 accessToken
-func GenerateToken[T int64 | string | uint](userID T, username string) (accessToken string, err error) {
+// TokenPair 登录或刷新时签发的一对 token
+type TokenPair struct {
+	AccessToken    string
+	RefreshToken   string
+	RefreshTokenID string // refresh token 的 jti，用于轮换和重复使用检测
+}
+
+// TokenSession 同一次登录签发的 token 共有的信息
+type TokenSession struct {
+	FamilyID   string
+	Generation int64
+	TwoFactor  bool
+}
+
+// NewTokenID 生成随机的 token ID，也用作 token 家族 ID
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateTokenPair 生成 accessToken 和 refreshToken
+func GenerateTokenPair[T int64 | string | uint](userID T, username string, session TokenSession, accessTTL, refreshTTL time.Duration) (*TokenPair, error) {
 	var int64UserID int64
 	switch v := any(userID).(type) {
 	case uint:
@@ -36,41 +66,68 @@ func GenerateToken[T int64 | string | uint](userID T, username string) (accessTo
 		// 尝试将 string 转为 uint
 		parsedID, err := strconv.ParseUint(v, 10, 32) // 假设 uint 是 32 位
 		if err != nil {
-			return "", fmt.Errorf("invalid userID format, could not convert to uint: %v", err)
+			return nil, fmt.Errorf("invalid userID format, could not convert to uint: %v", err)
 		}
 		int64UserID = int64(parsedID)
 	default:
-		return "", fmt.Errorf("unsupported userID type")
+		return nil, fmt.Errorf("unsupported userID type")
 	}
 
+	keys, err := getKeySet()
+	if err != nil {
+		return nil, err
+	}
+	signing := keys.signing
+
 	// 定义生成 token 的闭包函数
-	generate := func(userID int64, username string, tokenType string, validTime time.Duration) (string, error) {
+	generate := func(userID int64, username string, tokenType string, validTime time.Duration) (string, string, error) {
+		tokenID, err := NewTokenID()
+		if err != nil {
+			return "", "", err
+		}
 		claims := MyClaims{
-			UserID:    userID,
-			Username:  username,
-			TokenType: tokenType,
+			UserID:     userID,
+			Username:   username,
+			TokenType:  tokenType,
+			FamilyID:   session.FamilyID,
+			Generation: session.Generation,
+			TwoFactor:  session.TwoFactor,
 			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        tokenID,
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(validTime)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
 				Issuer:    "Ethen",
 			},
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		return token.SignedString([]byte(mySecret))
+		token := jwt.NewWithClaims(signing.method, claims)
+		token.Header["kid"] = signing.kid
+		signed, err := token.SignedString(signing.privateKey)
+		return signed, tokenID, err
 	}
 
-	accessToken, err = generate(int64UserID, username, AccessTokenName, time.Hour*24*7)
+	accessToken, _, err := generate(int64UserID, username, AccessTokenName, accessTTL)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate access token: %v", err)
+		return nil, fmt.Errorf("failed to generate access token: %v", err)
+	}
+	refreshToken, refreshTokenID, err := generate(int64UserID, username, RefreshTokenName, refreshTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %v", err)
 	}
 
-	return accessToken, nil
+	return &TokenPair{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		RefreshTokenID: refreshTokenID,
+	}, nil
 }
 
 // ParseToken 解析token
 func ParseToken(tokenString string) (*MyClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(mySecret), nil
-	})
+	keys, err := getKeySet()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &MyClaims{}, keys.keyFunc)
 	if err != nil {
 		return nil, err
 	}
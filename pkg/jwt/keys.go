@@ -0,0 +1,248 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultKid 未配置 keys、只配置了 secret 时使用的密钥 kid
+	defaultKid = "default"
+	// devSecret 非 release 模式下未配置任何密钥时使用的开发密钥，任何人都可以用它伪造 token，release 模式下拒绝使用
+	devSecret = "mutli-table-dev-secret"
+	// minSecretLength release 模式下 HS256 密钥的最小长度，单位字节
+	minSecretLength = 32
+)
+
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey interface{} // 为 nil 时只能用于校验
+	publicKey  interface{}
+}
+
+type keySet struct {
+	signing *signingKey
+	byKid   map[string]*signingKey
+	// legacy 用于校验没有 kid 头的旧 token，取第一个 HS256 密钥
+	legacy *signingKey
+}
+
+var (
+	keysOnce sync.Once
+	keys     *keySet
+	keysErr  error
+)
+
+// LoadKeys 从配置中加载签名密钥，服务启动时调用以便尽早发现配置错误
+func LoadKeys() error {
+	keysOnce.Do(func() {
+		conf := settings.GetConfig()
+		keys, keysErr = loadKeySet(conf.JWTConfig, conf.Mode == "release")
+	})
+	return keysErr
+}
+
+func getKeySet() (*keySet, error) {
+	if err := LoadKeys(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// loadKeySet 加载全部密钥，release 为 true 时拒绝空密钥、开发密钥和过短的 HS256 密钥
+func loadKeySet(conf *settings.JWTConfig, release bool) (*keySet, error) {
+	if conf == nil {
+		return nil, errors.New("未配置 jwt")
+	}
+	cfgs := conf.Keys
+	signingKid := conf.SigningKid
+	if len(cfgs) == 0 {
+		secret := conf.Secret
+		if secret == "" {
+			if release {
+				return nil, errors.New("未配置 JWT 签名密钥，请设置环境变量 JWT_SECRET 或 jwt.keys")
+			}
+			zap.L().Warn("未配置 JWT 签名密钥，使用开发密钥，请勿在生产环境中使用")
+			secret = devSecret
+		}
+		cfgs = []settings.JWTKeyConfig{{Kid: defaultKid, Algorithm: jwt.SigningMethodHS256.Alg(), Secret: secret}}
+		signingKid = defaultKid
+	}
+
+	set := &keySet{byKid: make(map[string]*signingKey, len(cfgs))}
+	for _, c := range cfgs {
+		key, err := loadKey(c, release)
+		if err != nil {
+			return nil, fmt.Errorf("加载 JWT 密钥 %q 失败: %w", c.Kid, err)
+		}
+		if _, ok := set.byKid[key.kid]; ok {
+			return nil, fmt.Errorf("JWT 密钥 kid %q 重复", key.kid)
+		}
+		set.byKid[key.kid] = key
+		if set.legacy == nil && key.method == jwt.SigningMethodHS256 {
+			set.legacy = key
+		}
+		if set.signing == nil && key.privateKey != nil && (signingKid == "" || signingKid == key.kid) {
+			set.signing = key
+		}
+	}
+	if set.signing == nil {
+		return nil, fmt.Errorf("找不到用于签发 token 的密钥 %q，签发密钥必须配置私钥", signingKid)
+	}
+	return set, nil
+}
+
+func loadKey(c settings.JWTKeyConfig, release bool) (*signingKey, error) {
+	if c.Kid == "" {
+		return nil, errors.New("kid 不能为空")
+	}
+	key := &signingKey{kid: c.Kid}
+	switch c.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret := os.ExpandEnv(c.Secret)
+		if secret == "" {
+			return nil, errors.New("HS256 密钥的 secret 不能为空")
+		}
+		if release && secret == devSecret {
+			return nil, errors.New("release 模式下不能使用开发密钥")
+		}
+		if release && len(secret) < minSecretLength {
+			return nil, fmt.Errorf("release 模式下 HS256 密钥至少需要 %d 字节", minSecretLength)
+		}
+		key.method = jwt.SigningMethodHS256
+		key.privateKey = []byte(secret)
+		key.publicKey = []byte(secret)
+	case jwt.SigningMethodRS256.Alg():
+		key.method = jwt.SigningMethodRS256
+		if c.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(os.ExpandEnv(c.PrivateKeyFile))
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey = priv
+			key.publicKey = &priv.PublicKey
+		}
+		if c.PublicKeyFile != "" {
+			pem, err := os.ReadFile(os.ExpandEnv(c.PublicKeyFile))
+			if err != nil {
+				return nil, err
+			}
+			if key.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		key.method = jwt.SigningMethodEdDSA
+		if c.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(os.ExpandEnv(c.PrivateKeyFile))
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey = priv
+			key.publicKey = priv.(ed25519.PrivateKey).Public()
+		}
+		if c.PublicKeyFile != "" {
+			pem, err := os.ReadFile(os.ExpandEnv(c.PublicKeyFile))
+			if err != nil {
+				return nil, err
+			}
+			if key.publicKey, err = jwt.ParseEdPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("不支持的签名算法 %q", c.Algorithm)
+	}
+	if key.publicKey == nil {
+		return nil, errors.New("至少需要配置私钥或公钥其中之一")
+	}
+	return key, nil
+}
+
+// keyFunc 按 token 头部的 kid 选择校验密钥，并要求 token 的签名算法与密钥一致，防止算法混淆攻击
+func (s *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := s.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = s.byKid[kid]
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.publicKey, nil
+}
+
+// JSONWebKey JWKS 中的一个公钥
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA 模数
+	E   string `json:"e,omitempty"`   // RSA 公钥指数
+	Crv string `json:"crv,omitempty"` // EdDSA 曲线
+	X   string `json:"x,omitempty"`   // EdDSA 公钥
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS 返回全部非对称密钥的公钥，HS256 密钥不会公开
+func JWKS() (*JSONWebKeySet, error) {
+	set, err := getKeySet()
+	if err != nil {
+		return nil, err
+	}
+	return set.jwks(), nil
+}
+
+func (s *keySet) jwks() *JSONWebKeySet {
+	result := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(s.byKid))}
+	for _, key := range s.byKid {
+		switch pub := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			result.Keys = append(result.Keys, JSONWebKey{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			result.Keys = append(result.Keys, JSONWebKey{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(result.Keys, func(i, j int) bool { return result.Keys[i].Kid < result.Keys[j].Kid })
+	return result
+}
@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sztu/mutli-table/settings"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestLoadKeySetSecretPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    *settings.JWTConfig
+		release bool
+		wantErr string
+	}{
+		{name: "release 模式未配置密钥", conf: &settings.JWTConfig{}, release: true, wantErr: "未配置 JWT 签名密钥"},
+		{name: "release 模式使用开发密钥", conf: &settings.JWTConfig{Secret: devSecret}, release: true, wantErr: "开发密钥"},
+		{name: "release 模式密钥过短", conf: &settings.JWTConfig{Secret: "short-secret"}, release: true, wantErr: "至少需要 32 字节"},
+		{
+			name: "release 模式 keys 中的 HS256 密钥过短",
+			conf: &settings.JWTConfig{Keys: []settings.JWTKeyConfig{
+				{Kid: "k1", Algorithm: jwt.SigningMethodHS256.Alg(), Secret: "short-secret"},
+			}},
+			release: true,
+			wantErr: "至少需要 32 字节",
+		},
+		{name: "release 模式密钥足够长", conf: &settings.JWTConfig{Secret: testSecret}, release: true},
+		{name: "非 release 模式未配置时使用开发密钥", conf: &settings.JWTConfig{}},
+		{name: "非 release 模式允许较短的密钥", conf: &settings.JWTConfig{Secret: "short-secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := loadKeySet(tt.conf, tt.release)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadKeySet() error = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadKeySet() error = %v", err)
+			}
+			if set.signing == nil || set.signing.method != jwt.SigningMethodHS256 {
+				t.Errorf("签发密钥 = %+v，期望 HS256", set.signing)
+			}
+		})
+	}
+}
+
+// testKeys 在临时目录中生成 RSA 和 Ed25519 密钥文件
+type testKeys struct {
+	rsa         *rsa.PrivateKey
+	ed          ed25519.PrivateKey
+	rsaPriv     string
+	rsaPub      string
+	rsaPubPEM   []byte
+	edPriv      string
+	edPub       ed25519.PublicKey
+	hs256Secret string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 Ed25519 密钥失败: %v", err)
+	}
+	writePEM := func(name, blockType string, der []byte) (string, []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("写入密钥文件失败: %v", err)
+		}
+		return path, data
+	}
+	keys := &testKeys{rsa: rsaKey, ed: edKey, edPub: edPub, hs256Secret: testSecret}
+	keys.rsaPriv, _ = writePEM("rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("编码 RSA 公钥失败: %v", err)
+	}
+	keys.rsaPub, keys.rsaPubPEM = writePEM("rsa.pub.pem", "PUBLIC KEY", pubDER)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("编码 Ed25519 私钥失败: %v", err)
+	}
+	keys.edPriv, _ = writePEM("ed.pem", "PRIVATE KEY", edDER)
+	return keys
+}
+
+// sign 签发测试 token，kid 为空时不设置 kid 头
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, &MyClaims{
+		UserID:    1,
+		Username:  "alice",
+		TokenType: AccessTokenName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("签发 token 失败: %v", err)
+	}
+	return signed
+}
+
+func TestKeySetKeyFunc(t *testing.T) {
+	k := newTestKeys(t)
+	// 轮换后：新的 EdDSA 密钥用于签发，旧的 RS256 密钥只保留公钥用于校验，另有一个 HS256 密钥兼容没有 kid 的旧 token
+	set, err := loadKeySet(&settings.JWTConfig{
+		SigningKid: "2026-10",
+		Keys: []settings.JWTKeyConfig{
+			{Kid: "2026-10", Algorithm: jwt.SigningMethodEdDSA.Alg(), PrivateKeyFile: k.edPriv},
+			{Kid: "2026-04", Algorithm: jwt.SigningMethodRS256.Alg(), PublicKeyFile: k.rsaPub},
+			{Kid: "legacy", Algorithm: jwt.SigningMethodHS256.Alg(), Secret: k.hs256Secret},
+		},
+	}, true)
+	if err != nil {
+		t.Fatalf("loadKeySet() error = %v", err)
+	}
+	if set.signing.kid != "2026-10" {
+		t.Fatalf("签发密钥 kid = %q，期望 2026-10", set.signing.kid)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		wantOK bool
+	}{
+		{name: "当前签发密钥", token: sign(t, jwt.SigningMethodEdDSA, "2026-10", k.ed), wantOK: true},
+		{name: "轮换后只保留公钥的旧密钥", token: sign(t, jwt.SigningMethodRS256, "2026-04", k.rsa), wantOK: true},
+		{name: "没有 kid 的旧 HS256 token", token: sign(t, jwt.SigningMethodHS256, "", []byte(k.hs256Secret)), wantOK: true},
+		{name: "未知 kid", token: sign(t, jwt.SigningMethodRS256, "2025-10", k.rsa)},
+		// 算法混淆：用公开的 RSA 公钥作为 HMAC 密钥伪造 token
+		{name: "RS256 密钥的 token 改用 HS256 签名", token: sign(t, jwt.SigningMethodHS256, "2026-04", k.rsaPubPEM)},
+		{name: "kid 指向 HS256 密钥但使用 RS256 签名", token: sign(t, jwt.SigningMethodRS256, "legacy", k.rsa)},
+		{name: "没有 kid 但使用 RS256 签名", token: sign(t, jwt.SigningMethodRS256, "", k.rsa)},
+		{name: "HS256 密钥不正确", token: sign(t, jwt.SigningMethodHS256, "legacy", []byte("another-secret-another-secret-00"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &MyClaims{}
+			_, err := jwt.ParseWithClaims(tt.token, claims, set.keyFunc)
+			if tt.wantOK {
+				if err != nil {
+					t.Fatalf("校验 token 失败: %v", err)
+				}
+				if claims.UserID != 1 {
+					t.Errorf("UserID = %d，期望 1", claims.UserID)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("token 应被拒绝")
+			}
+		})
+	}
+}
+
+func TestKeySetLegacyRequiresHS256Key(t *testing.T) {
+	k := newTestKeys(t)
+	set, err := loadKeySet(&settings.JWTConfig{Keys: []settings.JWTKeyConfig{
+		{Kid: "2026-04", Algorithm: jwt.SigningMethodRS256.Alg(), PrivateKeyFile: k.rsaPriv},
+	}}, true)
+	if err != nil {
+		t.Fatalf("loadKeySet() error = %v", err)
+	}
+	if _, err := jwt.Parse(sign(t, jwt.SigningMethodRS256, "", k.rsa), set.keyFunc); err == nil {
+		t.Error("没有 HS256 密钥时，没有 kid 的 token 应被拒绝")
+	}
+}
+
+func TestLoadKeySetErrors(t *testing.T) {
+	k := newTestKeys(t)
+	tests := []struct {
+		name    string
+		conf    *settings.JWTConfig
+		wantErr string
+	}{
+		{
+			name: "签发密钥只有公钥",
+			conf: &settings.JWTConfig{SigningKid: "2026-04", Keys: []settings.JWTKeyConfig{
+				{Kid: "2026-04", Algorithm: jwt.SigningMethodRS256.Alg(), PublicKeyFile: k.rsaPub},
+			}},
+			wantErr: "找不到用于签发 token 的密钥",
+		},
+		{
+			name: "kid 重复",
+			conf: &settings.JWTConfig{Keys: []settings.JWTKeyConfig{
+				{Kid: "k1", Algorithm: jwt.SigningMethodEdDSA.Alg(), PrivateKeyFile: k.edPriv},
+				{Kid: "k1", Algorithm: jwt.SigningMethodRS256.Alg(), PublicKeyFile: k.rsaPub},
+			}},
+			wantErr: "重复",
+		},
+		{
+			name: "不支持的算法",
+			conf: &settings.JWTConfig{Keys: []settings.JWTKeyConfig{
+				{Kid: "k1", Algorithm: "none"},
+			}},
+			wantErr: "不支持的签名算法",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadKeySet(tt.conf, true); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadKeySet() error = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	k := newTestKeys(t)
+	set, err := loadKeySet(&settings.JWTConfig{
+		SigningKid: "b-ed",
+		Keys: []settings.JWTKeyConfig{
+			{Kid: "b-ed", Algorithm: jwt.SigningMethodEdDSA.Alg(), PrivateKeyFile: k.edPriv},
+			{Kid: "a-rsa", Algorithm: jwt.SigningMethodRS256.Alg(), PublicKeyFile: k.rsaPub},
+			{Kid: "c-hs", Algorithm: jwt.SigningMethodHS256.Alg(), Secret: k.hs256Secret},
+		},
+	}, true)
+	if err != nil {
+		t.Fatalf("loadKeySet() error = %v", err)
+	}
+	got := set.jwks().Keys
+	want := []JSONWebKey{
+		{
+			Kty: "RSA", Kid: "a-rsa", Use: "sig", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(k.rsa.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.rsa.E)).Bytes()),
+		},
+		{Kty: "OKP", Kid: "b-ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(k.edPub)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("JWKS 包含 %d 个密钥，期望 %d 个（HS256 密钥不能公开）: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("JWKS[%d] = %+v，期望 %+v", i, got[i], want[i])
+		}
+	}
+}
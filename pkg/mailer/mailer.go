@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string // 纯文本正文
+}
+
+// Sender 邮件发送器，不同实现对应不同的投递方式
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var (
+	sender Sender
+	once   sync.Once
+)
+
+// GetSender 根据配置获取邮件发送器
+// 使用单例模式，确保发送器只初始化一次
+func GetSender() Sender {
+	once.Do(func() {
+		sender = NewSender(settings.GetConfig().MailConfig)
+	})
+	return sender
+}
+
+// NewSender 根据配置中的 driver 创建发送器，未知的 driver 回退为写本地文件
+func NewSender(cfg *settings.MailConfig) Sender {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPSender(cfg)
+	case "log":
+		return NewLogSender(cfg.LogPath)
+	default:
+		zap.L().Warn("未知的邮件发送方式，使用本地文件发送", zap.String("driver", cfg.Driver))
+		return NewLogSender(cfg.LogPath)
+	}
+}
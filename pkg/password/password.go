@@ -0,0 +1,162 @@
+// Package password 负责用户密码的哈希与校验。
+//
+// 新密码使用 argon2id（PHC 格式 $argon2id$v=19$m=..,t=..,p=..$salt$hash）或 bcrypt（$2a$/$2b$/$2y$）存储，
+// 哈希串本身记录了算法和参数；不带算法前缀的旧 MD5 哈希仍可校验，并在登录成功后由调用方重新计算。
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sztu/mutli-table/pkg"
+	"github.com/sztu/mutli-table/settings"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// Unusable 没有本地密码的用户（如通过 OIDC 创建的用户）存储该值，任何密码都无法通过校验
+const Unusable = "!"
+
+// ErrMalformedHash 存储的哈希串无法解析
+var ErrMalformedHash = errors.New("密码哈希格式错误")
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+type params struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+}
+
+// currentParams 读取配置中的哈希参数，未配置或配置非法时回落到默认值
+func currentParams() params {
+	p := params{
+		algorithm:  AlgorithmArgon2id,
+		bcryptCost: 12,
+		argon2:     argon2Params{memory: 64 * 1024, time: 3, threads: 2},
+	}
+	conf := settings.GetConfig().PasswordConfig
+	if conf == nil {
+		return p
+	}
+	if conf.Algorithm == AlgorithmBcrypt {
+		p.algorithm = AlgorithmBcrypt
+	}
+	if conf.BcryptCost >= bcrypt.MinCost && conf.BcryptCost <= bcrypt.MaxCost {
+		p.bcryptCost = conf.BcryptCost
+	}
+	if conf.Argon2Memory > 0 {
+		p.argon2.memory = conf.Argon2Memory
+	}
+	if conf.Argon2Time > 0 {
+		p.argon2.time = conf.Argon2Time
+	}
+	if conf.Argon2Threads > 0 {
+		p.argon2.threads = conf.Argon2Threads
+	}
+	return p
+}
+
+// Hash 按当前配置的算法计算密码哈希，每次调用使用新的随机盐
+func Hash(password string) (string, error) {
+	p := currentParams()
+	if p.algorithm == AlgorithmBcrypt {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), p.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(h), nil
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.argon2.time, p.argon2.memory, p.argon2.threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.argon2.memory, p.argon2.time, p.argon2.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 校验密码是否与存储的哈希匹配。
+// needsRehash 为 true 表示哈希使用的是旧算法或与当前配置不同的参数，调用方应在校验通过后重新计算并保存。
+func Verify(password, encoded string) (ok bool, needsRehash bool, err error) {
+	p := currentParams()
+	switch {
+	case encoded == "" || strings.HasPrefix(encoded, Unusable):
+		return false, false, nil
+
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		hp, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, hp.time, hp.memory, hp.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		return true, p.algorithm != AlgorithmArgon2id || hp != p.argon2, nil
+
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+		return true, p.algorithm != AlgorithmBcrypt || cost != p.bcryptCost, nil
+
+	default:
+		// 旧版 MD5 哈希，校验通过后一律需要升级
+		legacy := pkg.EncryptPassword(password)
+		if subtle.ConstantTimeCompare([]byte(legacy), []byte(encoded)) != 1 {
+			return false, false, nil
+		}
+		return true, true, nil
+	}
+}
+
+// decodeArgon2id 解析 $argon2id$v=19$m=65536,t=3,p=2$salt$hash
+func decodeArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var hp argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return hp, nil, nil, ErrMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return hp, nil, nil, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hp.memory, &hp.time, &hp.threads); err != nil {
+		return hp, nil, nil, ErrMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return hp, nil, nil, ErrMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return hp, nil, nil, ErrMalformedHash
+	}
+	return hp, salt, key, nil
+}
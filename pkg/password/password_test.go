@@ -0,0 +1,166 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sztu/mutli-table/pkg"
+	"github.com/sztu/mutli-table/settings"
+)
+
+// 测试使用较低的计算成本，避免测试过慢
+var (
+	fastArgon2 = settings.PasswordConfig{Algorithm: AlgorithmArgon2id, BcryptCost: 4, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1}
+	fastBcrypt = settings.PasswordConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 4, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1}
+)
+
+// usePasswordConfig 替换哈希参数，测试结束后恢复
+func usePasswordConfig(t *testing.T, conf settings.PasswordConfig) {
+	t.Helper()
+	c := settings.GetConfig()
+	old := c.PasswordConfig
+	c.PasswordConfig = &conf
+	t.Cleanup(func() { c.PasswordConfig = old })
+}
+
+func TestHashVerifyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   settings.PasswordConfig
+		prefix string
+	}{
+		{name: "argon2id", conf: fastArgon2, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", conf: fastBcrypt, prefix: "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePasswordConfig(t, tt.conf)
+			encoded, err := Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !strings.HasPrefix(encoded, tt.prefix) {
+				t.Fatalf("Hash() = %q，期望前缀 %q", encoded, tt.prefix)
+			}
+			if again, _ := Hash("correct horse"); again == encoded {
+				t.Error("每次哈希应使用不同的盐")
+			}
+
+			ok, needsRehash, err := Verify("correct horse", encoded)
+			if err != nil || !ok || needsRehash {
+				t.Errorf("Verify(正确密码) = %v, %v, %v，期望 true, false, nil", ok, needsRehash, err)
+			}
+			ok, needsRehash, err = Verify("wrong horse", encoded)
+			if err != nil || ok || needsRehash {
+				t.Errorf("Verify(错误密码) = %v, %v, %v，期望 false, false, nil", ok, needsRehash, err)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacyMD5(t *testing.T) {
+	usePasswordConfig(t, fastArgon2)
+	legacy := pkg.EncryptPassword("old-password")
+
+	ok, needsRehash, err := Verify("old-password", legacy)
+	if err != nil || !ok || !needsRehash {
+		t.Fatalf("Verify(旧 MD5 哈希) = %v, %v, %v，期望 true, true, nil", ok, needsRehash, err)
+	}
+	ok, needsRehash, err = Verify("wrong-password", legacy)
+	if err != nil || ok || needsRehash {
+		t.Errorf("Verify(旧 MD5 哈希, 错误密码) = %v, %v, %v，期望 false, false, nil", ok, needsRehash, err)
+	}
+
+	// 升级：重新计算后按当前算法存储，不再需要升级
+	upgraded, err := Hash("old-password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if ok, needsRehash, err := Verify("old-password", upgraded); err != nil || !ok || needsRehash {
+		t.Errorf("Verify(升级后的哈希) = %v, %v, %v，期望 true, false, nil", ok, needsRehash, err)
+	}
+}
+
+func TestVerifyNeedsRehash(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  settings.PasswordConfig
+		to    settings.PasswordConfig
+		wants bool
+	}{
+		{name: "参数不变", from: fastArgon2, to: fastArgon2},
+		{name: "argon2id 内存参数变化", from: fastArgon2, to: withArgon2(fastArgon2, 2048, 1, 1), wants: true},
+		{name: "argon2id 迭代次数变化", from: fastArgon2, to: withArgon2(fastArgon2, 1024, 2, 1), wants: true},
+		{name: "argon2id 并行度变化", from: fastArgon2, to: withArgon2(fastArgon2, 1024, 1, 2), wants: true},
+		{name: "argon2id 切换为 bcrypt", from: fastArgon2, to: fastBcrypt, wants: true},
+		{name: "bcrypt 切换为 argon2id", from: fastBcrypt, to: fastArgon2, wants: true},
+		{name: "bcrypt 成本变化", from: fastBcrypt, to: withBcryptCost(fastBcrypt, 5), wants: true},
+		{name: "修改 bcrypt 成本不影响 argon2id 哈希", from: fastArgon2, to: withBcryptCost(fastArgon2, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePasswordConfig(t, tt.from)
+			encoded, err := Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			usePasswordConfig(t, tt.to)
+			ok, needsRehash, err := Verify("secret", encoded)
+			if err != nil || !ok {
+				t.Fatalf("Verify() = %v, %v，期望校验通过", ok, err)
+			}
+			if needsRehash != tt.wants {
+				t.Errorf("needsRehash = %v，期望 %v", needsRehash, tt.wants)
+			}
+		})
+	}
+}
+
+func withArgon2(conf settings.PasswordConfig, memory, time uint32, threads uint8) settings.PasswordConfig {
+	conf.Argon2Memory, conf.Argon2Time, conf.Argon2Threads = memory, time, threads
+	return conf
+}
+
+func withBcryptCost(conf settings.PasswordConfig, cost int) settings.PasswordConfig {
+	conf.BcryptCost = cost
+	return conf
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	usePasswordConfig(t, fastArgon2)
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr error // nil 表示只要求返回错误
+	}{
+		{name: "argon2id 段数不足", encoded: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", wantErr: ErrMalformedHash},
+		{name: "argon2id 版本不支持", encoded: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5", wantErr: ErrMalformedHash},
+		{name: "argon2id 参数无法解析", encoded: "$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5", wantErr: ErrMalformedHash},
+		{name: "argon2id 盐不是 base64", encoded: "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5a2V5", wantErr: ErrMalformedHash},
+		{name: "argon2id 哈希为空", encoded: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHQ$", wantErr: ErrMalformedHash},
+		{name: "bcrypt 哈希被截断", encoded: "$2a$04$short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := Verify("secret", tt.encoded)
+			if ok || needsRehash {
+				t.Errorf("Verify() = %v, %v，格式错误的哈希不能通过校验", ok, needsRehash)
+			}
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("Verify() error = %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyUnusable(t *testing.T) {
+	usePasswordConfig(t, fastArgon2)
+	for _, encoded := range []string{"", Unusable, Unusable + "locked"} {
+		for _, plain := range []string{"", "!", "secret"} {
+			if ok, needsRehash, err := Verify(plain, encoded); ok || needsRehash || err != nil {
+				t.Errorf("Verify(%q, %q) = %v, %v, %v，期望 false, false, nil", plain, encoded, ok, needsRehash, err)
+			}
+		}
+	}
+}
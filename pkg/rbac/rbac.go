@@ -0,0 +1,142 @@
+package rbac
+
+// 用户角色
+const (
+	RoleAdmin     = "admin"     // 管理员：拥有全部权限
+	RoleScheduler = "scheduler" // 排课员：维护课表，审核变更申请
+	RoleTeacher   = "teacher"   // 教师：查看课表，为自己任教的课程提交变更申请
+	RoleStudent   = "student"   // 学生：只能查看自己所在班级的课表
+	RoleViewer    = "viewer"    // 只读用户
+)
+
+// Permission 接口权限，每个路由声明其所需的权限
+type Permission string
+
+const (
+	PermClassView       Permission = "class:view"       // 查看班级
+	PermClassManage     Permission = "class:manage"     // 创建、修改、删除班级
+	PermSheetView       Permission = "sheet:view"       // 查看课表，具体班级/工作表的访问级别见 AccessRead 等
+	PermSheetExport     Permission = "sheet:export"     // 导出课表
+	PermScheduleEdit    Permission = "schedule:edit"    // 直接排课、维护课程、审核变更申请
+	PermSchedulePropose Permission = "schedule:propose" // 提交变更申请
+	PermUserList        Permission = "user:list"        // 查询用户列表
+	PermUserManage      Permission = "user:manage"      // 分配角色等用户管理操作
+	PermWebhookManage   Permission = "webhook:manage"   // 管理 webhook 订阅
+	PermAuditView       Permission = "audit:view"       // 查询审计日志
+
+	// 个人接口，所有角色都拥有，声明这些权限是为了限制访问令牌的范围
+	PermProfileView        Permission = "profile:view"        // 查看个人资料、所在班级和个人课表
+	PermNotificationView   Permission = "notification:view"   // 查看站内通知
+	PermNotificationManage Permission = "notification:manage" // 将站内通知标记为已读
+)
+
+var rolePermissions = map[string]map[Permission]bool{
+	RoleAdmin: {
+		PermClassView:       true,
+		PermClassManage:     true,
+		PermSheetView:       true,
+		PermSheetExport:     true,
+		PermScheduleEdit:    true,
+		PermSchedulePropose: true,
+		PermUserList:        true,
+		PermUserManage:      true,
+		PermWebhookManage:   true,
+		PermAuditView:       true,
+
+		PermProfileView:        true,
+		PermNotificationView:   true,
+		PermNotificationManage: true,
+	},
+	RoleScheduler: {
+		PermClassView:       true,
+		PermSheetView:       true,
+		PermSheetExport:     true,
+		PermScheduleEdit:    true,
+		PermSchedulePropose: true,
+		PermUserList:        true,
+
+		PermProfileView:        true,
+		PermNotificationView:   true,
+		PermNotificationManage: true,
+	},
+	RoleTeacher: {
+		PermClassView:       true,
+		PermSheetView:       true,
+		PermSheetExport:     true,
+		PermSchedulePropose: true,
+
+		PermProfileView:        true,
+		PermNotificationView:   true,
+		PermNotificationManage: true,
+	},
+	RoleStudent: {
+		PermClassView:   true,
+		PermSheetView:   true,
+		PermSheetExport: true,
+
+		PermProfileView:        true,
+		PermNotificationView:   true,
+		PermNotificationManage: true,
+	},
+	RoleViewer: {
+		PermClassView:   true,
+		PermSheetView:   true,
+		PermSheetExport: true,
+
+		PermProfileView:        true,
+		PermNotificationView:   true,
+		PermNotificationManage: true,
+	},
+}
+
+// ValidRole 判断角色是否存在
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission 判断角色是否拥有某项权限，未知角色没有任何权限
+func HasPermission(role string, perm Permission) bool {
+	return rolePermissions[role][perm]
+}
+
+// 班级/工作表的访问级别，数值越大权限越高，高级别包含低级别的全部权限
+const (
+	AccessNone  = iota
+	AccessRead  // 查看课表
+	AccessEdit  // 直接修改课表、审核变更申请
+	AccessAdmin // 管理工作表，并可以将权限分享给其他用户
+)
+
+var accessLevelNames = map[int]string{
+	AccessRead:  "read",
+	AccessEdit:  "edit",
+	AccessAdmin: "admin",
+}
+
+// AccessLevelName 返回访问级别在数据库和接口中的名称
+func AccessLevelName(level int) string {
+	return accessLevelNames[level]
+}
+
+// ParseAccessLevel 解析访问级别名称，未知名称返回 AccessNone
+func ParseAccessLevel(name string) int {
+	for level, n := range accessLevelNames {
+		if n == name {
+			return level
+		}
+	}
+	return AccessNone
+}
+
+// RoleAccessLevel 角色在所有班级上默认拥有的访问级别，授权记录只能在此基础上提升
+func RoleAccessLevel(role string) int {
+	switch role {
+	case RoleAdmin, RoleScheduler:
+		return AccessAdmin
+	case RoleTeacher, RoleViewer:
+		return AccessRead
+	}
+	// 学生默认没有任何班级的权限，加入班级后获得该班级的 read 权限
+	return AccessNone
+}
@@ -0,0 +1,59 @@
+package rbac
+
+import "net/http"
+
+// 访问令牌的权限范围，令牌的实际权限为所属用户角色权限与范围的交集
+const (
+	ScopeRead   = "read"   // 只读：查看班级、课表、用户列表、个人资料和通知，可以导出
+	ScopeExport = "export" // 仅导出课表
+	ScopeWrite  = "write"  // 与所属用户相同的全部权限
+)
+
+var scopePermissions = map[string]map[Permission]bool{
+	ScopeRead: {
+		PermClassView:   true,
+		PermSheetView:   true,
+		PermSheetExport: true,
+		PermUserList:    true,
+
+		PermProfileView:      true,
+		PermNotificationView: true,
+	},
+	ScopeExport: {
+		PermSheetExport: true,
+	},
+	ScopeWrite: nil, // 不额外限制
+}
+
+// ValidScope 判断权限范围是否存在
+func ValidScope(scope string) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// ScopeAllows 判断权限范围是否包含某项权限，多个范围取并集
+func ScopeAllows(scopes []string, perm Permission) bool {
+	for _, scope := range scopes {
+		perms, ok := scopePermissions[scope]
+		if !ok {
+			continue
+		}
+		if perms == nil || perms[perm] {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeAllowsMethod 只有 write 范围可以发起修改类请求，其余范围只能 GET
+func ScopeAllowsMethod(scopes []string, method string) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == ScopeWrite {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		perm   Permission
+		want   bool
+	}{
+		{name: "read 可以查看课表", scopes: []string{ScopeRead}, perm: PermSheetView, want: true},
+		{name: "read 可以查看个人资料", scopes: []string{ScopeRead}, perm: PermProfileView, want: true},
+		{name: "read 可以查看通知", scopes: []string{ScopeRead}, perm: PermNotificationView, want: true},
+		{name: "read 不能标记通知", scopes: []string{ScopeRead}, perm: PermNotificationManage},
+		{name: "read 不能排课", scopes: []string{ScopeRead}, perm: PermScheduleEdit},
+		{name: "export 可以导出", scopes: []string{ScopeExport}, perm: PermSheetExport, want: true},
+		{name: "export 不能查看个人资料", scopes: []string{ScopeExport}, perm: PermProfileView},
+		{name: "export 不能查看通知", scopes: []string{ScopeExport}, perm: PermNotificationView},
+		{name: "多个范围取并集", scopes: []string{ScopeExport, ScopeRead}, perm: PermProfileView, want: true},
+		{name: "write 不额外限制", scopes: []string{ScopeWrite}, perm: PermNotificationManage, want: true},
+		{name: "未知范围", scopes: []string{"admin"}, perm: PermSheetView},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopeAllows(tt.scopes, tt.perm); got != tt.want {
+				t.Errorf("ScopeAllows(%v, %s) = %v，期望 %v", tt.scopes, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeAllowsMethod(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		method string
+		want   bool
+	}{
+		{scopes: []string{ScopeRead}, method: http.MethodGet, want: true},
+		{scopes: []string{ScopeRead}, method: http.MethodPut},
+		{scopes: []string{ScopeExport}, method: http.MethodDelete},
+		{scopes: []string{ScopeRead, ScopeWrite}, method: http.MethodPost, want: true},
+	}
+	for _, tt := range tests {
+		if got := ScopeAllowsMethod(tt.scopes, tt.method); got != tt.want {
+			t.Errorf("ScopeAllowsMethod(%v, %s) = %v，期望 %v", tt.scopes, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestPersonalPermissionsGrantedToAllRoles(t *testing.T) {
+	for role := range rolePermissions {
+		for _, perm := range []Permission{PermProfileView, PermNotificationView, PermNotificationManage} {
+			if !HasPermission(role, perm) {
+				t.Errorf("角色 %s 缺少个人接口权限 %s", role, perm)
+			}
+		}
+	}
+}
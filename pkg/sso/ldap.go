@@ -0,0 +1,139 @@
+package sso
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sztu/mutli-table/settings"
+)
+
+// ErrInvalidCredentials 用户不存在或密码错误
+var ErrInvalidCredentials = errors.New("用户名或密码错误")
+
+// LDAPConn LDAP 认证需要用到的连接操作，*ldap.Conn 满足该接口，测试时可以替换为进程内的桩实现
+type LDAPConn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAPDialer 建立 LDAP 连接
+type LDAPDialer func(ctx context.Context, conf *settings.LDAPConfig) (LDAPConn, error)
+
+// LDAPClient 通过“服务账号查找用户 DN，再以用户 DN 和密码绑定”的方式校验用户名密码
+type LDAPClient struct {
+	conf *settings.LDAPConfig
+	dial LDAPDialer
+}
+
+// NewLDAPClient 创建 LDAP 客户端，dial 为 nil 时使用 DialLDAP
+func NewLDAPClient(conf *settings.LDAPConfig, dial LDAPDialer) *LDAPClient {
+	if dial == nil {
+		dial = DialLDAP
+	}
+	return &LDAPClient{conf: conf, dial: dial}
+}
+
+// DialLDAP 按配置连接 LDAP 服务器，ldap:// 地址在配置了 startTLS 时升级为 TLS 连接
+func DialLDAP(ctx context.Context, conf *settings.LDAPConfig) (LDAPConn, error) {
+	timeout := time.Duration(conf.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify}
+	conn, err := ldap.DialURL(conf.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("连接 LDAP 服务器失败: %w", err)
+	}
+	conn.SetTimeout(timeout)
+	if conf.StartTLS && strings.HasPrefix(conf.URL, "ldap://") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("LDAP StartTLS 失败: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Authenticate 校验用户名密码，成功时返回目录中的用户信息；用户不存在或密码错误时返回 ErrInvalidCredentials
+func (c *LDAPClient) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	// 空密码在很多 LDAP 服务器上会被当作匿名绑定而成功，必须提前拒绝
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := c.dial(ctx, c.conf)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if c.conf.BindDN != "" {
+		if err := conn.Bind(c.conf.BindDN, c.conf.BindPassword); err != nil {
+			return nil, fmt.Errorf("LDAP 服务账号绑定失败: %w", err)
+		}
+	}
+
+	var attributes []string
+	for _, attr := range []string{c.conf.SubjectAttribute, c.conf.UsernameAttribute, c.conf.EmailAttribute, c.conf.NameAttribute} {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		c.conf.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, 0, false,
+		fmt.Sprintf(c.conf.UserFilter, ldap.EscapeFilter(username)),
+		attributes,
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, fmt.Errorf("LDAP 用户过滤条件匹配到多个条目: %s", username)
+		}
+		return nil, fmt.Errorf("LDAP 查询用户失败: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("LDAP 用户过滤条件匹配到多个条目: %s", username)
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("LDAP 用户绑定失败: %w", err)
+	}
+
+	identity := &Identity{
+		Issuer:   c.conf.Name,
+		Subject:  entry.DN,
+		Email:    entry.GetAttributeValue(c.conf.EmailAttribute),
+		Name:     entry.GetAttributeValue(c.conf.NameAttribute),
+		Username: username,
+	}
+	if c.conf.SubjectAttribute != "" {
+		if v := entry.GetAttributeValue(c.conf.SubjectAttribute); v != "" {
+			identity.Subject = v
+		}
+	}
+	if c.conf.UsernameAttribute != "" {
+		if v := entry.GetAttributeValue(c.conf.UsernameAttribute); v != "" {
+			identity.Username = v
+		}
+	}
+	// 目录中的邮箱由管理员维护，配置 trustEmail 时视为已验证
+	identity.EmailVerified = identity.Email != "" && c.conf.TrustEmail
+	return identity, nil
+}
@@ -0,0 +1,257 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sztu/mutli-table/settings"
+)
+
+const (
+	testBindDN       = "cn=svc,dc=example,dc=com"
+	testBindPassword = "svc-secret"
+)
+
+// fakeLDAPUser 目录中的一个用户
+type fakeLDAPUser struct {
+	dn       string
+	uid      string
+	password string
+	mail     string
+	cn       string
+}
+
+// fakeLDAP 进程内的 LDAP 目录，记录绑定和查询的过滤条件，按 (uid=<转义后的值>) 匹配用户
+type fakeLDAP struct {
+	users   []fakeLDAPUser
+	dials   int
+	binds   []string
+	filters []string
+	closed  bool
+}
+
+func (f *fakeLDAP) dial(context.Context, *settings.LDAPConfig) (LDAPConn, error) {
+	f.dials++
+	return f, nil
+}
+
+func (f *fakeLDAP) Bind(username, password string) error {
+	f.binds = append(f.binds, username)
+	if username == testBindDN && password == testBindPassword {
+		return nil
+	}
+	for _, u := range f.users {
+		if u.dn == username && u.password == password {
+			return nil
+		}
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (f *fakeLDAP) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	f.filters = append(f.filters, req.Filter)
+	result := &ldap.SearchResult{}
+	for _, u := range f.users {
+		if strings.Contains(req.Filter, "(uid="+ldap.EscapeFilter(u.uid)+")") {
+			result.Entries = append(result.Entries, ldap.NewEntry(u.dn, map[string][]string{
+				"uid":  {u.uid},
+				"mail": {u.mail},
+				"cn":   {u.cn},
+			}))
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeLDAP) Close() error {
+	f.closed = true
+	return nil
+}
+
+func testLDAPConfig() *settings.LDAPConfig {
+	return &settings.LDAPConfig{
+		Name:              "ldap",
+		BindDN:            testBindDN,
+		BindPassword:      testBindPassword,
+		BaseDN:            "dc=example,dc=com",
+		UserFilter:        "(&(objectClass=person)(uid=%s))",
+		UsernameAttribute: "uid",
+		EmailAttribute:    "mail",
+		NameAttribute:     "cn",
+		TrustEmail:        true,
+	}
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	alice := fakeLDAPUser{dn: "uid=alice,dc=example,dc=com", uid: "alice", password: "alice-pw", mail: "alice@example.com", cn: "Alice"}
+	tests := []struct {
+		name         string
+		users        []fakeLDAPUser
+		conf         func(conf *settings.LDAPConfig)
+		username     string
+		password     string
+		wantErr      error
+		wantErrMsg   string
+		wantIdentity *Identity
+		wantDials    int
+		wantBinds    []string
+		wantFilters  []string
+	}{
+		{
+			name:         "登录成功",
+			users:        []fakeLDAPUser{alice},
+			username:     "alice",
+			password:     "alice-pw",
+			wantIdentity: &Identity{Issuer: "ldap", Subject: alice.dn, Email: alice.mail, EmailVerified: true, Name: "Alice", Username: "alice"},
+			wantDials:    1,
+			wantBinds:    []string{testBindDN, alice.dn},
+			wantFilters:  []string{"(&(objectClass=person)(uid=alice))"},
+		},
+		{
+			name:        "密码错误",
+			users:       []fakeLDAPUser{alice},
+			username:    "alice",
+			password:    "wrong",
+			wantErr:     ErrInvalidCredentials,
+			wantDials:   1,
+			wantBinds:   []string{testBindDN, alice.dn},
+			wantFilters: []string{"(&(objectClass=person)(uid=alice))"},
+		},
+		{
+			name:        "用户不存在",
+			users:       []fakeLDAPUser{alice},
+			username:    "bob",
+			password:    "bob-pw",
+			wantErr:     ErrInvalidCredentials,
+			wantDials:   1,
+			wantBinds:   []string{testBindDN},
+			wantFilters: []string{"(&(objectClass=person)(uid=bob))"},
+		},
+		{
+			name:     "空密码不连接目录",
+			users:    []fakeLDAPUser{alice},
+			username: "alice",
+			password: "",
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			name:     "空用户名不连接目录",
+			users:    []fakeLDAPUser{alice},
+			username: "",
+			password: "alice-pw",
+			wantErr:  ErrInvalidCredentials,
+		},
+		{
+			name:        "用户名中的通配符被转义",
+			users:       []fakeLDAPUser{alice},
+			username:    "*",
+			password:    "alice-pw",
+			wantErr:     ErrInvalidCredentials,
+			wantDials:   1,
+			wantBinds:   []string{testBindDN},
+			wantFilters: []string{`(&(objectClass=person)(uid=\2a))`},
+		},
+		{
+			name:        "用户名中的过滤条件注入被转义",
+			users:       []fakeLDAPUser{alice},
+			username:    "alice)(uid=*",
+			password:    "alice-pw",
+			wantErr:     ErrInvalidCredentials,
+			wantDials:   1,
+			wantBinds:   []string{testBindDN},
+			wantFilters: []string{`(&(objectClass=person)(uid=alice\29\28uid=\2a))`},
+		},
+		{
+			name:       "服务账号绑定失败",
+			users:      []fakeLDAPUser{alice},
+			conf:       func(conf *settings.LDAPConfig) { conf.BindPassword = "wrong" },
+			username:   "alice",
+			password:   "alice-pw",
+			wantErrMsg: "LDAP 服务账号绑定失败",
+			wantDials:  1,
+			wantBinds:  []string{testBindDN},
+		},
+		{
+			name: "匹配到多个条目",
+			users: []fakeLDAPUser{
+				alice,
+				{dn: "uid=alice,ou=other,dc=example,dc=com", uid: "alice", password: "alice-pw"},
+			},
+			username:    "alice",
+			password:    "alice-pw",
+			wantErrMsg:  "匹配到多个条目",
+			wantDials:   1,
+			wantBinds:   []string{testBindDN},
+			wantFilters: []string{"(&(objectClass=person)(uid=alice))"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := testLDAPConfig()
+			if tt.conf != nil {
+				tt.conf(conf)
+			}
+			directory := &fakeLDAP{users: tt.users}
+			identity, err := NewLDAPClient(conf, directory.dial).Authenticate(context.Background(), tt.username, tt.password)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v，期望 %v", err, tt.wantErr)
+				}
+			case tt.wantErrMsg != "":
+				if err == nil || errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Fatalf("Authenticate() error = %v，期望包含 %q", err, tt.wantErrMsg)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Authenticate() error = %v", err)
+				}
+				if *identity != *tt.wantIdentity {
+					t.Errorf("Authenticate() = %+v，期望 %+v", *identity, *tt.wantIdentity)
+				}
+			}
+			if directory.dials != tt.wantDials {
+				t.Errorf("连接次数 = %d，期望 %d", directory.dials, tt.wantDials)
+			}
+			if directory.dials > 0 && !directory.closed {
+				t.Error("连接未关闭")
+			}
+			if strings.Join(directory.binds, "|") != strings.Join(tt.wantBinds, "|") {
+				t.Errorf("绑定 = %v，期望 %v", directory.binds, tt.wantBinds)
+			}
+			if strings.Join(directory.filters, "|") != strings.Join(tt.wantFilters, "|") {
+				t.Errorf("过滤条件 = %v，期望 %v", directory.filters, tt.wantFilters)
+			}
+		})
+	}
+}
+
+func TestLDAPAnonymousSearch(t *testing.T) {
+	conf := testLDAPConfig()
+	conf.BindDN = ""
+	conf.BindPassword = ""
+	conf.TrustEmail = false
+	directory := &fakeLDAP{users: []fakeLDAPUser{{dn: "uid=alice,dc=example,dc=com", uid: "alice", password: "alice-pw", mail: "alice@example.com"}}}
+	identity, err := NewLDAPClient(conf, directory.dial).Authenticate(context.Background(), "alice", "alice-pw")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if len(directory.binds) != 1 || directory.binds[0] != "uid=alice,dc=example,dc=com" {
+		t.Errorf("未配置服务账号时只应以用户 DN 绑定，实际绑定 %v", directory.binds)
+	}
+	if identity.EmailVerified {
+		t.Error("未配置 trustEmail 时目录中的邮箱不应视为已验证")
+	}
+}
+
+func TestLDAPDialError(t *testing.T) {
+	dialErr := errors.New("connection refused")
+	dial := func(context.Context, *settings.LDAPConfig) (LDAPConn, error) { return nil, dialErr }
+	if _, err := NewLDAPClient(testLDAPConfig(), dial).Authenticate(context.Background(), "alice", "alice-pw"); !errors.Is(err, dialErr) {
+		t.Errorf("Authenticate() error = %v，期望 %v", err, dialErr)
+	}
+}
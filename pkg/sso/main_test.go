@@ -0,0 +1,14 @@
+package sso
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain 切换到项目根目录，使 settings 能读取 ./conf/config.yaml
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,174 @@
+// Package sso 对接外部身份提供方，目前支持 OpenID Connect 授权码 + PKCE 流程。
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sztu/mutli-table/settings"
+	"golang.org/x/oauth2"
+)
+
+// ErrOIDCDisabled 未启用 OIDC 登录
+var ErrOIDCDisabled = errors.New("未启用 OIDC 登录")
+
+// Identity 身份提供方返回的用户信息
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string // 配置的 usernameClaim 对应的值
+	Name          string
+}
+
+// AuthRequest 一次授权请求，State 返回给前端，Nonce 和 CodeVerifier 由服务端保存到回调时使用
+type AuthRequest struct {
+	URL          string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+type client struct {
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth2   oauth2.Config
+	conf     *settings.OIDCConfig
+}
+
+var (
+	mu     sync.Mutex
+	cached *client
+)
+
+// Enabled 是否启用 OIDC 登录
+func Enabled() bool {
+	conf := settings.GetConfig().OIDCConfig
+	return conf != nil && conf.Enabled && conf.Issuer != "" && conf.ClientID != ""
+}
+
+// getClient 首次使用时通过 discovery 初始化客户端；初始化失败不缓存，下次请求重试，避免身份提供方短暂不可用导致需要重启服务
+func getClient(ctx context.Context) (*client, error) {
+	if !Enabled() {
+		return nil, ErrOIDCDisabled
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	conf := settings.GetConfig().OIDCConfig
+	provider, err := oidc.NewProvider(ctx, conf.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OIDC 配置失败: %w", err)
+	}
+	scopes := []string{oidc.ScopeOpenID}
+	for _, s := range conf.Scopes {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	cached = &client{
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: conf.ClientID}),
+		oauth2: oauth2.Config{
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			RedirectURL:  conf.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		conf: conf,
+	}
+	return cached, nil
+}
+
+// NewAuthRequest 生成跳转到身份提供方的授权地址，携带 state、nonce 和 S256 code_challenge
+func NewAuthRequest(ctx context.Context) (*AuthRequest, error) {
+	c, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+	return &AuthRequest{
+		URL:          c.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}, nil
+}
+
+// Exchange 用授权码换取 token，并校验 ID token 的签名、issuer、audience 和 nonce
+func Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	c, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("授权码换取 token 失败: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("身份提供方未返回 id_token")
+	}
+	idToken, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("校验 id_token 失败: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token 的 nonce 不匹配")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("解析 id_token 失败: %w", err)
+	}
+	identity := &Identity{
+		Issuer:   idToken.Issuer,
+		Subject:  idToken.Subject,
+		Email:    stringClaim(claims, "email"),
+		Name:     stringClaim(claims, "name"),
+		Username: stringClaim(claims, c.conf.UsernameClaim),
+	}
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		// 部分身份提供方以字符串形式返回
+		identity.EmailVerified = v == "true"
+	}
+	return identity, nil
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	if name == "" {
+		return ""
+	}
+	v, _ := claims[name].(string)
+	return v
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,293 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sztu/mutli-table/settings"
+)
+
+const mockClientID = "mutli-table"
+
+// mockIdP 基于 httptest 的 OIDC 身份提供方，支持 discovery、JWKS 和授权码 + PKCE 换取 token
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthCode
+}
+
+// mockAuthCode 一次授权，授权码只能使用一次
+type mockAuthCode struct {
+	nonce     string
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	idp := &mockIdP{key: key, codes: make(map[string]mockAuthCode)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	base := idp.server.URL
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                base,
+		"authorization_endpoint":                base + "/authorize",
+		"token_endpoint":                        base + "/token",
+		"jwks_uri":                              base + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := idp.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize 模拟用户在身份提供方登录并同意授权，返回回调中的授权码
+func (idp *mockIdP) authorize(t *testing.T, authURL string, claims map[string]interface{}) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("解析授权地址失败: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != mockClientID || q.Get("response_type") != "code" {
+		t.Fatalf("授权地址参数错误: %s", authURL)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("授权地址缺少 PKCE 参数: %s", authURL)
+	}
+	if q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("授权地址缺少 state 或 nonce: %s", authURL)
+	}
+	code := "code-" + q.Get("state")
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthCode{nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), claims: claims}
+	idp.mu.Unlock()
+	return code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"sub":   "user-1",
+		"aud":   mockClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test"
+	signed, err := idToken.SignedString(idp.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// useMockIdP 让 sso 包使用 mock 身份提供方，测试结束后恢复原配置
+func useMockIdP(t *testing.T) *mockIdP {
+	idp := newMockIdP(t)
+	conf := settings.GetConfig()
+	old := conf.OIDCConfig
+	conf.OIDCConfig = &settings.OIDCConfig{
+		Enabled:       true,
+		Issuer:        idp.server.URL,
+		ClientID:      mockClientID,
+		RedirectURL:   "http://localhost:5173/oidc/callback",
+		Scopes:        []string{"profile", "email"},
+		UsernameClaim: "preferred_username",
+	}
+	resetClient := func() {
+		mu.Lock()
+		cached = nil
+		mu.Unlock()
+	}
+	resetClient()
+	t.Cleanup(func() {
+		conf.OIDCConfig = old
+		resetClient()
+	})
+	return idp
+}
+
+func TestOIDCExchange(t *testing.T) {
+	tests := []struct {
+		name          string
+		claims        map[string]interface{}
+		wrongNonce    bool
+		wrongVerifier bool
+		wantErr       string
+		want          Identity
+	}{
+		{
+			name:   "已验证邮箱",
+			claims: map[string]interface{}{"email": "alice@example.com", "email_verified": true, "preferred_username": "alice", "name": "Alice"},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Username: "alice", Name: "Alice"},
+		},
+		{
+			name:   "email_verified 为字符串",
+			claims: map[string]interface{}{"email": "alice@example.com", "email_verified": "true"},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com", EmailVerified: true},
+		},
+		{
+			name:   "未验证邮箱",
+			claims: map[string]interface{}{"email": "alice@example.com", "email_verified": false},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com"},
+		},
+		{
+			name:   "未返回 email_verified 视为未验证",
+			claims: map[string]interface{}{"email": "alice@example.com"},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com"},
+		},
+		{
+			name:       "nonce 不匹配",
+			wrongNonce: true,
+			wantErr:    "nonce",
+		},
+		{
+			name:          "PKCE code_verifier 不匹配",
+			wrongVerifier: true,
+			wantErr:       "授权码换取 token 失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := useMockIdP(t)
+			ctx := context.Background()
+			req, err := NewAuthRequest(ctx)
+			if err != nil {
+				t.Fatalf("NewAuthRequest() error = %v", err)
+			}
+			code := idp.authorize(t, req.URL, tt.claims)
+
+			nonce, verifier := req.Nonce, req.CodeVerifier
+			if tt.wrongNonce {
+				nonce = "other-nonce"
+			}
+			if tt.wrongVerifier {
+				verifier = strings.Repeat("a", 43)
+			}
+			identity, err := Exchange(ctx, code, verifier, nonce)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Exchange() error = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			tt.want.Issuer = idp.server.URL
+			if *identity != tt.want {
+				t.Errorf("Exchange() = %+v，期望 %+v", *identity, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCCodeSingleUse(t *testing.T) {
+	idp := useMockIdP(t)
+	ctx := context.Background()
+	req, err := NewAuthRequest(ctx)
+	if err != nil {
+		t.Fatalf("NewAuthRequest() error = %v", err)
+	}
+	code := idp.authorize(t, req.URL, nil)
+	if _, err := Exchange(ctx, code, req.CodeVerifier, req.Nonce); err != nil {
+		t.Fatalf("第一次 Exchange() error = %v", err)
+	}
+	if _, err := Exchange(ctx, code, req.CodeVerifier, req.Nonce); err == nil {
+		t.Error("授权码重复使用时 Exchange() 应返回错误")
+	}
+}
+
+func TestNewAuthRequestUnique(t *testing.T) {
+	useMockIdP(t)
+	a, err := NewAuthRequest(context.Background())
+	if err != nil {
+		t.Fatalf("NewAuthRequest() error = %v", err)
+	}
+	b, err := NewAuthRequest(context.Background())
+	if err != nil {
+		t.Fatalf("NewAuthRequest() error = %v", err)
+	}
+	if a.State == b.State || a.Nonce == b.Nonce || a.CodeVerifier == b.CodeVerifier {
+		t.Error("每次授权请求的 state、nonce 和 code_verifier 都应不同")
+	}
+}
+
+func TestOIDCDisabled(t *testing.T) {
+	useMockIdP(t)
+	settings.GetConfig().OIDCConfig.Enabled = false
+	if _, err := NewAuthRequest(context.Background()); err != ErrOIDCDisabled {
+		t.Errorf("NewAuthRequest() error = %v，期望 ErrOIDCDisabled", err)
+	}
+}
@@ -0,0 +1,123 @@
+package totp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 测试用的密钥 "12345678901234567890" 的 base32 编码
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC 6238 附录 B 给出 8 位验证码，6 位验证码为其后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s，期望 %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeSecretFormat(t *testing.T) {
+	want, _ := Code(rfcSecret, 1)
+	// 用户手动输入的密钥可能是小写或带空格
+	if got, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1); err != nil || got != want {
+		t.Errorf("Code(小写密钥) = %s, %v，期望 %s", got, err, want)
+	}
+	if _, err := Code("not-base32!", 1); err == nil {
+		t.Error("非法密钥应返回错误")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	codeAt := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantOK   bool
+		wantStep int64
+	}{
+		{name: "当前时间步", code: codeAt(current), skew: 1, wantOK: true, wantStep: current},
+		{name: "上一个时间步在允许偏差内", code: codeAt(current - 1), skew: 1, wantOK: true, wantStep: current - 1},
+		{name: "下一个时间步在允许偏差内", code: codeAt(current + 1), skew: 1, wantOK: true, wantStep: current + 1},
+		{name: "超出允许偏差", code: codeAt(current - 2), skew: 1},
+		{name: "不允许偏差时只接受当前时间步", code: codeAt(current - 1), skew: 0},
+		{name: "验证码带空格", code: codeAt(current)[:3] + " " + codeAt(current)[3:], skew: 0, wantOK: true, wantStep: current},
+		{name: "位数不对", code: "12345", skew: 1},
+		{name: "错误的验证码", code: "000000", skew: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, tt.code, now, tt.skew)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v，期望 %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateAfterRejectsReuse(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	code, _ := Code(rfcSecret, current)
+	previous, _ := Code(rfcSecret, current-1)
+
+	step, ok, err := ValidateAfter(rfcSecret, code, now, 1, current-1)
+	if err != nil || !ok || step != current {
+		t.Fatalf("首次使用 ValidateAfter() = %d, %v, %v，期望 %d, true, nil", step, ok, err, current)
+	}
+	// 同一验证码再次使用
+	if _, ok, err := ValidateAfter(rfcSecret, code, now, 1, step); ok || !errors.Is(err, ErrReused) {
+		t.Errorf("重复使用 ValidateAfter() = %v, %v，期望 ErrReused", ok, err)
+	}
+	// 已使用较新的验证码后，偏差范围内更早的验证码也不能再使用
+	if _, ok, err := ValidateAfter(rfcSecret, previous, now, 1, step); ok || !errors.Is(err, ErrReused) {
+		t.Errorf("使用更早的验证码 ValidateAfter() = %v, %v，期望 ErrReused", ok, err)
+	}
+	// 错误的验证码不报告为重复使用
+	if _, ok, err := ValidateAfter(rfcSecret, "000000", now, 1, step); ok || err != nil {
+		t.Errorf("错误的验证码 ValidateAfter() = %v, %v，期望 false, nil", ok, err)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	b, _ := GenerateSecret()
+	if a == b || len(a) != 32 {
+		t.Errorf("GenerateSecret() = %q, %q，期望两个不同的 32 位 base32 密钥", a, b)
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("生成的密钥无法使用: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 回调地址指向回环、内网、链路本地等保留地址
+var ErrForbiddenAddress = errors.New("回调地址不能指向内网或保留地址")
+
+// reservedPrefixes net.IP 的 IsPrivate、IsLoopback 等方法没有覆盖的保留地址段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档示例
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档示例
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档示例
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留及广播地址
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64，可以映射到任意 IPv4 地址
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地 NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档示例
+}
+
+// IsForbiddenIP 判断地址是否不允许作为回调目标：回环、RFC 1918 内网、链路本地（含 169.254.169.254 云元数据地址）、
+// 组播、未指定地址以及其他保留地址
+func IsForbiddenIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient 创建投递用的 HTTP 客户端。
+// 不跟随重定向（3xx 视为投递失败），不使用环境变量中的代理；allowPrivate 为 false 时在建立连接时校验解析后的地址，
+// 拒绝内网和保留地址，DNS 重绑定也无法绕过。allowPrivate 只应在开发环境中开启
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || IsForbiddenIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsForbiddenIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "127.0.0.1", want: true},
+		{ip: "::1", want: true},
+		{ip: "10.1.2.3", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "fd00::1", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "::", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "224.0.0.1", want: true},
+		{ip: "255.255.255.255", want: true},
+		{ip: "::ffff:127.0.0.1", want: true},
+		{ip: "::ffff:169.254.169.254", want: true},
+		{ip: "64:ff9b::a9fe:a9fe", want: true},
+		{ip: "8.8.8.8"},
+		{ip: "1.1.1.1"},
+		{ip: "2606:4700:4700::1111"},
+	}
+	for _, tt := range tests {
+		if got := IsForbiddenIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsForbiddenIP(%s) = %v，期望 %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientRejectsPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer srv.Close()
+
+	resp, err := Send(context.Background(), NewClient(time.Second, false), &Request{URL: srv.URL, Secret: "secret", EventType: "ping"})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Send() error = %v，期望 ErrForbiddenAddress", err)
+	}
+	if resp.StatusCode != 0 || resp.Body != "" {
+		t.Errorf("Send() = %+v，不应读取到响应", resp)
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	// 测试服务器只能监听本机地址，这里允许内网地址，只校验重定向
+	resp, err := Send(context.Background(), NewClient(time.Second, true), &Request{URL: redirect.URL, Secret: "secret", EventType: "ping"})
+	if err == nil {
+		t.Fatal("重定向应视为投递失败")
+	}
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("StatusCode = %d，期望 %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+	if resp.Body == "internal secret" {
+		t.Error("不应跟随重定向读取其他地址的响应")
+	}
+}
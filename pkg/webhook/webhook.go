@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	maxResponseBody = 4096
+)
+
+// Request 一次 webhook 投递的内容
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID int64
+	Body       []byte
+}
+
+// Response 接收方的响应，StatusCode 为 0 表示请求未送达
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+// Sign 计算签名：hex(HMAC-SHA256(secret, timestamp + "." + body))。
+// 接收方应使用相同方式计算并比较 X-Webhook-Signature 中 "sha256=" 之后的部分，
+// 同时校验 X-Webhook-Timestamp 与当前时间的偏差以防止重放
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send 以 POST 方式投递事件，2xx 视为成功，其余状态码返回错误
+func Send(ctx context.Context, client *http.Client, r *Request) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return &Response{}, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mutli-table-webhook/1.0")
+	req.Header.Set(HeaderEvent, r.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(r.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(r.Secret, timestamp, r.Body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &Response{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := &Response{StatusCode: resp.StatusCode, Body: string(body)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return result, nil
+}
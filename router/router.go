@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sztu/mutli-table/controller"
 	"github.com/sztu/mutli-table/logger"
+	"github.com/sztu/mutli-table/pkg/rbac"
 	"github.com/sztu/mutli-table/settings"
 	"go.uber.org/zap"
 )
@@ -29,9 +30,12 @@ func SetupRouter() *gin.Engine {
 		gin.SetMode(gin.DebugMode)
 	}
 	r.Use(controller.CorsMiddleware())
+	// 公开签名公钥，供其他服务校验本服务签发的 token
+	r.GET("/.well-known/jwks.json", controller.JWKSHandler)
 	// 创建 API v1 路由组
 	v1 := r.Group("/api/v1").Use(
 		controller.LimitBodySizeMiddleware(),
+		controller.AuditMiddleware(),
 		controller.TimeoutMiddleware(),
 	)
 
@@ -44,43 +48,152 @@ func SetupRouter() *gin.Engine {
 
 	// 用户登录相关路由
 	v1.POST("/login", controller.LoginHandler)
+	v1.POST("/login/2fa", controller.TwoFactorLoginHandler)
 	v1.POST("/signup", controller.SignUpHandler)
 	v1.POST("/logout", controller.LogoutHandler)
+	v1.POST("/refresh", controller.RefreshHandler)
+	v1.GET("/oidc/authorize", controller.OIDCAuthorizeHandler)
+	v1.POST("/oidc/callback", controller.OIDCCallbackHandler)
+
+	// 邮箱验证与找回密码
+	v1.POST("/email/verify", controller.VerifyEmailHandler)
+	v1.POST("/password/forgot", controller.ForgotPasswordHandler)
+	v1.POST("/password/reset", controller.ResetPasswordHandler)
 	v1.Use(controller.JWTAuthMiddleware())
 	{
 		// 班级管理
-		v1.POST("/classes", controller.CreateClassHandler)
-		v1.GET("/classes", controller.ListClassesHandler)
-		v1.GET("/classes/:class_id", controller.GetClassHandler)
-		v1.PUT("/classes/:class_id", controller.UpdateClassHandler)
-		v1.DELETE("/classes/:class_id", controller.DeleteClassHandler)
+		v1.POST("/classes", controller.RequirePermission(rbac.PermClassManage), controller.CreateClassHandler)
+		v1.GET("/classes", controller.RequirePermission(rbac.PermClassView), controller.ListClassesHandler)
+		v1.GET("/classes/:class_id", controller.RequirePermission(rbac.PermClassView), controller.GetClassHandler)
+		v1.PUT("/classes/:class_id", controller.RequirePermission(rbac.PermClassManage), controller.UpdateClassHandler)
+		v1.DELETE("/classes/:class_id", controller.RequirePermission(rbac.PermClassManage), controller.DeleteClassHandler)
 
 		// 工作表管理
-		v1.POST("/classes/:class_id/sheet", controller.CreateSheetHandler)
-		v1.GET("/classes/:class_id/sheet", controller.ListSheetsHandler)
-		v1.GET("/classes/:class_id/sheet/:sheet_id", controller.GetSheetHandler)
-		v1.PUT("/classes/:class_id/sheet/:sheet_id", controller.UpdateSheetHandler)
-		v1.DELETE("/classes/:class_id/sheet/:sheet_id", controller.DeleteSheetHandler)
+		v1.POST("/classes/:class_id/sheet", controller.RequirePermission(rbac.PermSheetView), controller.CreateSheetHandler)
+		v1.GET("/classes/:class_id/sheet", controller.RequirePermission(rbac.PermSheetView), controller.ListSheetsHandler)
+		v1.GET("/classes/:class_id/sheet/:sheet_id", controller.RequirePermission(rbac.PermSheetView), controller.GetSheetHandler)
+		v1.PUT("/classes/:class_id/sheet/:sheet_id", controller.RequirePermission(rbac.PermSheetView), controller.UpdateSheetHandler)
+		v1.DELETE("/classes/:class_id/sheet/:sheet_id", controller.RequirePermission(rbac.PermSheetView), controller.DeleteSheetHandler)
 
 		// 单元格管理
-		v1.GET("/classes/:class_id/sheet/:sheet_id/cell", controller.GetCellsHandler)
-		v1.PUT("/classes/:class_id/sheet/:sheet_id/cell", controller.DeleteItemInCellHandler)
+		v1.GET("/classes/:class_id/sheet/:sheet_id/cell", controller.RequirePermission(rbac.PermSheetView), controller.GetCellsHandler)
+		v1.PUT("/classes/:class_id/sheet/:sheet_id/cell", controller.RequirePermission(rbac.PermSchedulePropose), controller.DeleteItemInCellHandler)
 
 		// 待拖动单元格管理
-		v1.POST("/drag-item", controller.CreateDragCellHandler)                 // 创建待拖动单元格(课程)
-		v1.GET("/:class_id/drag-item", controller.ListDragCellsHandler)         // 列出所有待拖动单元格
-		v1.GET("/drag-item/:drag_item_id", controller.GetDragCellHandler)       // 获取单个待拖动单元格
-		v1.PUT("/drag-item/:drag_item_id", controller.UpdateDragCellHandler)    // 更新待拖动单元格
-		v1.DELETE("/drag-item/:drag_item_id", controller.DeleteDragCellHandler) // 删除待拖动单元格
+		v1.POST("/drag-item", controller.RequirePermission(rbac.PermScheduleEdit), controller.CreateDragCellHandler)                 // 创建待拖动单元格(课程)
+		v1.GET("/:class_id/drag-item", controller.RequirePermission(rbac.PermSheetView), controller.ListDragCellsHandler)            // 列出所有待拖动单元格
+		v1.GET("/drag-item/:drag_item_id", controller.RequirePermission(rbac.PermSheetView), controller.GetDragCellHandler)          // 获取单个待拖动单元格
+		v1.PUT("/drag-item/:drag_item_id", controller.RequirePermission(rbac.PermScheduleEdit), controller.UpdateDragCellHandler)    // 更新待拖动单元格
+		v1.DELETE("/drag-item/:drag_item_id", controller.RequirePermission(rbac.PermScheduleEdit), controller.DeleteDragCellHandler) // 删除待拖动单元格
 
 		// 所有用户查询
-		v1.GET("/users", controller.ListUsersHandler)
+		v1.GET("/users", controller.RequirePermission(rbac.PermUserList), controller.ListUsersHandler)
 
 		// 课程查看
-		v1.POST("/sheet/courses/view", controller.ViewDragItemHandler) // 查看自己当前周的所有课程
+		v1.POST("/sheet/courses/view", controller.RequirePermission(rbac.PermSheetView), controller.ViewDragItemHandler) // 查看自己当前周的所有课程
 
 		// 拖放操作接口
-		v1.PUT("/classes/:class_id/sheet/:sheet_id/drag-item/:drag_item_id/move", controller.MoveDragItemHandler)
+		v1.PUT("/classes/:class_id/sheet/:sheet_id/drag-item/:drag_item_id/move", controller.RequirePermission(rbac.PermSchedulePropose), controller.MoveDragItemHandler)
+
+		// 拖动锁：拖动课程或编辑单元格前加锁，修改时通过 X-Lock-Token 请求头携带 token
+		v1.POST("/locks/acquire", controller.RequirePermission(rbac.PermSchedulePropose), controller.AcquireLockHandler)
+		v1.POST("/locks/renew", controller.RequirePermission(rbac.PermSchedulePropose), controller.RenewLockHandler)
+		v1.POST("/locks/release", controller.RequirePermission(rbac.PermSchedulePropose), controller.ReleaseLockHandler)
+
+		// 变更申请审核
+		v1.GET("/classes/:class_id/change-requests", controller.RequirePermission(rbac.PermSchedulePropose), controller.ListClassChangeRequestsHandler) // 班级下的变更申请
+		v1.GET("/change-requests/mine", controller.RequirePermission(rbac.PermSchedulePropose), controller.ListMyChangeRequestsHandler)                 // 我提交的变更申请
+		v1.PUT("/change-requests/:request_id/approve", controller.RequirePermission(rbac.PermSchedulePropose), controller.ApproveChangeRequestHandler)  // 通过
+		v1.PUT("/change-requests/:request_id/reject", controller.RequirePermission(rbac.PermSchedulePropose), controller.RejectChangeRequestHandler)    // 驳回
+		v1.DELETE("/change-requests/:request_id", controller.RequirePermission(rbac.PermSchedulePropose), controller.CancelChangeRequestHandler)        // 撤回
+
+		// 站内通知
+		v1.GET("/notifications", controller.RequireScope(rbac.PermNotificationView), controller.ListNotificationsHandler)
+		v1.PUT("/notifications/read-all", controller.RequireScope(rbac.PermNotificationManage), controller.MarkAllNotificationsReadHandler)
+		v1.PUT("/notifications/:notification_id/read", controller.RequireScope(rbac.PermNotificationManage), controller.MarkNotificationReadHandler)
+
+		// webhook 订阅
+		v1.POST("/webhooks", controller.RequirePermission(rbac.PermWebhookManage), controller.CreateWebhookHandler)
+		v1.GET("/webhooks", controller.RequirePermission(rbac.PermWebhookManage), controller.ListWebhooksHandler)
+		v1.GET("/webhooks/:webhook_id", controller.RequirePermission(rbac.PermWebhookManage), controller.GetWebhookHandler)
+		v1.PUT("/webhooks/:webhook_id", controller.RequirePermission(rbac.PermWebhookManage), controller.UpdateWebhookHandler)
+		v1.DELETE("/webhooks/:webhook_id", controller.RequirePermission(rbac.PermWebhookManage), controller.DeleteWebhookHandler)
+		v1.GET("/webhooks/:webhook_id/deliveries", controller.RequirePermission(rbac.PermWebhookManage), controller.ListWebhookDeliveriesHandler) // 投递记录
+		v1.POST("/webhooks/:webhook_id/ping", controller.RequirePermission(rbac.PermWebhookManage), controller.PingWebhookHandler)                // 发送测试事件
+
+		// 班级/工作表授权
+		v1.GET("/classes/:class_id/grants", controller.RequirePermission(rbac.PermClassView), controller.ListClassGrantsHandler)
+		v1.POST("/classes/:class_id/grants", controller.RequirePermission(rbac.PermClassView), controller.ShareClassHandler)
+		v1.POST("/classes/:class_id/sheet/:sheet_id/grants", controller.RequirePermission(rbac.PermSheetView), controller.ShareSheetHandler)
+		v1.DELETE("/classes/:class_id/grants/:grant_id", controller.RequirePermission(rbac.PermClassView), controller.RevokeGrantHandler)
+
+		// 导出与公开分享
+		v1.GET("/classes/:class_id/sheet/:sheet_id/export", controller.RequirePermission(rbac.PermSheetExport), controller.ExportSheetHandler) // 导出为 CSV
+		v1.POST("/classes/:class_id/share-links", controller.RequirePermission(rbac.PermClassView), controller.CreateShareLinkHandler)
+		v1.GET("/classes/:class_id/share-links", controller.RequirePermission(rbac.PermClassView), controller.ListShareLinksHandler)
+		v1.DELETE("/classes/:class_id/share-links/:link_id", controller.RequirePermission(rbac.PermClassView), controller.RevokeShareLinkHandler)
+
+		// 班级学生
+		v1.POST("/classes/:class_id/students", controller.RequirePermission(rbac.PermClassView), controller.EnrollStudentsHandler) // 批量加入班级
+		v1.GET("/classes/:class_id/students", controller.RequirePermission(rbac.PermClassView), controller.ListClassStudentsHandler)
+		v1.DELETE("/classes/:class_id/students/:user_id", controller.RequirePermission(rbac.PermClassView), controller.RemoveClassStudentHandler)
+
+		// 个人资料与账号管理，修改类接口不允许使用访问令牌调用；个人接口不校验角色权限，以便未启用两步验证的用户完成启用
+		v1.GET("/me", controller.RequireScope(rbac.PermProfileView), controller.GetProfileHandler)
+		v1.PUT("/me", controller.RequireSession(), controller.UpdateProfileHandler)
+		v1.DELETE("/me", controller.RequireSession(), controller.DeleteAccountHandler)
+		v1.PUT("/me/password", controller.RequireSession(), controller.ChangePasswordHandler)
+		v1.GET("/me/classes", controller.RequireScope(rbac.PermProfileView), controller.ListMyClassesHandler)
+		v1.GET("/me/timetable", controller.RequireScope(rbac.PermProfileView), controller.MyTimetableHandler) // 个人课表：?week=N 查询整周，?date=2006-01-02 或不传查询当天
+		v1.POST("/me/email/verification", controller.RequireSession(), controller.ResendEmailVerificationHandler)
+
+		// 登录会话
+		v1.GET("/me/sessions", controller.RequireSession(), controller.ListSessionsHandler)
+		v1.DELETE("/me/sessions", controller.RequireSession(), controller.RevokeAllSessionsHandler)
+		v1.DELETE("/me/sessions/:session_id", controller.RequireSession(), controller.RevokeSessionHandler)
+
+		// 两步验证
+		v1.GET("/me/2fa", controller.RequireSession(), controller.GetTwoFactorStatusHandler)
+		v1.DELETE("/me/2fa", controller.RequireSession(), controller.DisableTwoFactorHandler)
+		v1.POST("/me/2fa/enroll", controller.RequireSession(), controller.EnrollTwoFactorHandler)
+		v1.POST("/me/2fa/confirm", controller.RequireSession(), controller.ConfirmTwoFactorHandler)
+		v1.POST("/me/2fa/recovery-codes", controller.RequireSession(), controller.RegenerateRecoveryCodesHandler)
+
+		// 个人访问令牌
+		v1.POST("/me/tokens", controller.RequireSession(), controller.RequireTwoFactorPolicy(), controller.CreateAPITokenHandler)
+		v1.GET("/me/tokens", controller.RequireSession(), controller.ListAPITokensHandler)
+		v1.DELETE("/me/tokens/:token_id", controller.RequireSession(), controller.RevokeAPITokenHandler)
+
+		// 用户与角色管理
+		v1.GET("/admin/users", controller.RequirePermission(rbac.PermUserManage), controller.SearchUsersHandler)
+		v1.PUT("/admin/users/:user_id/role", controller.RequirePermission(rbac.PermUserManage), controller.AssignUserRoleHandler)
+		v1.POST("/admin/users/:user_id/unlock", controller.RequirePermission(rbac.PermUserManage), controller.UnlockUserHandler)
+		v1.POST("/admin/users/:user_id/disable", controller.RequirePermission(rbac.PermUserManage), controller.DisableUserHandler)
+		v1.POST("/admin/users/:user_id/enable", controller.RequirePermission(rbac.PermUserManage), controller.EnableUserHandler)
+		v1.PUT("/admin/users/:user_id/password", controller.RequirePermission(rbac.PermUserManage), controller.AdminResetPasswordHandler)
+		v1.POST("/admin/users/:user_id/restore", controller.RequirePermission(rbac.PermUserManage), controller.RestoreUserHandler)
+
+		// 审计日志
+		v1.GET("/admin/audit-logs", controller.RequirePermission(rbac.PermAuditView), controller.SearchAuditLogsHandler)
+	}
+
+	// 公开分享：访客通过分享链接中的 token 只读访问，无需登录
+	public := r.Group("/api/v1/public").Use(
+		controller.LimitBodySizeMiddleware(),
+		controller.TimeoutMiddleware(),
+	)
+	{
+		public.GET("/share/:token", controller.PublicShareHandler)
+		public.GET("/share/:token/sheets/:sheet_id/cells", controller.PublicCellsHandler)
+		public.GET("/share/:token/sheets/:sheet_id/export", controller.PublicExportHandler)
+	}
+
+	// 实时协作：长连接不能使用请求超时和请求体大小限制中间件，单独注册
+	realtime := r.Group("/api/v1").Use(controller.JWTAuthMiddleware())
+	{
+		realtime.GET("/classes/:class_id/sheet/:sheet_id/ws", controller.RequirePermission(rbac.PermSheetView), controller.WebSocketHandler)
+		realtime.GET("/classes/:class_id/sheet/:sheet_id/events", controller.RequirePermission(rbac.PermSheetView), controller.SheetEventsHandler) // SSE，适用于不支持 WebSocket 的网络环境
+		realtime.GET("/classes/:class_id/events", controller.RequirePermission(rbac.PermSheetView), controller.ClassEventsHandler)
 	}
 
 	r.NoRoute(func(c *gin.Context) {
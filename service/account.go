@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// accountConfig 读取账号相关配置，未配置时使用默认值
+func accountConfig() settings.AccountConfig {
+	conf := settings.AccountConfig{
+		PublicURL:        "http://localhost:5173",
+		EmailVerifyTTL:   24,
+		PasswordResetTTL: 30,
+		MailCooldown:     60,
+	}
+	if c := settings.GetConfig().AccountConfig; c != nil {
+		conf.RequireVerifiedEmail = c.RequireVerifiedEmail
+		if c.PublicURL != "" {
+			conf.PublicURL = c.PublicURL
+		}
+		if c.EmailVerifyTTL > 0 {
+			conf.EmailVerifyTTL = c.EmailVerifyTTL
+		}
+		if c.PasswordResetTTL > 0 {
+			conf.PasswordResetTTL = c.PasswordResetTTL
+		}
+		if c.MailCooldown > 0 {
+			conf.MailCooldown = c.MailCooldown
+		}
+	}
+	return conf
+}
+
+// issueAccountToken 生成一次性 token 并保存到 Redis，token 绑定签发时的邮箱，邮箱变更后失效
+func issueAccountToken(ctx context.Context, purpose string, user *model.User, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	value := fmt.Sprintf("%d:%s", user.UserID, user.Email)
+	if err := cache.SaveAccountToken(ctx, purpose, user.UserID, hashAccountToken(token), value, ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeAccountToken 校验并作废一次性 token，返回 token 对应且邮箱未变更的用户
+func consumeAccountToken(ctx context.Context, purpose, token string) (*model.User, *apiError.ApiError) {
+	invalid := &apiError.ApiError{Code: code.InvalidParam, Msg: "链接无效或已过期"}
+	value, ok, err := cache.ConsumeAccountToken(ctx, purpose, hashAccountToken(token))
+	if err != nil {
+		zap.L().Error("读取一次性 token 失败", zap.String("purpose", purpose), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "服务器错误"}
+	}
+	if !ok {
+		return nil, invalid
+	}
+	idPart, email, found := strings.Cut(value, ":")
+	userID, err := strconv.ParseInt(idPart, 10, 64)
+	if !found || err != nil {
+		return nil, invalid
+	}
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "服务器错误"}
+	}
+	if user == nil || user.UserID == 0 || user.Email != email {
+		return nil, invalid
+	}
+	return user, nil
+}
+
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// accountLink 拼接前端页面链接
+func accountLink(path, token string) string {
+	return strings.TrimRight(accountConfig().PublicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendVerificationEmail 为用户签发邮箱验证链接并写入发件箱
+func sendVerificationEmail(ctx context.Context, user *model.User) error {
+	conf := accountConfig()
+	ttl := time.Duration(conf.EmailVerifyTTL) * time.Hour
+	token, err := issueAccountToken(ctx, cache.TokenPurposeEmailVerify, user, ttl)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s，你好：\n\n请在 %d 小时内打开以下链接完成邮箱验证：\n%s\n\n如果这不是你本人的操作，请忽略这封邮件。",
+		user.Username, conf.EmailVerifyTTL, accountLink("/verify-email", token))
+	return enqueueEmail(ctx, user.Email, "[排课系统] 验证你的邮箱", body)
+}
+
+// ResendEmailVerification 重新发送邮箱验证邮件
+func ResendEmailVerification(ctx context.Context, userID int64) *apiError.ApiError {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "发送验证邮件失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return &apiError.ApiError{Code: code.InvalidAuth, Msg: "用户不存在"}
+	}
+	if user.Email == "" {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "尚未设置邮箱"}
+	}
+	if user.EmailVerified {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "邮箱已验证"}
+	}
+	cooldown := time.Duration(accountConfig().MailCooldown) * time.Second
+	ok, err := cache.AcquireAccountMailCooldown(ctx, cache.TokenPurposeEmailVerify, userID, cooldown)
+	if err != nil {
+		zap.L().Error("检查邮件发送冷却失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "发送验证邮件失败"}
+	}
+	if !ok {
+		return &apiError.ApiError{
+			Code: code.TooManyRequests,
+			Msg:  "发送过于频繁，请稍后再试",
+			Data: &DTO.RetryAfterDTO{RetryAfter: accountConfig().MailCooldown},
+		}
+	}
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		zap.L().Error("发送验证邮件失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "发送验证邮件失败"}
+	}
+	return nil
+}
+
+// VerifyEmail 使用邮件中的 token 完成邮箱验证
+func VerifyEmail(ctx context.Context, token string) *apiError.ApiError {
+	user, apiErr := consumeAccountToken(ctx, cache.TokenPurposeEmailVerify, token)
+	if apiErr != nil {
+		return apiErr
+	}
+	if _, err := dao.MarkUserEmailVerified(ctx, user.UserID, user.Email); err != nil {
+		zap.L().Error("标记邮箱已验证失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "验证邮箱失败"}
+	}
+	return nil
+}
+
+// ForgotPassword 向邮箱对应的用户发送重置密码邮件。
+// 无论邮箱是否存在、是否处于冷却期都返回成功，避免通过该接口探测注册邮箱
+func ForgotPassword(ctx context.Context, email string) *apiError.ApiError {
+	user, err := dao.FindUserByEmail(ctx, email)
+	if err != nil {
+		zap.L().Error("根据邮箱查询用户失败", zap.Error(err))
+		return nil
+	}
+	if user == nil {
+		return nil
+	}
+	conf := accountConfig()
+	ok, err := cache.AcquireAccountMailCooldown(ctx, cache.TokenPurposePasswordReset, user.UserID, time.Duration(conf.MailCooldown)*time.Second)
+	if err != nil {
+		zap.L().Error("检查邮件发送冷却失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	if err := sendPasswordResetEmail(ctx, user); err != nil {
+		zap.L().Error("发送重置密码邮件失败", zap.Int64("userID", user.UserID), zap.Error(err))
+	}
+	return nil
+}
+
+// sendPasswordResetEmail 为用户签发重置密码链接并写入发件箱
+func sendPasswordResetEmail(ctx context.Context, user *model.User) error {
+	conf := accountConfig()
+	token, err := issueAccountToken(ctx, cache.TokenPurposePasswordReset, user, time.Duration(conf.PasswordResetTTL)*time.Minute)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s，你好：\n\n我们收到了重置密码的请求，请在 %d 分钟内打开以下链接设置新密码：\n%s\n\n如果这不是你本人的操作，请忽略这封邮件，你的密码不会改变。",
+		user.Username, conf.PasswordResetTTL, accountLink("/reset-password", token))
+	return enqueueEmail(ctx, user.Email, "[排课系统] 重置密码", body)
+}
+
+// ResetPassword 使用邮件中的 token 设置新密码，同时清除登录失败记录并撤销全部登录会话；
+// 能收到邮件也说明邮箱有效，一并标记为已验证
+func ResetPassword(ctx context.Context, dto *DTO.ResetPasswordRequestDTO) *apiError.ApiError {
+	user, apiErr := consumeAccountToken(ctx, cache.TokenPurposePasswordReset, dto.Token)
+	if apiErr != nil {
+		return apiErr
+	}
+	hashed, err := password.Hash(dto.NewPassword)
+	if err != nil {
+		zap.L().Error("计算密码哈希失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "重置密码失败"}
+	}
+	if err := dao.UpdateUserPassword(ctx, user.UserID, hashed); err != nil {
+		zap.L().Error("更新密码失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "重置密码失败"}
+	}
+	clearLoginFailures(ctx, user.Username)
+	if err := revokeUserSessions(ctx, user.UserID, ""); err != nil {
+		zap.L().Error("撤销登录会话失败", zap.Int64("userID", user.UserID), zap.Error(err))
+	}
+	if !user.EmailVerified {
+		if _, err := dao.MarkUserEmailVerified(ctx, user.UserID, user.Email); err != nil {
+			zap.L().Error("标记邮箱已验证失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		}
+	}
+	return nil
+}
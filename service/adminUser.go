@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// maxAdminUserPageSize 管理员用户列表单页最大条数
+const maxAdminUserPageSize = 100
+
+// SearchUsers 管理员分页查询用户，keyword 匹配用户名、显示名称和邮箱；
+// status 可选 active、disabled、deleted，为空时返回全部未注销的用户
+func SearchUsers(ctx context.Context, keyword, role, status string, page, pageSize int) (*DTO.AdminUserListDTO, *apiError.ApiError) {
+	if role != "" && !rbac.ValidRole(role) {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的角色"}
+	}
+	switch status {
+	case "", dao.UserStatusActive, dao.UserStatusDisabled, dao.UserStatusDeleted:
+	default:
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的用户状态"}
+	}
+	if pageSize > maxAdminUserPageSize {
+		pageSize = maxAdminUserPageSize
+	}
+
+	users, total, err := dao.SearchUsers(ctx, strings.TrimSpace(keyword), role, status, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询用户列表失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询用户列表失败"}
+	}
+	list := make([]DTO.AdminUserDTO, 0, len(users))
+	for _, user := range users {
+		list = append(list, toAdminUserDTO(user))
+	}
+	return &DTO.AdminUserListDTO{Total: total, List: list}, nil
+}
+
+// SetUserDisabled 管理员停用或启用用户。停用后用户不能登录，已签发的 token 和访问令牌立即失效；
+// 不允许停用自己
+func SetUserDisabled(ctx context.Context, operatorID, userID int64, disabled bool) (*DTO.AdminUserDTO, *apiError.ApiError) {
+	if disabled && operatorID == userID {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "不能停用自己的账号"}
+	}
+	user, apiErr := findManagedUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if _, err := dao.SetUserDisabled(ctx, userID, disabled); err != nil {
+		zap.L().Error("修改用户停用状态失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "修改用户状态失败"}
+	}
+	if err := cache.SetUserDisabledFlag(ctx, userID, disabled); err != nil {
+		zap.L().Error("同步用户停用标记失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "修改用户状态失败，请重试"}
+	}
+	if disabled {
+		if err := revokeUserSessions(ctx, userID, ""); err != nil {
+			zap.L().Error("撤销登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+		}
+	}
+	zap.L().Info("修改用户停用状态",
+		zap.Int64("operatorID", operatorID),
+		zap.Int64("userID", userID),
+		zap.Bool("disabled", disabled))
+
+	user.Disabled = disabled
+	result := toAdminUserDTO(user)
+	return &result, nil
+}
+
+// AdminResetPassword 管理员重置用户密码：传入新密码时直接设置并撤销用户的全部登录会话，
+// 否则向用户邮箱发送重置密码链接
+func AdminResetPassword(ctx context.Context, operatorID, userID int64, dto *DTO.AdminResetPasswordRequestDTO) *apiError.ApiError {
+	user, apiErr := findManagedUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if dto.Password == "" {
+		if user.Email == "" {
+			return &apiError.ApiError{Code: code.InvalidParam, Msg: "该用户未设置邮箱，请直接设置新密码"}
+		}
+		if err := sendPasswordResetEmail(ctx, user); err != nil {
+			zap.L().Error("发送重置密码邮件失败", zap.Int64("userID", userID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "发送重置密码邮件失败"}
+		}
+		zap.L().Info("管理员发送重置密码邮件", zap.Int64("operatorID", operatorID), zap.Int64("userID", userID))
+		return nil
+	}
+
+	hashed, err := password.Hash(dto.Password)
+	if err != nil {
+		zap.L().Error("计算密码哈希失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "重置密码失败"}
+	}
+	if err := dao.UpdateUserPassword(ctx, userID, hashed); err != nil {
+		zap.L().Error("更新密码失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "重置密码失败"}
+	}
+	clearLoginFailures(ctx, user.Username)
+	if err := revokeUserSessions(ctx, userID, ""); err != nil {
+		zap.L().Error("撤销登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+	}
+	zap.L().Info("管理员重置用户密码", zap.Int64("operatorID", operatorID), zap.Int64("userID", userID))
+	return nil
+}
+
+// RestoreUser 恢复已注销的用户，注销后用户名或邮箱已被其他用户使用时不能恢复
+func RestoreUser(ctx context.Context, operatorID, userID int64) (*DTO.AdminUserDTO, *apiError.ApiError) {
+	user, err := dao.FindDeletedUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询已注销用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "恢复用户失败"}
+	}
+	if user == nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在或未注销"}
+	}
+	if active, err := dao.FindUserByID(ctx, userID); err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "恢复用户失败"}
+	} else if active != nil && active.UserID != 0 {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "用户未注销"}
+	}
+	if other, err := dao.FindUserByUsername(ctx, user.Username); err != nil {
+		zap.L().Error("查询用户名失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "恢复用户失败"}
+	} else if other != nil {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "用户名已被其他用户使用，无法恢复"}
+	}
+	if user.Email != "" {
+		if other, err := dao.FindUserByEmail(ctx, user.Email); err != nil {
+			zap.L().Error("查询邮箱失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "恢复用户失败"}
+		} else if other != nil {
+			return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "邮箱已被其他用户使用，无法恢复"}
+		}
+	}
+
+	ok, err := dao.RestoreUser(ctx, userID, user.DeleteTime)
+	if err != nil {
+		// 并发注册占用了用户名或邮箱时唯一索引冲突
+		zap.L().Error("恢复用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "恢复用户失败"}
+	}
+	if !ok {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在或未注销"}
+	}
+	zap.L().Info("恢复已注销用户", zap.Int64("operatorID", operatorID), zap.Int64("userID", userID))
+
+	user.DeleteTime = 0
+	result := toAdminUserDTO(user)
+	return &result, nil
+}
+
+// findManagedUser 查询管理员操作的目标用户，用户不存在或已注销时返回 NotFound
+func findManagedUser(ctx context.Context, userID int64) (*model.User, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询用户失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在"}
+	}
+	return user, nil
+}
+
+func toAdminUserDTO(user *model.User) DTO.AdminUserDTO {
+	result := DTO.AdminUserDTO{
+		UserID:        user.UserID,
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          effectiveRole(user),
+		Disabled:      user.Disabled,
+		Deleted:       user.DeleteTime > 0,
+		CreateTime:    user.CreateTime,
+	}
+	if user.DeleteTime > 0 {
+		result.DeleteTime = time.Unix(user.DeleteTime, 0).Format(time.RFC3339)
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiTokenPrefix 访问令牌的固定前缀，用于和 JWT 区分，也便于密钥扫描工具识别
+	apiTokenPrefix        = "mtp_"
+	apiTokenDisplayLen    = 12
+	maxAPITokensPerUser   = 20
+	apiTokenTouchInterval = time.Minute // 最近使用时间的更新间隔，避免每个请求都写数据库
+)
+
+// IsAPIToken 判断 Bearer 凭据是否为访问令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// CreateAPIToken 为当前用户创建访问令牌，明文只在返回值中出现一次
+func CreateAPIToken(ctx context.Context, userID int64, dto *DTO.CreateAPITokenRequestDTO) (*DTO.APITokenDTO, *apiError.ApiError) {
+	var scopes []string
+	for _, scope := range dto.Scopes {
+		if !rbac.ValidScope(scope) {
+			return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的权限范围: " + scope}
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	count, err := dao.CountAPITokensByUser(ctx, userID)
+	if err != nil {
+		zap.L().Error("统计访问令牌数量失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建访问令牌失败"}
+	}
+	if count >= maxAPITokensPerUser {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "访问令牌数量已达上限，请先撤销不再使用的令牌"}
+	}
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		zap.L().Error("生成访问令牌失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建访问令牌失败"}
+	}
+	plain := apiTokenPrefix + hex.EncodeToString(b)
+	token := &model.APIToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(dto.Name),
+		TokenHash:   hashAPIToken(plain),
+		TokenPrefix: plain[:apiTokenDisplayLen],
+		Scopes:      strings.Join(scopes, ","),
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if dto.ExpiresIn > 0 {
+		expire := time.Now().AddDate(0, 0, dto.ExpiresIn)
+		token.ExpireTime = &expire
+	}
+	if err := dao.CreateAPIToken(ctx, token); err != nil {
+		zap.L().Error("创建访问令牌失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建访问令牌失败"}
+	}
+	result := toAPITokenDTO(token)
+	result.Token = plain
+	return result, nil
+}
+
+// ListAPITokens 查询当前用户的访问令牌
+func ListAPITokens(ctx context.Context, userID int64) ([]DTO.APITokenDTO, *apiError.ApiError) {
+	tokens, err := dao.ListAPITokensByUser(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询访问令牌失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询访问令牌失败"}
+	}
+	result := make([]DTO.APITokenDTO, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, *toAPITokenDTO(token))
+	}
+	return result, nil
+}
+
+// RevokeAPIToken 撤销当前用户的访问令牌，撤销后立即失效
+func RevokeAPIToken(ctx context.Context, userID, tokenID int64) *apiError.ApiError {
+	found, err := dao.RevokeAPIToken(ctx, userID, tokenID)
+	if err != nil {
+		zap.L().Error("撤销访问令牌失败", zap.Int64("tokenID", tokenID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销访问令牌失败"}
+	}
+	if !found {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "访问令牌不存在"}
+	}
+	return nil
+}
+
+// AuthenticateAPIToken 校验访问令牌，返回令牌所属用户及权限范围
+func AuthenticateAPIToken(ctx context.Context, plain, clientIP string) (*model.User, []string, *apiError.ApiError) {
+	token, err := dao.GetAPITokenByHash(ctx, hashAPIToken(plain))
+	if err != nil {
+		zap.L().Error("查询访问令牌失败", zap.Error(err))
+		return nil, nil, &apiError.ApiError{Code: code.ServerError, Msg: "校验访问令牌失败"}
+	}
+	if token == nil {
+		return nil, nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "无效的访问令牌"}
+	}
+	if token.ExpireTime != nil && time.Now().After(*token.ExpireTime) {
+		return nil, nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "访问令牌已过期"}
+	}
+	user, err := dao.FindUserByID(ctx, token.UserID)
+	if err != nil {
+		zap.L().Error("查询访问令牌所属用户失败", zap.Int64("userID", token.UserID), zap.Error(err))
+		return nil, nil, &apiError.ApiError{Code: code.ServerError, Msg: "校验访问令牌失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return nil, nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "无效的访问令牌"}
+	}
+	if user.Disabled {
+		return nil, nil, errUserDisabled
+	}
+
+	if token.LastUsedTime == nil || time.Since(*token.LastUsedTime) > apiTokenTouchInterval || token.LastUsedIP != clientIP {
+		if err := dao.TouchAPIToken(ctx, token.ID, clientIP); err != nil {
+			zap.L().Warn("记录访问令牌使用时间失败", zap.Int64("tokenID", token.ID), zap.Error(err))
+		}
+	}
+	return user, strings.Split(token.Scopes, ","), nil
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPITokenDTO(token *model.APIToken) *DTO.APITokenDTO {
+	result := &DTO.APITokenDTO{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      strings.Split(token.Scopes, ","),
+		LastUsedIP:  token.LastUsedIP,
+		CreateTime:  token.CreateTime.Format(time.RFC3339),
+	}
+	if token.ExpireTime != nil {
+		result.ExpireTime = token.ExpireTime.Format(time.RFC3339)
+	}
+	if token.LastUsedTime != nil {
+		result.LastUsedTime = token.LastUsedTime.Format(time.RFC3339)
+	}
+	return result
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+const (
+	// auditBatchSize 单次批量写入的最大条数
+	auditBatchSize = 100
+	// auditFlushInterval 队列中的日志最长等待多久写入数据库
+	auditFlushInterval = time.Second
+	// auditPurgeInterval 过期日志清理间隔
+	auditPurgeInterval = 24 * time.Hour
+	// auditPurgeBatch 清理时每条 DELETE 语句最多删除的行数，避免长时间锁表
+	auditPurgeBatch = 1000
+	// maxAuditLogPageSize 审计日志单页最大条数
+	maxAuditLogPageSize = 100
+	// auditRedacted 敏感字段脱敏后的值
+	auditRedacted = "***"
+)
+
+// auditSensitiveKeys 请求体中名称完全相同的字段会被脱敏，两步验证码、恢复码、OIDC 授权码等都叫 code
+var auditSensitiveKeys = map[string]bool{
+	"code":          true,
+	"recovery_code": true,
+	"state":         true,
+}
+
+// auditSensitiveWords 名称中包含这些词的字段会被脱敏
+var auditSensitiveWords = []string{"password", "token", "secret"}
+
+var (
+	auditQueue     chan *model.AuditLog
+	auditQueueOnce sync.Once
+)
+
+func auditConfig() settings.AuditConfig {
+	conf := settings.AuditConfig{
+		Enabled:       true,
+		RetentionDays: 180,
+		BufferSize:    1000,
+		SummaryLength: 1024,
+	}
+	if c := settings.GetConfig().AuditConfig; c != nil {
+		conf.Enabled = c.Enabled
+		if c.RetentionDays > 0 {
+			conf.RetentionDays = c.RetentionDays
+		}
+		if c.BufferSize > 0 {
+			conf.BufferSize = c.BufferSize
+		}
+		if c.SummaryLength > 0 {
+			conf.SummaryLength = c.SummaryLength
+		}
+	}
+	return conf
+}
+
+func getAuditQueue() chan *model.AuditLog {
+	auditQueueOnce.Do(func() {
+		auditQueue = make(chan *model.AuditLog, auditConfig().BufferSize)
+	})
+	return auditQueue
+}
+
+// AuditEnabled 是否记录审计日志
+func AuditEnabled() bool {
+	return auditConfig().Enabled
+}
+
+// RecordAudit 将审计日志放入写入队列，不阻塞请求；队列已满时丢弃并打印告警
+func RecordAudit(entry *model.AuditLog) {
+	entry.ActorName = truncate(entry.ActorName, 64)
+	entry.Path = truncate(entry.Path, 512)
+	entry.Targets = truncate(entry.Targets, 512)
+	entry.ResultMsg = truncate(entry.ResultMsg, 255)
+	entry.UserAgent = truncate(entry.UserAgent, 255)
+	select {
+	case getAuditQueue() <- entry:
+	default:
+		zap.L().Warn("审计日志队列已满，丢弃日志",
+			zap.Int64("actorID", entry.ActorID),
+			zap.String("method", entry.Method),
+			zap.String("path", entry.Path),
+			zap.Int32("status", entry.Status))
+	}
+}
+
+// IsAuditSensitiveKey 判断请求体字段或路由参数是否需要脱敏，token_id 等以 _id 结尾的字段只是编号，不脱敏
+func IsAuditSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "_id") {
+		return false
+	}
+	if auditSensitiveKeys[key] {
+		return true
+	}
+	for _, word := range auditSensitiveWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeAuditBody 生成请求摘要：JSON 请求体脱敏后重新编码，超长时截断；
+// 其他类型（如导入时上传的文件）只记录类型和大小
+func SummarizeAuditBody(contentType string, body []byte, size int64) string {
+	if size == 0 {
+		return ""
+	}
+	if !strings.Contains(contentType, "json") {
+		return fmt.Sprintf("<%s, %d bytes>", contentType, size)
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<无法解析的 JSON, %d bytes>", size)
+	}
+	summary, err := json.Marshal(redactAuditValue(value))
+	if err != nil {
+		return fmt.Sprintf("<无法解析的 JSON, %d bytes>", size)
+	}
+	return truncate(string(summary), auditConfig().SummaryLength)
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if IsAuditSensitiveKey(key) {
+				v[key] = auditRedacted
+			} else {
+				v[key] = redactAuditValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactAuditValue(item)
+		}
+	}
+	return value
+}
+
+// StartAuditWorker 启动后台协程批量写入审计日志并定期清理过期日志，ctx 取消时写完队列中剩余的日志后退出
+func StartAuditWorker(ctx context.Context) {
+	queue := getAuditQueue()
+	go func() {
+		ticker := time.NewTicker(auditFlushInterval)
+		defer ticker.Stop()
+		batch := make([]*model.AuditLog, 0, auditBatchSize)
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case entry := <-queue:
+						batch = append(batch, entry)
+						if len(batch) >= auditBatchSize {
+							batch = flushAuditLogs(context.Background(), batch)
+						}
+					default:
+						flushAuditLogs(context.Background(), batch)
+						return
+					}
+				}
+			case entry := <-queue:
+				batch = append(batch, entry)
+				if len(batch) >= auditBatchSize {
+					batch = flushAuditLogs(ctx, batch)
+				}
+			case <-ticker.C:
+				batch = flushAuditLogs(ctx, batch)
+			}
+		}
+	}()
+
+	go func() {
+		purgeAuditLogs(ctx)
+		ticker := time.NewTicker(auditPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purgeAuditLogs(ctx)
+			}
+		}
+	}()
+}
+
+// flushAuditLogs 写入一批审计日志，写入失败时丢弃并打印日志，返回清空后的切片供复用
+func flushAuditLogs(ctx context.Context, batch []*model.AuditLog) []*model.AuditLog {
+	if len(batch) == 0 {
+		return batch
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("写入审计日志时发生 panic", zap.Any("panic", r))
+		}
+	}()
+	if err := dao.CreateAuditLogs(ctx, batch); err != nil {
+		zap.L().Error("写入审计日志失败", zap.Int("count", len(batch)), zap.Error(err))
+	}
+	return batch[:0]
+}
+
+// purgeAuditLogs 分批删除超过保留期限的审计日志
+func purgeAuditLogs(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("清理审计日志时发生 panic", zap.Any("panic", r))
+		}
+	}()
+
+	before := time.Now().AddDate(0, 0, -auditConfig().RetentionDays)
+	var total int64
+	for {
+		deleted, err := dao.DeleteAuditLogsBefore(ctx, before, auditPurgeBatch)
+		if err != nil {
+			zap.L().Error("清理过期审计日志失败", zap.Error(err))
+			return
+		}
+		total += deleted
+		if deleted < auditPurgeBatch || ctx.Err() != nil {
+			break
+		}
+	}
+	if total > 0 {
+		zap.L().Info("已清理过期审计日志", zap.Int64("count", total), zap.Time("before", before))
+	}
+}
+
+// SearchAuditLogs 管理员按操作人、目标对象和时间范围查询审计日志
+func SearchAuditLogs(ctx context.Context, filter *dao.AuditLogFilter, page, pageSize int) (*DTO.AuditLogListDTO, *apiError.ApiError) {
+	if filter.EntityID != "" && filter.EntityType == "" {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "按对象ID查询时必须指定对象类型"}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "开始时间必须早于结束时间"}
+	}
+	if pageSize > maxAuditLogPageSize {
+		pageSize = maxAuditLogPageSize
+	}
+
+	logs, total, err := dao.SearchAuditLogs(ctx, filter, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询审计日志失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询审计日志失败"}
+	}
+	list := make([]DTO.AuditLogDTO, 0, len(logs))
+	for _, item := range logs {
+		list = append(list, toAuditLogDTO(item))
+	}
+	return &DTO.AuditLogListDTO{Total: total, List: list}, nil
+}
+
+func toAuditLogDTO(item *model.AuditLog) DTO.AuditLogDTO {
+	targets := map[string]string{}
+	if item.Targets != "" {
+		if err := json.Unmarshal([]byte(item.Targets), &targets); err != nil {
+			zap.L().Warn("解析审计日志目标对象失败", zap.Int64("auditLogID", item.ID), zap.Error(err))
+		}
+	}
+	return DTO.AuditLogDTO{
+		ID:         item.ID,
+		ActorID:    item.ActorID,
+		ActorName:  item.ActorName,
+		AuthType:   item.AuthType,
+		Method:     item.Method,
+		Route:      item.Route,
+		Path:       item.Path,
+		EntityType: item.EntityType,
+		EntityID:   item.EntityID,
+		Targets:    targets,
+		Summary:    item.Summary,
+		Status:     item.Status,
+		ResultCode: item.ResultCode,
+		ResultMsg:  item.ResultMsg,
+		IP:         item.IP,
+		UserAgent:  item.UserAgent,
+		LatencyMs:  item.LatencyMs,
+		CreateTime: item.CreateTime.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// 认证方式标识，对应配置 auth.providers
+const (
+	AuthProviderLocal = "local"
+	AuthProviderLDAP  = "ldap"
+)
+
+// Authenticator 用户名密码认证方式。
+// 用户不存在或密码错误时返回 sso.ErrInvalidCredentials，登录时继续尝试下一种方式；
+// 其他错误表示该认证方式暂不可用
+type Authenticator interface {
+	Name() string
+	Authenticate(ctx context.Context, username, plain string) (*model.User, error)
+}
+
+// localAuthenticator 校验本地数据库中的密码哈希
+type localAuthenticator struct{}
+
+func (localAuthenticator) Name() string { return AuthProviderLocal }
+
+func (localAuthenticator) Authenticate(ctx context.Context, username, plain string) (*model.User, error) {
+	user, err := dao.FindUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		verifyDummyPassword(plain)
+		return nil, sso.ErrInvalidCredentials
+	}
+	ok, needsRehash, err := password.Verify(plain, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, sso.ErrInvalidCredentials
+	}
+	if needsRehash {
+		rehashPassword(ctx, user.UserID, plain)
+	}
+	return user, nil
+}
+
+// ldapAuthenticator 通过 LDAP 绑定校验密码，并将目录用户映射为本地用户
+type ldapAuthenticator struct {
+	conf   *settings.LDAPConfig
+	client *sso.LDAPClient
+}
+
+func (a *ldapAuthenticator) Name() string { return AuthProviderLDAP }
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, username, plain string) (*model.User, error) {
+	identity, err := a.client.Authenticate(ctx, username, plain)
+	if err != nil {
+		return nil, err
+	}
+	user, apiErr := resolveExternalUser(ctx, identity, a.conf.LinkByEmail, a.conf.AutoCreate)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return user, nil
+}
+
+var (
+	authenticatorsOnce sync.Once
+	authenticators     []Authenticator
+)
+
+// getAuthenticators 按配置顺序返回启用的认证方式，未配置时只使用本地数据库
+func getAuthenticators() []Authenticator {
+	authenticatorsOnce.Do(func() {
+		var names []string
+		if conf := settings.GetConfig().AuthConfig; conf != nil {
+			names = conf.Providers
+		}
+		for _, name := range names {
+			switch name {
+			case AuthProviderLocal:
+				authenticators = append(authenticators, localAuthenticator{})
+			case AuthProviderLDAP:
+				conf := settings.GetConfig().LDAPConfig
+				if conf == nil || conf.URL == "" {
+					zap.L().Warn("未配置 LDAP 服务器地址，跳过 LDAP 认证")
+					continue
+				}
+				authenticators = append(authenticators, &ldapAuthenticator{conf: conf, client: sso.NewLDAPClient(conf, nil)})
+			default:
+				zap.L().Warn("未知的认证方式", zap.String("provider", name))
+			}
+		}
+		if len(authenticators) == 0 {
+			authenticators = []Authenticator{localAuthenticator{}}
+		}
+	})
+	return authenticators
+}
+
+// errAuthUnavailable 所有认证方式都无法完成校验（而不是密码错误）
+var errAuthUnavailable = errors.New("认证服务暂不可用")
+
+// authenticate 依次尝试各认证方式，第一个认证成功的结果生效。
+// 只要有一种方式明确拒绝了凭据就返回 sso.ErrInvalidCredentials，全部因故障失败时返回 errAuthUnavailable，
+// 映射本地用户失败时返回 *apiError.ApiError
+func authenticate(ctx context.Context, username, plain string) (*model.User, error) {
+	rejected := false
+	for _, a := range getAuthenticators() {
+		user, err := a.Authenticate(ctx, username, plain)
+		if err == nil {
+			return user, nil
+		}
+		if errors.Is(err, sso.ErrInvalidCredentials) {
+			rejected = true
+			continue
+		}
+		// 凭据正确但映射本地用户时被拒绝（如账号未开通），不再尝试其他方式
+		var apiErr *apiError.ApiError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		zap.L().Error("认证方式不可用", zap.String("provider", a.Name()), zap.String("username", username), zap.Error(err))
+	}
+	if rejected {
+		return nil, sso.ErrInvalidCredentials
+	}
+	return nil, errAuthUnavailable
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"github.com/sztu/mutli-table/settings"
+)
+
+// fakeLDAPConn 只有一个用户的 LDAP 目录，不配置服务账号
+type fakeLDAPConn struct {
+	dn, password, mail string
+}
+
+func (f *fakeLDAPConn) Bind(username, password string) error {
+	if username == f.dn && password == f.password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (f *fakeLDAPConn) Search(*ldap.SearchRequest) (*ldap.SearchResult, error) {
+	return &ldap.SearchResult{Entries: []*ldap.Entry{
+		ldap.NewEntry(f.dn, map[string][]string{"uid": {"alice"}, "mail": {f.mail}}),
+	}}, nil
+}
+
+func (f *fakeLDAPConn) Close() error { return nil }
+
+func TestLDAPAuthenticatorLinkByEmail(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       *model.User
+		trustEmail  bool
+		linkByEmail bool
+		wantLinked  bool
+	}{
+		{
+			name:        "目录邮箱可信且本地邮箱已验证时绑定",
+			local:       &model.User{UserID: 100, Username: "alice", Email: "alice@example.com", EmailVerified: true},
+			trustEmail:  true,
+			linkByEmail: true,
+			wantLinked:  true,
+		},
+		{
+			name:        "本地账号邮箱未验证时不绑定",
+			local:       &model.User{UserID: 100, Username: "mallory", Email: "alice@example.com"},
+			trustEmail:  true,
+			linkByEmail: true,
+		},
+		{
+			name:        "未开启 trustEmail 时不绑定",
+			local:       &model.User{UserID: 100, Username: "alice", Email: "alice@example.com", EmailVerified: true},
+			linkByEmail: true,
+		},
+		{
+			name:       "默认不按邮箱绑定",
+			local:      &model.User{UserID: 100, Username: "alice", Email: "alice@example.com", EmailVerified: true},
+			trustEmail: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useMemoryExternalUsers(t, tt.local)
+			conf := &settings.LDAPConfig{
+				Name:              "ldap",
+				BaseDN:            "dc=example,dc=com",
+				UserFilter:        "(uid=%s)",
+				UsernameAttribute: "uid",
+				EmailAttribute:    "mail",
+				TrustEmail:        tt.trustEmail,
+				AutoCreate:        true,
+				LinkByEmail:       tt.linkByEmail,
+			}
+			directory := &fakeLDAPConn{dn: "uid=alice,dc=example,dc=com", password: "alice-pw", mail: "alice@example.com"}
+			dial := func(context.Context, *settings.LDAPConfig) (sso.LDAPConn, error) { return directory, nil }
+			a := &ldapAuthenticator{conf: conf, client: sso.NewLDAPClient(conf, dial)}
+
+			user, err := a.Authenticate(context.Background(), "alice", "alice-pw")
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if linked := user.UserID == tt.local.UserID; linked != tt.wantLinked {
+				t.Fatalf("绑定到已有用户 = %v，期望 %v", linked, tt.wantLinked)
+			}
+			if !tt.wantLinked && user.Email != "" {
+				t.Errorf("新建用户不应占用已有用户的邮箱，实际 %q", user.Email)
+			}
+			if len(store.identities) != 1 || store.identities[0].UserID != user.UserID {
+				t.Errorf("绑定关系 = %+v，期望绑定到用户 %d", store.identities, user.UserID)
+			}
+		})
+	}
+}
+
+func TestLDAPLinkByEmailDefaultOff(t *testing.T) {
+	if conf := settings.GetConfig().LDAPConfig; conf != nil && conf.LinkByEmail {
+		t.Error("ldap.linkByEmail 默认应关闭")
+	}
+}
@@ -5,13 +5,33 @@ import (
 	"time"
 
 	dao "github.com/sztu/mutli-table/DAO"
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
 	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func GetCells(ctx context.Context, userID, sheetID int64) ([]DTO.CellDTO, *apiError.ApiError) {
+	sheet, err := dao.GetSheetByID(ctx, sheetID)
+	if err != nil {
+		zap.L().Error("GetCells 查询工作表失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取单元格失败"}
+	}
+	if sheet == nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessRead); apiErr != nil {
+		return nil, apiErr
+	}
+	return sheetCells(ctx, sheetID)
+}
+
+// sheetCells 查询工作表的全部单元格，调用方负责权限校验
+func sheetCells(ctx context.Context, sheetID int64) ([]DTO.CellDTO, *apiError.ApiError) {
 	// 查询单元格
 	cells, err := dao.GetCellsBySheetID(ctx, sheetID)
 	if err != nil {
@@ -19,35 +39,68 @@ func GetCells(ctx context.Context, userID, sheetID int64) ([]DTO.CellDTO, *apiEr
 	}
 	// 转换为 DTO
 	var result []DTO.CellDTO
-	for _, c := range cells {
-		if c.ItemID == nil {
-			result = append(result, DTO.CellDTO{
-				ID:       c.ID,
-				SheetID:  c.SheetID,
-				RowIndex: int(c.RowIndex),
-				ColIndex: int(c.ColIndex),
-			})
-		} else {
-			dragItem, err := dao.GetDraggableItemByID(ctx, *c.ItemID)
-			if err != nil {
-				return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取拖拽项失败"}
-			}
-			result = append(result, DTO.CellDTO{
-				ID:        c.ID,
-				SheetID:   c.SheetID,
-				RowIndex:  int(c.RowIndex),
-				ColIndex:  int(c.ColIndex),
-				ItemID:    c.ItemID,
-				Content:   dragItem.Content,
-				WeekType:  dragItem.WeekType,
-				ClassRoom: dragItem.Classroom,
-				Teacher:   dragItem.Teacher,
-			})
+	for i := range cells {
+		cellDTO, err := toCellDTO(ctx, &cells[i])
+		if err != nil {
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取拖拽项失败"}
 		}
+		result = append(result, *cellDTO)
+	}
+	return result, nil
+}
+
+func toCellDTO(ctx context.Context, c *model.Cell) (*DTO.CellDTO, error) {
+	result := &DTO.CellDTO{
+		ID:       c.ID,
+		SheetID:  c.SheetID,
+		RowIndex: int(c.RowIndex),
+		ColIndex: int(c.ColIndex),
+		Version:  int64(c.Version),
+	}
+	if c.ItemID == nil {
+		return result, nil
+	}
+	dragItem, err := dao.GetDraggableItemByID(ctx, *c.ItemID)
+	if err != nil {
+		return nil, err
 	}
+	result.ItemID = c.ItemID
+	result.Content = dragItem.Content
+	result.WeekType = dragItem.WeekType
+	result.ClassRoom = dragItem.Classroom
+	result.Teacher = dragItem.Teacher
 	return result, nil
 }
 
+// checkCellVersion 校验客户端看到的单元格版本，expected 为 nil 时不校验
+func checkCellVersion(ctx context.Context, cell *model.Cell, expected *int64) *apiError.ApiError {
+	if expected == nil || int64(cell.Version) == *expected {
+		return nil
+	}
+	return cellConflictError(ctx, cell.SheetID, int(cell.RowIndex), int(cell.ColIndex), *expected)
+}
+
+// cellConflictError 构造单元格版本冲突错误，携带单元格的最新内容，格式与 WebSocket 的 CELL_CONFLICT 消息一致
+func cellConflictError(ctx context.Context, sheetID int64, row, col int, expected int64) *apiError.ApiError {
+	conflict := &DTO.CellConflictDTO{
+		Type:            "CELL_CONFLICT",
+		SheetID:         sheetID,
+		Row:             row,
+		Column:          col,
+		ExpectedVersion: expected,
+	}
+	if cell, err := dao.GetCellByPosition(ctx, sheetID, row, col); err == nil {
+		if current, err := toCellDTO(ctx, cell); err == nil {
+			conflict.Current = current
+		}
+	}
+	return &apiError.ApiError{
+		Code: code.CellConflict,
+		Msg:  "单元格已被其他用户修改，请刷新后重试",
+		Data: conflict,
+	}
+}
+
 // // 更新单元格
 // func UpdateCell(ctx context.Context, userID, sheetID int64, req DTO.UpdateCellRequestDTO) *apiError.ApiError {
 // 	perm, err := dao.GetPermission(ctx, userID, sheetID)
@@ -65,39 +118,117 @@ func GetCells(ctx context.Context, userID, sheetID int64) ([]DTO.CellDTO, *apiEr
 // 	return nil
 // }
 
-func DeleteItemInCell(ctx context.Context, userID, classID, sheetID int64, req DTO.DeleteItemInCellRequest) *apiError.ApiError {
+// DeleteItemInCell 移除单元格中的课程
+// 排课员的操作直接生效；任课老师移除自己的课程时生成一条待审核的变更申请并返回
+func DeleteItemInCell(ctx context.Context, userID, classID, sheetID int64, req DTO.DeleteItemInCellRequest) (*DTO.ChangeRequestDTO, *apiError.ApiError) {
+	currentSheet, err := dao.GetSheetByID(ctx, sheetID)
+	if err != nil || currentSheet == nil {
+		zap.L().Error("获取工作表信息失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取工作表失败"}
+	}
+	if currentSheet.ClassID != classID {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
 	targetCell, err := dao.GetCellByPosition(ctx, sheetID, req.Row, req.Col)
-	needToDelete := 0
 	if err != nil {
 		zap.L().Error("GetCellByRowAndCol 查询单元格失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取单元格失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取单元格失败"}
+	}
+	if apiErr := checkCellVersion(ctx, targetCell, req.ExpectedVersion); apiErr != nil {
+		return nil, apiErr
 	}
 	if targetCell.ItemID == nil {
-		return &apiError.ApiError{Code: code.InvalidParam, Msg: "删除的单元格为空"}
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "删除的单元格为空"}
+	}
+	item, err := dao.GetDraggableItemByID(ctx, *targetCell.ItemID)
+	if err != nil || item == nil {
+		zap.L().Error("DeleteItemInCell 获取元素失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
+	}
+	// 单元格或其中的课程正被他人拖动时不允许修改
+	if apiErr := checkLocks(ctx, userID, req.LockToken,
+		cellLockKey(sheetID, req.Row, req.Col), dragItemLockKey(item.ID)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if isClassScheduler(ctx, userID, currentSheet) {
+		return nil, applyDeleteItemInCell(ctx, userID, currentSheet, item, targetCell)
+	}
+
+	// 非排课员只能为自己任教的课程提交移除申请
+	userName, err := dao.GetUserNameByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("DeleteItemInCell 获取用户名失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
+	}
+	if item.Teacher != userName {
+		return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限移除该课程"}
+	}
+	return createChangeRequest(ctx, &model.ChangeRequest{
+		ClassID:    classID,
+		SheetID:    sheetID,
+		ItemID:     item.ID,
+		Action:     ChangeActionRemove,
+		TargetRow:  int32(req.Row),
+		TargetCol:  int32(req.Col),
+		Comment:    req.Comment,
+		ProposerID: userID,
+	})
+}
+
+// applyDeleteItemInCell 清空目标单元格，并同步清空同班级其他周相同位置的同一课程，调用方需先完成权限校验。
+// 所有工作表在同一个事务中修改，任何一张失败都整体回滚
+func applyDeleteItemInCell(ctx context.Context, userID int64, currentSheet *model.Sheet, item *model.DraggableItem, targetCell *model.Cell) *apiError.ApiError {
+	tx := mysql.GetDB().WithContext(ctx).Begin()
+	// 保证事务异常或 panic 时回滚
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	afterCommit, apiErr := applyDeleteItemInCellTx(ctx, tx, userID, currentSheet, item, targetCell)
+	if apiErr != nil {
+		tx.Rollback()
+		return apiErr
 	}
-	needToDelete = int(*targetCell.ItemID)
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		zap.L().Error("DeleteItemInCell 事务提交失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
+	}
+	afterCommit()
+	return nil
+}
+
+// applyDeleteItemInCellTx 在事务 tx 中清空单元格，成功时返回提交后需要执行的推送和通知，失败时由调用方回滚
+func applyDeleteItemInCellTx(ctx context.Context, tx *gorm.DB, userID int64, currentSheet *model.Sheet, item *model.DraggableItem, targetCell *model.Cell) (func(), *apiError.ApiError) {
+	sheetID := currentSheet.ID
+	needToDelete := int(*targetCell.ItemID)
+	row, col := int(targetCell.RowIndex), int(targetCell.ColIndex)
 	targetCell.ItemID = nil
 	targetCell.UpdateTime = time.Now()
 	targetCell.LastModifiedBy = userID
-	currentSheet, err := dao.GetSheetByID(ctx, sheetID)
-	if err != nil || currentSheet == nil {
-		zap.L().Error("获取工作表信息失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取工作表失败"}
-	}
 
-	// 更新当前单元格
-	if err := dao.UpdateCell(ctx, sheetID, targetCell); err != nil {
+	// 更新当前单元格，读取之后被他人修改过则放弃
+	updated, err := dao.UpdateCellWithVersionTx(ctx, tx, targetCell)
+	if err != nil {
 		zap.L().Error("更新当前单元格失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "更新单元格失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新单元格失败"}
+	}
+	if !updated {
+		return nil, cellConflictError(ctx, sheetID, row, col, int64(targetCell.Version))
 	}
 
+	// 受影响的工作表，包括同步移除的其他周
+	clearedSheetIDs := []int64{sheetID}
+
 	// 获取该班级的所有工作表
 	sheets, _, err := dao.ListSheets(ctx, userID, currentSheet.ClassID, 1, 1000) // 假设一个班级不会有超过1000个工作表
 	if err != nil {
 		zap.L().Error("获取班级工作表列表失败",
 			zap.Int64("classID", currentSheet.ClassID),
 			zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取班级工作表列表失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取班级工作表列表失败"}
 	}
 
 	// 遍历所有工作表，更新相同位置的单元格
@@ -107,30 +238,40 @@ func DeleteItemInCell(ctx context.Context, userID, classID, sheetID int64, req D
 		}
 
 		// 获取目标单元格
-		targetCell, err := dao.GetCellByPosition(ctx, sheet.ID, req.Row, req.Col)
-		if err != nil || targetCell == nil {
+		weekCell, err := dao.GetCellByPositionTx(ctx, tx, sheet.ID, row, col)
+		if err != nil || weekCell == nil {
 			zap.L().Error("获取目标单元格失败",
 				zap.Int64("sheetID", sheet.ID),
 				zap.Error(err))
 			continue
 		}
-		if targetCell.ItemID == nil {
+		if weekCell.ItemID == nil {
 			continue
 		}
-		if int(*targetCell.ItemID) != needToDelete {
+		if int(*weekCell.ItemID) != needToDelete {
 			continue
 		}
-		targetCell.ItemID = nil
-		targetCell.UpdateTime = time.Now()
-		targetCell.LastModifiedBy = userID
+		weekCell.ItemID = nil
+		weekCell.UpdateTime = time.Now()
+		weekCell.LastModifiedBy = userID
 
-		// 更新目标单元格
-		if err := dao.UpdateCell(ctx, sheet.ID, targetCell); err != nil {
+		// 更新目标单元格，读取之后被他人修改过则整体放弃
+		updated, err := dao.UpdateCellWithVersionTx(ctx, tx, weekCell)
+		if err != nil {
 			zap.L().Error("更新工作表单元格失败",
 				zap.Int64("sheetID", sheet.ID),
 				zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新单元格失败"}
+		}
+		if !updated {
+			return nil, cellConflictError(ctx, sheet.ID, row, col, int64(weekCell.Version))
 		}
+		clearedSheetIDs = append(clearedSheetIDs, sheet.ID)
 	}
 
-	return nil
+	return func() {
+		publishCellCleared(ctx, userID, currentSheet.ClassID, clearedSheetIDs, row, col)
+		notifyTeacher(ctx, userID, NotificationItemRemoved, currentSheet, item, row, col)
+		emitItemWebhookEvent(ctx, WebhookEventItemRemoved, userID, currentSheet, item, row, col)
+	}, nil
 }
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// ChangeActionMove 将课程移动到目标单元格
+	ChangeActionMove = "move"
+	// ChangeActionRemove 将课程从单元格中移除
+	ChangeActionRemove = "remove"
+
+	ChangeStatusPending   = "pending"
+	ChangeStatusApproved  = "approved"
+	ChangeStatusRejected  = "rejected"
+	ChangeStatusCancelled = "cancelled"
+)
+
+// isClassScheduler 判断用户是否为该课表的排课员，即对工作表拥有 edit 及以上访问级别
+// （排课员、管理员角色，或被授予了班级/工作表编辑权限的用户）。
+// 排课员的移动/移除操作直接生效，并负责审核其他用户提交的变更申请
+func isClassScheduler(ctx context.Context, userID int64, sheet *model.Sheet) bool {
+	return requireSheetAccess(ctx, userID, sheet, rbac.AccessEdit) == nil
+}
+
+func createChangeRequest(ctx context.Context, req *model.ChangeRequest) (*DTO.ChangeRequestDTO, *apiError.ApiError) {
+	req.Status = ChangeStatusPending
+	req.CreateTime = time.Now()
+	req.UpdateTime = time.Now()
+	if err := dao.CreateChangeRequest(ctx, req); err != nil {
+		zap.L().Error("创建变更申请失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "提交变更申请失败"}
+	}
+	return toChangeRequestDTO(req), nil
+}
+
+// ListClassChangeRequests 分页查询班级下的变更申请
+func ListClassChangeRequests(ctx context.Context, userID, classID int64, status string, page, pageSize int) (*DTO.ChangeRequestListDTO, *apiError.ApiError) {
+	if !validChangeStatus(status) {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的申请状态"}
+	}
+	if _, err := dao.GetClassByID(ctx, classID); err != nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "班级不存在"}
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessEdit); apiErr != nil {
+		return nil, apiErr
+	}
+	reqs, total, err := dao.ListChangeRequestsByClass(ctx, classID, status, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询班级变更申请失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询变更申请失败"}
+	}
+	return toChangeRequestListDTO(reqs, total), nil
+}
+
+// ListMyChangeRequests 分页查询当前用户提交的变更申请
+func ListMyChangeRequests(ctx context.Context, userID int64, status string, page, pageSize int) (*DTO.ChangeRequestListDTO, *apiError.ApiError) {
+	if !validChangeStatus(status) {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的申请状态"}
+	}
+	reqs, total, err := dao.ListChangeRequestsByProposer(ctx, userID, status, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询个人变更申请失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询变更申请失败"}
+	}
+	return toChangeRequestListDTO(reqs, total), nil
+}
+
+// ApproveChangeRequest 排课员通过变更申请
+// 以审核人的身份重新执行移动/移除（包括锁、教师冲突、单双周等全部校验），
+// 申请状态与课表修改在同一个事务中提交；应用失败时整体回滚，申请保持待审核状态，由排课员调整课表后重试或驳回
+func ApproveChangeRequest(ctx context.Context, reviewerID, requestID int64, lockToken string) (*DTO.ChangeRequestDTO, *apiError.ApiError) {
+	req, sheet, item, apiErr := loadReviewableChangeRequest(ctx, reviewerID, requestID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// 课程或目标单元格正被他人拖动时不允许审核通过
+	if apiErr := checkLocks(ctx, reviewerID, lockToken,
+		dragItemLockKey(item.ID), cellLockKey(sheet.ID, int(req.TargetRow), int(req.TargetCol))); apiErr != nil {
+		return nil, apiErr
+	}
+
+	tx := mysql.GetDB().WithContext(ctx).Begin()
+	// 保证事务异常或 panic 时回滚
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	req.Status = ChangeStatusApproved
+	req.ReviewerID = reviewerID
+	req.UpdateTime = time.Now()
+	ok, err := dao.UpdateChangeRequestStatusTx(ctx, tx, req, ChangeStatusPending)
+	if err != nil {
+		tx.Rollback()
+		zap.L().Error("更新变更申请状态失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "审核变更申请失败"}
+	}
+	if !ok {
+		tx.Rollback()
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "该申请已被处理"}
+	}
+
+	afterCommit, apiErr := applyChangeRequestTx(ctx, tx, reviewerID, req, sheet, item)
+	if apiErr != nil {
+		tx.Rollback()
+		return nil, apiErr
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		zap.L().Error("审核变更申请事务提交失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "审核变更申请失败"}
+	}
+	afterCommit()
+	return toChangeRequestDTO(req), nil
+}
+
+// RejectChangeRequest 排课员驳回变更申请
+func RejectChangeRequest(ctx context.Context, reviewerID, requestID int64, dto *DTO.RejectChangeRequestDTO) (*DTO.ChangeRequestDTO, *apiError.ApiError) {
+	req, _, _, apiErr := loadReviewableChangeRequest(ctx, reviewerID, requestID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	req.Status = ChangeStatusRejected
+	req.ReviewerID = reviewerID
+	req.RejectReason = dto.Reason
+	req.UpdateTime = time.Now()
+	ok, err := dao.UpdateChangeRequestStatus(ctx, req, ChangeStatusPending)
+	if err != nil {
+		zap.L().Error("更新变更申请状态失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "驳回变更申请失败"}
+	}
+	if !ok {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "该申请已被处理"}
+	}
+	return toChangeRequestDTO(req), nil
+}
+
+// CancelChangeRequest 申请人撤回自己尚未审核的变更申请
+func CancelChangeRequest(ctx context.Context, userID, requestID int64) *apiError.ApiError {
+	req, err := dao.GetChangeRequestByID(ctx, requestID)
+	if err != nil {
+		zap.L().Error("查询变更申请失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "查询变更申请失败"}
+	}
+	if req == nil {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "变更申请不存在"}
+	}
+	if req.ProposerID != userID {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "只能撤回自己提交的申请"}
+	}
+
+	req.Status = ChangeStatusCancelled
+	req.UpdateTime = time.Now()
+	ok, err := dao.UpdateChangeRequestStatus(ctx, req, ChangeStatusPending)
+	if err != nil {
+		zap.L().Error("撤回变更申请失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤回变更申请失败"}
+	}
+	if !ok {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "该申请已被处理"}
+	}
+	return nil
+}
+
+// loadReviewableChangeRequest 加载待审核的申请及其关联的工作表和课程，并校验审核人是否为排课员
+func loadReviewableChangeRequest(ctx context.Context, reviewerID, requestID int64) (*model.ChangeRequest, *model.Sheet, *model.DraggableItem, *apiError.ApiError) {
+	req, err := dao.GetChangeRequestByID(ctx, requestID)
+	if err != nil {
+		zap.L().Error("查询变更申请失败", zap.Int64("requestID", requestID), zap.Error(err))
+		return nil, nil, nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询变更申请失败"}
+	}
+	if req == nil {
+		return nil, nil, nil, &apiError.ApiError{Code: code.NotFound, Msg: "变更申请不存在"}
+	}
+	if req.Status != ChangeStatusPending {
+		return nil, nil, nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "该申请已被处理"}
+	}
+
+	sheet, err := dao.GetSheetByID(ctx, req.SheetID)
+	if err != nil {
+		zap.L().Error("查询工作表失败", zap.Int64("sheetID", req.SheetID), zap.Error(err))
+		return nil, nil, nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询工作表失败"}
+	}
+	if sheet == nil {
+		return nil, nil, nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	item, err := dao.GetDraggableItemByID(ctx, req.ItemID)
+	if err != nil || item == nil {
+		return nil, nil, nil, &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
+	}
+	if !isClassScheduler(ctx, reviewerID, sheet) {
+		return nil, nil, nil, &apiError.ApiError{Code: code.NoPermission, Msg: "只有排课员可以审核变更申请"}
+	}
+	return req, sheet, item, nil
+}
+
+// applyChangeRequestTx 在事务 tx 中按申请内容修改课表，成功时返回提交后需要执行的推送和通知
+func applyChangeRequestTx(ctx context.Context, tx *gorm.DB, reviewerID int64, req *model.ChangeRequest, sheet *model.Sheet, item *model.DraggableItem) (func(), *apiError.ApiError) {
+	switch req.Action {
+	case ChangeActionMove:
+		return applyMoveDragItemTx(ctx, tx, reviewerID, sheet, item, &DTO.MoveDragItemRequest{
+			TargetRow: int(req.TargetRow),
+			TargetCol: int(req.TargetCol),
+		})
+	case ChangeActionRemove:
+		cell, err := dao.GetCellByPositionTx(ctx, tx, sheet.ID, int(req.TargetRow), int(req.TargetCol))
+		if err != nil || cell == nil {
+			zap.L().Error("查询单元格失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取单元格失败"}
+		}
+		// 申请提交后单元格可能已被修改，此时不能再按原申请移除
+		if cell.ItemID == nil || *cell.ItemID != req.ItemID {
+			return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "单元格内容已变化，无法应用该申请"}
+		}
+		return applyDeleteItemInCellTx(ctx, tx, reviewerID, sheet, item, cell)
+	default:
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "未知的申请类型"}
+	}
+}
+
+func validChangeStatus(status string) bool {
+	switch status {
+	case "", ChangeStatusPending, ChangeStatusApproved, ChangeStatusRejected, ChangeStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func toChangeRequestDTO(req *model.ChangeRequest) *DTO.ChangeRequestDTO {
+	return &DTO.ChangeRequestDTO{
+		ID:           req.ID,
+		ClassID:      req.ClassID,
+		SheetID:      req.SheetID,
+		ItemID:       req.ItemID,
+		Action:       req.Action,
+		TargetRow:    int(req.TargetRow),
+		TargetCol:    int(req.TargetCol),
+		Comment:      req.Comment,
+		Status:       req.Status,
+		ProposerID:   req.ProposerID,
+		ReviewerID:   req.ReviewerID,
+		RejectReason: req.RejectReason,
+		CreateTime:   req.CreateTime.Format(time.RFC3339),
+		UpdateTime:   req.UpdateTime.Format(time.RFC3339),
+	}
+}
+
+func toChangeRequestListDTO(reqs []*model.ChangeRequest, total int64) *DTO.ChangeRequestListDTO {
+	list := make([]DTO.ChangeRequestDTO, 0, len(reqs))
+	for _, req := range reqs {
+		list = append(list, *toChangeRequestDTO(req))
+	}
+	return &DTO.ChangeRequestListDTO{Total: total, List: list}
+}
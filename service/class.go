@@ -94,5 +94,10 @@ func DeleteClass(ctx context.Context, userID, classID int64) *apiError.ApiError
 		zap.L().Error("删除班级失败", zap.Error(err))
 		return &apiError.ApiError{Code: code.ServerError, Msg: "删除班级失败"}
 	}
+	emitWebhookEvent(ctx, WebhookEventClassDeleted, classID, &DTO.WebhookClassEventDTO{
+		ClassID:    classID,
+		Name:       class.Name,
+		OperatorID: userID,
+	})
 	return nil
 }
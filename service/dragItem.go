@@ -13,6 +13,7 @@ import (
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func CreateDragItem(ctx context.Context, userID int64, req *DTO.CreateDragItemRequestDTO) (*DTO.DragItemResponseDTO, *apiError.ApiError) {
@@ -145,7 +146,7 @@ func GetDragItem(ctx context.Context, userID int64, itemID int64) (*DTO.DragItem
 func UpdateDragItem(ctx context.Context, userID int64, itemID int64, req *DTO.UpdateDragItemRequestDTO) (*DTO.DragItemResponseDTO, *apiError.ApiError) {
 	item, err := dao.GetDraggableItemByID(ctx, itemID)
 	if err != nil || item == nil {
-		return nil, &apiError.ApiError{code.NotFound, "元素不存在"}
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
 	}
 	if item.CreatorID != userID {
 		return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限读取该元素"}
@@ -166,7 +167,7 @@ func UpdateDragItem(ctx context.Context, userID int64, itemID int64, req *DTO.Up
 	item.Classroom = req.ClassRoom
 	if err := dao.UpdateDraggableItemTx(ctx, tx, item); err != nil {
 		tx.Rollback()
-		return nil, &apiError.ApiError{code.ServerError, "基础信息更新失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "基础信息更新失败"}
 	}
 
 	// 删除旧的班级关联
@@ -195,7 +196,7 @@ func UpdateDragItem(ctx context.Context, userID int64, itemID int64, req *DTO.Up
 	// 获取最新班级名称
 	classNames, _ := dao.GetClassNamesByItemID(ctx, itemID) // 忽略错误，主流程已成功
 
-	return &DTO.DragItemResponseDTO{
+	result := &DTO.DragItemResponseDTO{
 		ID:         item.ID,
 		Content:    item.Content,
 		WeekType:   item.WeekType,
@@ -205,13 +206,15 @@ func UpdateDragItem(ctx context.Context, userID int64, itemID int64, req *DTO.Up
 		CreatorID:  item.CreatorID,
 		CreateTime: item.CreateTime.Format(time.RFC3339),
 		UpdateTime: item.UpdateTime.Format(time.RFC3339),
-	}, nil
+	}
+	publishItemUpdated(ctx, userID, result)
+	return result, nil
 }
 
 func DeleteDragItem(ctx context.Context, userID int64, itemID int64) *apiError.ApiError {
 	item, err := dao.GetDraggableItemByID(ctx, itemID)
 	if err != nil || item == nil {
-		return &apiError.ApiError{code.NotFound, "元素不存在"}
+		return &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
 	}
 	if item.CreatorID != userID {
 		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限读取该元素"}
@@ -222,10 +225,10 @@ func DeleteDragItem(ctx context.Context, userID int64, itemID int64) *apiError.A
 		zap.L().Error("DeleteDragItem 检查引用失败",
 			zap.Int64("itemID", itemID),
 			zap.Error(err))
-		return &apiError.ApiError{code.ServerError, "系统繁忙，请稍后再试"}
+		return &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
 	}
 	if refCount > 0 {
-		return &apiError.ApiError{code.ServerError, "存在关联单元格，请先解除关联"}
+		return &apiError.ApiError{Code: code.ServerError, Msg: "存在关联单元格，请先解除关联"}
 	}
 	// 执行删除操作
 	// 开启事务
@@ -251,7 +254,7 @@ func DeleteDragItem(ctx context.Context, userID int64, itemID int64) *apiError.A
 		zap.L().Error("DeleteDragItem 删除失败",
 			zap.Int64("itemID", itemID),
 			zap.Error(err))
-		return &apiError.ApiError{code.ServerError, "删除操作失败"}
+		return &apiError.ApiError{Code: code.ServerError, Msg: "删除操作失败"}
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -261,62 +264,115 @@ func DeleteDragItem(ctx context.Context, userID int64, itemID int64) *apiError.A
 	return nil
 }
 
-// MoveDragItem 实现拖拽元素的移动或交换
-// 业务逻辑：
-// 1. 如果拖拽元素原本在某个单元格中（sourceCell 不为 nil）
-//   - 当目标单元格已有拖拽元素时，交换两个单元格的 item_id
-//   - 当目标单元格为空时，直接移动拖拽元素（同时清空原单元格的关联）
-//
-// 2. 如果拖拽元素原本不在任何单元格中（sourceCell 为 nil，即在待拖拽列表中）
-//   - 当目标单元格已有拖拽元素时，返回错误
-//   - 当目标单元格为空时，从待拖拽列表中获取该拖拽元素，并关联该拖拽元素
-func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int64, dto *DTO.MoveDragItemRequest) *apiError.ApiError {
+// MoveDragItem 处理拖拽请求
+// 排课员（见 isClassScheduler）的操作直接生效；
+// 任课老师移动自己的课程时不直接修改课表，而是生成一条待排课员审核的变更申请并返回
+func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int64, dto *DTO.MoveDragItemRequest) (*DTO.ChangeRequestDTO, *apiError.ApiError) {
 	item, err := dao.GetDraggableItemByID(ctx, dragItemID)
 	if err != nil || item == nil {
 		zap.L().Error("MoveDragItem 获取元素失败", zap.Error(err))
-		return &apiError.ApiError{code.NotFound, "元素不存在"}
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
 	}
 	itemClassIDs, err := dao.GetDraggableItemClassIDs(ctx, item.ID)
 	if err != nil {
 		zap.L().Error("MoveDragItem 获取元素班级失败", zap.Error(err))
-		return &apiError.ApiError{code.ServerError, "系统繁忙，请稍后再试"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
 	}
 	if !slices.Contains(itemClassIDs, classID) {
-		return &apiError.ApiError{code.NoPermission, "无权限操作该班级的元素"}
+		return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "无权限操作该班级的元素"}
 	}
 
 	userName, err := dao.GetUserNameByID(ctx, userID)
 	if err != nil {
 		zap.L().Error("MoveDragItem 获取用户名失败", zap.Error(err))
-		return &apiError.ApiError{code.ServerError, "系统繁忙，请稍后再试"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
+	}
+	sheet, err := dao.GetSheetByID(ctx, sheetID)
+	if err != nil {
+		zap.L().Error("MoveDragItem 获取工作表失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
 	}
-	if item.CreatorID != userID && item.Teacher != userName {
+	if sheet == nil || sheet.ClassID != classID {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	// 排课员可以移动任意课程，其他用户只能移动自己任教的课程
+	scheduler := isClassScheduler(ctx, userID, sheet)
+	if !scheduler && item.Teacher != userName {
 		zap.L().Error("MoveDragItem 没有权限读取该元素",
 			zap.Int64("userID", userID),
 			zap.Int64("dragItemID", dragItemID))
-		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限读取该元素"}
+		return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限读取该元素"}
+	}
+	// 课程或目标单元格正被他人拖动时不允许修改
+	if apiErr := checkLocks(ctx, userID, dto.LockToken,
+		dragItemLockKey(item.ID), cellLockKey(sheetID, dto.TargetRow, dto.TargetCol)); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if scheduler {
+		return nil, applyMoveDragItem(ctx, userID, sheet, item, dto)
+	}
+	if dto.ExpectedVersion != nil {
+		targetCell, err := dao.GetCellByPosition(ctx, sheetID, dto.TargetRow, dto.TargetCol)
+		if err != nil {
+			zap.L().Error("MoveDragItem 获取目标单元格失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取目标单元格失败"}
+		}
+		if apiErr := checkCellVersion(ctx, targetCell, dto.ExpectedVersion); apiErr != nil {
+			return nil, apiErr
+		}
 	}
+	return createChangeRequest(ctx, &model.ChangeRequest{
+		ClassID:    classID,
+		SheetID:    sheetID,
+		ItemID:     item.ID,
+		Action:     ChangeActionMove,
+		TargetRow:  int32(dto.TargetRow),
+		TargetCol:  int32(dto.TargetCol),
+		Comment:    dto.Comment,
+		ProposerID: userID,
+	})
+}
 
-	db := mysql.GetDB().WithContext(ctx)
-	tx := db.Begin()
+// applyMoveDragItem 实现拖拽元素的移动或交换，调用方需先完成权限校验。
+// 当前周和按周类型同步的其他周在同一个事务中写入，任何一周失败都整体回滚
+func applyMoveDragItem(ctx context.Context, userID int64, sheet *model.Sheet, item *model.DraggableItem, dto *DTO.MoveDragItemRequest) *apiError.ApiError {
+	tx := mysql.GetDB().WithContext(ctx).Begin()
 	// 保证事务异常或 panic 时回滚
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
-	sheet, err := dao.GetSheetByID(ctx, sheetID)
-	if err != nil {
-		zap.L().Error("MoveDragItem 获取工作表失败", zap.Error(err))
-		return &apiError.ApiError{code.ServerError, "系统繁忙，请稍后再试"}
+	afterCommit, apiErr := applyMoveDragItemTx(ctx, tx, userID, sheet, item, dto)
+	if apiErr != nil {
+		tx.Rollback()
+		return apiErr
 	}
-	if sheet == nil {
-		zap.L().Error("MoveDragItem 获取工作表失败", zap.Error(err))
-		return &apiError.ApiError{code.NotFound, "工作表不存在"}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		zap.L().Error("MoveDragItem 事务提交失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
 	}
+	afterCommit()
+	return nil
+}
+
+// applyMoveDragItemTx 在事务 tx 中写入移动结果，成功时返回提交后需要执行的推送和通知，失败时由调用方回滚
+// 业务逻辑：
+// 1. 如果拖拽元素原本在某个单元格中（sourceCell 不为 nil）
+//   - 当目标单元格已有拖拽元素时，交换两个单元格的 item_id
+//   - 当目标单元格为空时，直接移动拖拽元素（同时清空原单元格的关联）
+//
+// 2. 如果拖拽元素原本不在任何单元格中（sourceCell 为 nil，即在待拖拽列表中）
+//   - 当目标单元格已有拖拽元素时，返回错误
+//   - 当目标单元格为空时，从待拖拽列表中获取该拖拽元素，并关联该拖拽元素
+func applyMoveDragItemTx(ctx context.Context, tx *gorm.DB, userID int64, sheet *model.Sheet, item *model.DraggableItem, dto *DTO.MoveDragItemRequest) (func(), *apiError.ApiError) {
+	sheetID := sheet.ID
+	dragItemID := item.ID
 	week := sheet.Week
 	var sheets []*model.Sheet
-	err = mysql.GetDB().WithContext(ctx).Where("week = ? AND delete_time = 0", week).Find(&sheets).Error
+	err := mysql.GetDB().WithContext(ctx).Where("week = ? AND delete_time = 0", week).Find(&sheets).Error
 	if err == nil {
 		for _, sheet := range sheets {
 			cells, err := dao.GetCellsBySheetID(ctx, sheet.ID)
@@ -326,8 +382,8 @@ func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int6
 			for _, cell := range cells {
 				if int(cell.RowIndex) == dto.TargetRow && int(cell.ColIndex) == dto.TargetCol && cell.ItemID != nil {
 					dragItem, _ := dao.GetDraggableItemByID(ctx, *cell.ItemID)
-					if dragItem != nil && dragItem.Teacher == item.Teacher && item.ID != dragItemID {
-						return &apiError.ApiError{Code: code.ServerError, Msg: "同一教师在该周该位置已存在拖拽元素"}
+					if dragItem != nil && dragItem.Teacher == item.Teacher && dragItem.ID != dragItemID {
+						return nil, &apiError.ApiError{Code: code.ServerError, Msg: "同一教师在该周该位置已存在拖拽元素"}
 					}
 				}
 			}
@@ -335,57 +391,51 @@ func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int6
 	}
 	if item.WeekType == "single" {
 		if week%2 == 0 {
-			tx.Rollback()
-			return &apiError.ApiError{Code: code.ServerError, Msg: "单周课程不能添加到双周表格"}
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "单周课程不能添加到双周表格"}
 		}
 	} else if item.WeekType == "double" {
 		if week%2 != 0 {
-			tx.Rollback()
-			return &apiError.ApiError{Code: code.ServerError, Msg: "双周课程不能添加到单周表格"}
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "双周课程不能添加到单周表格"}
 		}
 	}
 
 	// 获取目标单元格
 	targetCell, err := dao.GetCellByPositionTx(ctx, tx, sheetID, dto.TargetRow, dto.TargetCol)
 	if err != nil || targetCell == nil {
-		tx.Rollback()
 		zap.L().Error("MoveDragItem 获取目标单元格失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取目标单元格失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取目标单元格失败"}
+	}
+	if apiErr := checkCellVersion(ctx, targetCell, dto.ExpectedVersion); apiErr != nil {
+		return nil, apiErr
 	}
 	if targetCell.ItemID != nil && targetCell.LastModifiedBy != userID {
 		// 目标单元格已有拖拽元素，不允许移动
-		tx.Rollback()
-		return &apiError.ApiError{Code: code.ServerError, Msg: "目标单元格已有拖拽元素"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "目标单元格已有拖拽元素"}
 	}
 
 	// 更新单元格更新时间
 	targetCell.UpdateTime = time.Now()
 	targetCell.ItemID = &item.ID
 	targetCell.LastModifiedBy = userID
-	if err := dao.UpdateCellTx(ctx, tx, targetCell); err != nil {
-		tx.Rollback()
+	updated, err := dao.UpdateCellWithVersionTx(ctx, tx, targetCell)
+	if err != nil {
 		zap.L().Error("MoveDragItem 更新源单元格失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "更新单元格失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新单元格失败"}
 	}
-
-	if err := tx.Commit().Error; err != nil {
-		tx.Rollback()
-		zap.L().Error("MoveDragItem 事务提交失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
+	if !updated {
+		// 读取之后单元格被他人修改
+		return nil, cellConflictError(ctx, sheetID, dto.TargetRow, dto.TargetCol, int64(targetCell.Version))
 	}
 
-	currentSheet, err := dao.GetSheetByID(ctx, sheetID)
-	if err != nil || currentSheet == nil {
-		zap.L().Error("获取工作表信息失败", zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取工作表失败"}
-	}
+	// 受影响的工作表，包括按周类型同步到的其他周
+	placedSheetIDs := []int64{sheetID}
 
-	totalWeeks, err := dao.GetClassTotalWeeks(ctx, currentSheet.ClassID)
+	totalWeeks, err := dao.GetClassTotalWeeks(ctx, sheet.ClassID)
 	if err != nil {
 		zap.L().Error("获取班级总周数失败",
-			zap.Int64("classID", currentSheet.ClassID),
+			zap.Int64("classID", sheet.ClassID),
 			zap.Error(err))
-		return &apiError.ApiError{Code: code.ServerError, Msg: "获取班级周数失败"}
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取班级周数失败"}
 	}
 	// 根据周类型生成目标周列表
 	var targetWeeks []int
@@ -406,12 +456,12 @@ func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int6
 
 	// 为每个目标周创建/更新单元格
 	for _, week := range targetWeeks {
-		if week == int(currentSheet.Week) { // 跳过当前周（已处理）
+		if week == int(sheet.Week) { // 跳过当前周（已处理）
 			continue
 		}
 
 		// 获取目标周的工作表
-		targetSheet, err := dao.GetSheetByClassIDandWeek(ctx, currentSheet.ClassID, week)
+		targetSheet, err := dao.GetSheetByClassIDandWeek(ctx, sheet.ClassID, week)
 		if err != nil {
 			zap.L().Error("获取周工作表失败",
 				zap.Int("week", week),
@@ -437,10 +487,7 @@ func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int6
 							continue
 						}
 						if dragItem != nil && dragItem.Teacher == item.Teacher && dragItem.ID != item.ID {
-							if dragItem.Teacher == item.Teacher {
-								tx.Rollback() // 回滚事务
-								return &apiError.ApiError{Code: code.ServerError, Msg: fmt.Sprintf("在%s班级该位置已存在同一教师的不同课程", className)}
-							}
+							return nil, &apiError.ApiError{Code: code.ServerError, Msg: fmt.Sprintf("在%s班级该位置已存在同一教师的不同课程", className)}
 						}
 					}
 				}
@@ -448,34 +495,43 @@ func MoveDragItem(ctx context.Context, classID, userID, sheetID, dragItemID int6
 		}
 
 		// 获取目标单元格
-		targetCell, err := dao.GetCellByPosition(ctx, targetSheet.ID, dto.TargetRow, dto.TargetCol)
-		if err != nil || targetCell == nil {
+		weekCell, err := dao.GetCellByPositionTx(ctx, tx, targetSheet.ID, dto.TargetRow, dto.TargetCol)
+		if err != nil || weekCell == nil {
 			zap.L().Error("获取目标单元格失败",
 				zap.Int("week", week),
 				zap.Error(err))
 			continue
 		}
-		if targetCell.ItemID != nil {
+		if weekCell.ItemID != nil {
 			// 目标单元格已有拖拽元素，不允许移动
 			zap.L().Error("目标单元格已有拖拽元素",
 				zap.Int("week", week),
-				zap.Int64("itemID", *targetCell.ItemID))
-			tx.Rollback() // 回滚事务
-			return &apiError.ApiError{Code: code.ServerError, Msg: fmt.Sprintf("该班级第%d周该位置有课程", week)}
+				zap.Int64("itemID", *weekCell.ItemID))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: fmt.Sprintf("该班级第%d周该位置有课程", week)}
 		}
-		targetCell.ItemID = &dragItemID
-		targetCell.UpdateTime = time.Now()
+		weekCell.ItemID = &dragItemID
+		weekCell.LastModifiedBy = userID
+		weekCell.UpdateTime = time.Now()
 
-		// 更新目标单元格
-		if err := dao.UpdateCell(ctx, sheetID, targetCell); err != nil {
+		// 更新目标周的单元格，读取之后被他人修改过则整体放弃
+		updated, err := dao.UpdateCellWithVersionTx(ctx, tx, weekCell)
+		if err != nil {
 			zap.L().Error("更新周单元格失败",
 				zap.Int("week", week),
 				zap.Error(err))
-			return &apiError.ApiError{Code: code.ServerError, Msg: "更新周单元格失败"}
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新周单元格失败"}
+		}
+		if !updated {
+			return nil, cellConflictError(ctx, targetSheet.ID, dto.TargetRow, dto.TargetCol, int64(weekCell.Version))
 		}
+		placedSheetIDs = append(placedSheetIDs, targetSheet.ID)
 	}
 
-	return nil
+	return func() {
+		publishItemPlaced(ctx, userID, sheet.ClassID, placedSheetIDs, dragItemID, dto.TargetRow, dto.TargetCol)
+		notifyTeacher(ctx, userID, NotificationItemPlaced, sheet, item, dto.TargetRow, dto.TargetCol)
+		emitItemWebhookEvent(ctx, WebhookEventItemPlaced, userID, sheet, item, dto.TargetRow, dto.TargetCol)
+	}, nil
 }
 
 func ViewCoursesByWeek(ctx context.Context, username string, week int) (*DTO.ViewCourseResponse, *apiError.ApiError) {
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/mailer"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxBatchSize    = 50
+	outboxSendTimeout  = 30 * time.Second
+	outboxStaleTimeout = 10 * time.Minute // 超过该时间仍处于 sending 的邮件视为投递中断
+)
+
+// enqueueEmail 将邮件写入发件箱，由后台协程异步投递
+func enqueueEmail(ctx context.Context, to, subject, body string) error {
+	return dao.CreateOutboxEmail(ctx, &model.EmailOutbox{
+		ToAddress:       to,
+		Subject:         subject,
+		Body:            body,
+		Status:          "pending",
+		NextAttemptTime: time.Now(),
+		CreateTime:      time.Now(),
+		UpdateTime:      time.Now(),
+	})
+}
+
+// StartEmailOutboxWorker 启动后台协程定期投递发件箱中的邮件，ctx 取消时退出
+func StartEmailOutboxWorker(ctx context.Context) {
+	cfg := settings.GetConfig().MailConfig
+	interval := time.Duration(cfg.OutboxInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deliverOutboxEmails(ctx, mailer.GetSender(), cfg.MaxAttempts)
+			}
+		}
+	}()
+}
+
+// deliverOutboxEmails 投递一批到期的邮件
+// 失败的邮件按 1、2、4、8... 分钟退避重试，达到最大次数后标记为 failed
+func deliverOutboxEmails(ctx context.Context, sender mailer.Sender, maxAttempts int) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("投递发件箱邮件时发生 panic", zap.Any("panic", r))
+		}
+	}()
+
+	if err := dao.ResetStaleOutboxEmails(ctx, time.Now().Add(-outboxStaleTimeout)); err != nil {
+		zap.L().Error("重置中断的发件箱邮件失败", zap.Error(err))
+	}
+	emails, err := dao.ListDueOutboxEmails(ctx, outboxBatchSize)
+	if err != nil {
+		zap.L().Error("查询发件箱失败", zap.Error(err))
+		return
+	}
+
+	for _, email := range emails {
+		claimed, err := dao.ClaimOutboxEmail(ctx, email.ID)
+		if err != nil || !claimed {
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+		err = sender.Send(sendCtx, &mailer.Message{
+			To:      email.ToAddress,
+			Subject: email.Subject,
+			Body:    email.Body,
+		})
+		cancel()
+
+		email.Attempts++
+		if err == nil {
+			email.Status = "sent"
+			email.LastError = ""
+		} else {
+			zap.L().Warn("邮件投递失败",
+				zap.Int64("emailID", email.ID),
+				zap.Int32("attempts", email.Attempts),
+				zap.Error(err))
+			email.LastError = truncate(err.Error(), 512)
+			if int(email.Attempts) >= maxAttempts {
+				email.Status = "failed"
+			} else {
+				email.Status = "pending"
+				email.NextAttemptTime = time.Now().Add(time.Duration(1<<(email.Attempts-1)) * time.Minute)
+			}
+		}
+		if err := dao.UpdateOutboxEmailResult(ctx, email); err != nil {
+			zap.L().Error("更新邮件投递结果失败", zap.Int64("emailID", email.ID), zap.Error(err))
+		}
+	}
+}
+
+// truncate 按字符截断字符串，避免超出数据库字段长度
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
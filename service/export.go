@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+var weekdayNames = []string{"周一", "周二", "周三", "周四", "周五", "周六", "周日"}
+
+// ExportSheetCSV 将工作表导出为 CSV，返回文件名和文件内容
+func ExportSheetCSV(ctx context.Context, userID, classID, sheetID int64) (string, []byte, *apiError.ApiError) {
+	sheet, apiErr := getSheetInClass(ctx, classID, sheetID)
+	if apiErr != nil {
+		return "", nil, apiErr
+	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessRead); apiErr != nil {
+		return "", nil, apiErr
+	}
+	return exportSheetCSV(ctx, sheet)
+}
+
+// exportSheetCSV 按课表的行列生成 CSV：第一行为星期，第一列为节次，
+// 每个单元格依次包含课程名称、教室、任课老师和单双周。调用方负责权限校验
+func exportSheetCSV(ctx context.Context, sheet *model.Sheet) (string, []byte, *apiError.ApiError) {
+	cells, err := dao.GetCellsBySheetID(ctx, sheet.ID)
+	if err != nil {
+		zap.L().Error("导出工作表时查询单元格失败", zap.Int64("sheetID", sheet.ID), zap.Error(err))
+		return "", nil, &apiError.ApiError{Code: code.ServerError, Msg: "导出工作表失败"}
+	}
+
+	grid := make([][]string, sheet.Row)
+	for i := range grid {
+		grid[i] = make([]string, sheet.Col)
+	}
+	items := make(map[int64]*model.DraggableItem)
+	for _, cell := range cells {
+		if cell.ItemID == nil || cell.RowIndex < 1 || cell.RowIndex > sheet.Row || cell.ColIndex < 1 || cell.ColIndex > sheet.Col {
+			continue
+		}
+		item, ok := items[*cell.ItemID]
+		if !ok {
+			if item, err = dao.GetDraggableItemByID(ctx, *cell.ItemID); err != nil {
+				zap.L().Error("导出工作表时查询课程失败", zap.Int64("itemID", *cell.ItemID), zap.Error(err))
+				return "", nil, &apiError.ApiError{Code: code.ServerError, Msg: "导出工作表失败"}
+			}
+			items[*cell.ItemID] = item
+		}
+		if item == nil {
+			continue
+		}
+		lines := []string{item.Content, item.Classroom, item.Teacher}
+		if name, ok := weekTypeNames[item.WeekType]; ok && item.WeekType != "all" {
+			lines = append(lines, name)
+		}
+		grid[cell.RowIndex-1][cell.ColIndex-1] = strings.Join(lines, "\n")
+	}
+
+	var buf bytes.Buffer
+	// 写入 UTF-8 BOM，避免 Excel 打开时中文乱码
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	header := make([]string, 0, sheet.Col+1)
+	header = append(header, "")
+	for col := 1; col <= int(sheet.Col); col++ {
+		if col <= len(weekdayNames) {
+			header = append(header, weekdayNames[col-1])
+		} else {
+			header = append(header, fmt.Sprintf("第%d列", col))
+		}
+	}
+	_ = w.Write(header)
+	for row := range grid {
+		record := append([]string{fmt.Sprintf("第%d节", row+1)}, grid[row]...)
+		_ = w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		zap.L().Error("生成 CSV 失败", zap.Int64("sheetID", sheet.ID), zap.Error(err))
+		return "", nil, &apiError.ApiError{Code: code.ServerError, Msg: "导出工作表失败"}
+	}
+
+	filename := fmt.Sprintf("%s-第%d周.csv", sheet.Name, sheet.Week)
+	return filename, buf.Bytes(), nil
+}
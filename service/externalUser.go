@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/pkg/snowflake"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"go.uber.org/zap"
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// externalUserStore 外部身份映射用到的用户和身份绑定数据，默认读写数据库，测试时可以替换为内存实现
+type externalUserStore interface {
+	GetUserIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	CreateUserIdentity(ctx context.Context, identity *model.UserIdentity) error
+	TouchUserIdentity(ctx context.Context, id int64, email string) error
+	FindUserByID(ctx context.Context, userID int64) (*model.User, error)
+	FindUsersByEmail(ctx context.Context, email string, limit int) ([]*model.User, error)
+	FindUserByUsername(ctx context.Context, username string) (*model.User, error)
+	CreateUser(ctx context.Context, user *model.User) error
+}
+
+type daoExternalUserStore struct{}
+
+func (daoExternalUserStore) GetUserIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	return dao.GetUserIdentity(ctx, provider, subject)
+}
+
+func (daoExternalUserStore) CreateUserIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	return dao.CreateUserIdentity(ctx, identity)
+}
+
+func (daoExternalUserStore) TouchUserIdentity(ctx context.Context, id int64, email string) error {
+	return dao.TouchUserIdentity(ctx, id, email)
+}
+
+func (daoExternalUserStore) FindUserByID(ctx context.Context, userID int64) (*model.User, error) {
+	return dao.FindUserByID(ctx, userID)
+}
+
+func (daoExternalUserStore) FindUsersByEmail(ctx context.Context, email string, limit int) ([]*model.User, error) {
+	return dao.FindUsersByEmail(ctx, email, limit)
+}
+
+func (daoExternalUserStore) FindUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	return dao.FindUserByUsername(ctx, username)
+}
+
+func (daoExternalUserStore) CreateUser(ctx context.Context, user *model.User) error {
+	return dao.CreateUser(ctx, user)
+}
+
+var externalUsers externalUserStore = daoExternalUserStore{}
+
+// resolveExternalUser 将外部身份（OIDC、LDAP）映射为本地用户：
+// 依次按已绑定身份、已验证邮箱查找，都找不到时在 autoCreate 为 true 时自动创建，并记录绑定关系。
+// 按邮箱绑定要求外部身份和本地账号的邮箱都已验证，且邮箱只属于一个本地账号：
+// 否则攻击者可以先用他人邮箱注册而不验证，等对方通过单点登录时登录到攻击者控制的账号
+func resolveExternalUser(ctx context.Context, identity *sso.Identity, linkByEmail, autoCreate bool) (*model.User, *apiError.ApiError) {
+	serverErr := &apiError.ApiError{Code: code.ServerError, Msg: "登录失败"}
+
+	bound, err := externalUsers.GetUserIdentity(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		zap.L().Error("查询外部身份失败", zap.Error(err))
+		return nil, serverErr
+	}
+	if bound != nil {
+		user, err := externalUsers.FindUserByID(ctx, bound.UserID)
+		if err != nil {
+			zap.L().Error("查询用户失败", zap.Int64("userID", bound.UserID), zap.Error(err))
+			return nil, serverErr
+		}
+		if user == nil || user.UserID == 0 {
+			return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "绑定的账号已注销"}
+		}
+		if err := externalUsers.TouchUserIdentity(ctx, bound.ID, identity.Email); err != nil {
+			zap.L().Error("更新外部身份登录时间失败", zap.Int64("identityID", bound.ID), zap.Error(err))
+		}
+		return user, nil
+	}
+
+	var user *model.User
+	if linkByEmail && identity.Email != "" && identity.EmailVerified {
+		user, err = findLinkableUser(ctx, identity)
+		if err != nil {
+			zap.L().Error("根据邮箱查询用户失败", zap.Error(err))
+			return nil, serverErr
+		}
+	}
+	if user == nil {
+		if !autoCreate {
+			return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "该账号尚未开通，请联系管理员"}
+		}
+		if user, err = createExternalUser(ctx, identity); err != nil {
+			zap.L().Error("创建外部身份用户失败", zap.String("provider", identity.Issuer), zap.String("subject", identity.Subject), zap.Error(err))
+			return nil, serverErr
+		}
+	}
+
+	now := time.Now()
+	if err := externalUsers.CreateUserIdentity(ctx, &model.UserIdentity{
+		UserID:        user.UserID,
+		Provider:      identity.Issuer,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		LastLoginTime: &now,
+		CreateTime:    now,
+		UpdateTime:    now,
+	}); err != nil {
+		zap.L().Error("绑定外部身份失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return nil, serverErr
+	}
+	zap.L().Info("绑定外部身份", zap.Int64("userID", user.UserID), zap.String("provider", identity.Issuer), zap.String("subject", identity.Subject))
+	return user, nil
+}
+
+// findLinkableUser 查找可以按邮箱绑定外部身份的本地用户，邮箱未验证或属于多个账号时返回 nil
+func findLinkableUser(ctx context.Context, identity *sso.Identity) (*model.User, error) {
+	users, err := externalUsers.FindUsersByEmail(ctx, identity.Email, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	if len(users) > 1 {
+		zap.L().Warn("邮箱属于多个本地账号，不按邮箱绑定外部身份",
+			zap.String("provider", identity.Issuer), zap.String("subject", identity.Subject))
+		return nil, nil
+	}
+	if !users[0].EmailVerified {
+		zap.L().Warn("本地账号的邮箱未验证，不按邮箱绑定外部身份",
+			zap.Int64("userID", users[0].UserID), zap.String("provider", identity.Issuer), zap.String("subject", identity.Subject))
+		return nil, nil
+	}
+	return users[0], nil
+}
+
+// createExternalUser 首次通过外部身份登录时创建本地用户，用户没有本地密码，可以通过找回密码设置
+func createExternalUser(ctx context.Context, identity *sso.Identity) (*model.User, error) {
+	userID, err := snowflake.GetID()
+	if err != nil {
+		return nil, err
+	}
+	username, err := availableUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		UserID:      userID,
+		Username:    username,
+		DisplayName: identity.Name,
+		Password:    password.Unusable,
+		Role:        defaultUserRole(),
+	}
+	// 未验证的邮箱可能属于他人，不写入用户信息，避免占用他人的邮箱
+	if identity.Email != "" && identity.EmailVerified {
+		existing, err := externalUsers.FindUsersByEmail(ctx, identity.Email, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) == 0 {
+			user.Email = identity.Email
+			user.EmailVerified = true
+		}
+	}
+	if err := externalUsers.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableUsername 依次尝试身份提供方给出的用户名、邮箱前缀、subject，已被占用时追加随机后缀
+func availableUsername(ctx context.Context, identity *sso.Identity) (string, error) {
+	base := ""
+	local, _, _ := strings.Cut(identity.Email, "@")
+	for _, candidate := range []string{identity.Username, local, identity.Subject} {
+		if candidate = usernameInvalidChars.ReplaceAllString(candidate, ""); candidate != "" {
+			base = candidate
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > 48 {
+		base = base[:48]
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		existing, err := externalUsers.FindUserByUsername(ctx, username)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return username, nil
+		}
+		b := make([]byte, 3)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		username = base + "_" + hex.EncodeToString(b)
+	}
+	return "", errors.New("无法生成可用的用户名")
+}
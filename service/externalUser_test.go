@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/sso"
+)
+
+// memoryExternalUserStore 进程内的 externalUserStore，模拟数据库中的用户和身份绑定
+type memoryExternalUserStore struct {
+	users      []*model.User
+	identities []*model.UserIdentity
+}
+
+func (s *memoryExternalUserStore) GetUserIdentity(_ context.Context, provider, subject string) (*model.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryExternalUserStore) CreateUserIdentity(_ context.Context, identity *model.UserIdentity) error {
+	identity.ID = int64(len(s.identities) + 1)
+	s.identities = append(s.identities, identity)
+	return nil
+}
+
+func (s *memoryExternalUserStore) TouchUserIdentity(_ context.Context, id int64, email string) error {
+	for _, identity := range s.identities {
+		if identity.ID == id {
+			identity.Email = email
+		}
+	}
+	return nil
+}
+
+// FindUserByID 与 dao.FindUserByID 一致，不存在时返回零值用户
+func (s *memoryExternalUserStore) FindUserByID(_ context.Context, userID int64) (*model.User, error) {
+	for _, user := range s.users {
+		if user.UserID == userID {
+			return user, nil
+		}
+	}
+	return &model.User{}, nil
+}
+
+func (s *memoryExternalUserStore) FindUsersByEmail(_ context.Context, email string, limit int) ([]*model.User, error) {
+	var users []*model.User
+	for _, user := range s.users {
+		if user.Email == email && len(users) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (s *memoryExternalUserStore) FindUserByUsername(_ context.Context, username string) (*model.User, error) {
+	for _, user := range s.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryExternalUserStore) CreateUser(_ context.Context, user *model.User) error {
+	s.users = append(s.users, user)
+	return nil
+}
+
+func useMemoryExternalUsers(t *testing.T, users ...*model.User) *memoryExternalUserStore {
+	store := &memoryExternalUserStore{users: users}
+	old := externalUsers
+	externalUsers = store
+	t.Cleanup(func() { externalUsers = old })
+	return store
+}
+
+func TestResolveExternalUser(t *testing.T) {
+	const issuer = "https://idp.example.com"
+	existing := func() *model.User {
+		return &model.User{UserID: 100, Username: "alice", Email: "alice@example.com", EmailVerified: true}
+	}
+
+	// 攻击者用 alice 的邮箱注册但未验证
+	unverified := func() []*model.User {
+		return []*model.User{{UserID: 100, Username: "mallory", Email: "alice@example.com"}}
+	}
+
+	tests := []struct {
+		name         string
+		users        func() []*model.User // 为空时只有已验证邮箱的 alice
+		identity     sso.Identity
+		linkByEmail  bool
+		autoCreate   bool
+		wantCode     code.RespCode // 0 表示成功
+		wantExisting bool          // 是否映射到已有用户 alice
+		wantEmail    string        // 新建用户的邮箱
+	}{
+		{
+			name:         "已验证邮箱绑定已有用户",
+			identity:     sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true, Username: "alice"},
+			linkByEmail:  true,
+			autoCreate:   false,
+			wantExisting: true,
+		},
+		{
+			name:        "未验证邮箱不绑定已有用户，且不自动创建时拒绝登录",
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: false},
+			linkByEmail: true,
+			autoCreate:  false,
+			wantCode:    code.NoPermission,
+		},
+		{
+			name:        "未验证邮箱自动创建新用户，不占用已有用户的邮箱",
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: false, Username: "mallory"},
+			linkByEmail: true,
+			autoCreate:  true,
+			wantEmail:   "",
+		},
+		{
+			name:        "本地账号邮箱未验证时不绑定，且不自动创建时拒绝登录",
+			users:       unverified,
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true, Username: "alice"},
+			linkByEmail: true,
+			autoCreate:  false,
+			wantCode:    code.NoPermission,
+		},
+		{
+			name:        "本地账号邮箱未验证时自动创建独立账号，不占用该邮箱",
+			users:       unverified,
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true, Username: "alice"},
+			linkByEmail: true,
+			autoCreate:  true,
+			wantEmail:   "",
+		},
+		{
+			name: "邮箱属于多个本地账号时不绑定",
+			users: func() []*model.User {
+				return []*model.User{existing(), {UserID: 101, Username: "alice2", Email: "alice@example.com", EmailVerified: true}}
+			},
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true},
+			linkByEmail: true,
+			autoCreate:  false,
+			wantCode:    code.NoPermission,
+		},
+		{
+			name:        "关闭按邮箱绑定时不绑定已有用户",
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-1", Email: "alice@example.com", EmailVerified: true},
+			linkByEmail: false,
+			autoCreate:  false,
+			wantCode:    code.NoPermission,
+		},
+		{
+			name:        "关闭自动创建时未开通的账号不能登录",
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-2", Email: "bob@example.com", EmailVerified: true},
+			linkByEmail: true,
+			autoCreate:  false,
+			wantCode:    code.NoPermission,
+		},
+		{
+			name:        "自动创建时写入已验证的邮箱",
+			identity:    sso.Identity{Issuer: issuer, Subject: "sub-2", Email: "bob@example.com", EmailVerified: true, Username: "bob"},
+			linkByEmail: true,
+			autoCreate:  true,
+			wantEmail:   "bob@example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := []*model.User{existing()}
+			if tt.users != nil {
+				users = tt.users()
+			}
+			store := useMemoryExternalUsers(t, users...)
+			user, apiErr := resolveExternalUser(context.Background(), &tt.identity, tt.linkByEmail, tt.autoCreate)
+			if tt.wantCode != 0 {
+				if apiErr == nil || apiErr.Code != tt.wantCode {
+					t.Fatalf("resolveExternalUser() error = %v，期望错误码 %v", apiErr, tt.wantCode)
+				}
+				if len(store.identities) != 0 {
+					t.Errorf("登录失败时不应记录绑定关系，实际 %d 条", len(store.identities))
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("resolveExternalUser() error = %v", apiErr)
+			}
+			if got := user.UserID == 100; got != tt.wantExisting {
+				t.Fatalf("映射到已有用户 = %v，期望 %v", got, tt.wantExisting)
+			}
+			if !tt.wantExisting {
+				if len(store.users) != len(users)+1 {
+					t.Fatalf("应创建新用户，实际用户数 %d", len(store.users))
+				}
+				if user.Email != tt.wantEmail || user.EmailVerified != (tt.wantEmail != "") {
+					t.Errorf("新用户邮箱 = %q（已验证 %v），期望 %q", user.Email, user.EmailVerified, tt.wantEmail)
+				}
+			}
+			if len(store.identities) != 1 || store.identities[0].UserID != user.UserID || store.identities[0].Subject != tt.identity.Subject {
+				t.Errorf("绑定关系 = %+v，期望绑定到用户 %d", store.identities, user.UserID)
+			}
+		})
+	}
+}
+
+func TestResolveExternalUserBoundIdentity(t *testing.T) {
+	store := useMemoryExternalUsers(t, &model.User{UserID: 100, Username: "alice", Email: "alice@example.com"})
+	store.identities = []*model.UserIdentity{{ID: 1, UserID: 100, Provider: "https://idp.example.com", Subject: "sub-1"}}
+
+	// 已绑定的身份即使邮箱变化、未验证，也直接映射到绑定的用户
+	identity := &sso.Identity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "new@example.com"}
+	user, apiErr := resolveExternalUser(context.Background(), identity, false, false)
+	if apiErr != nil {
+		t.Fatalf("resolveExternalUser() error = %v", apiErr)
+	}
+	if user.UserID != 100 {
+		t.Errorf("UserID = %d，期望 100", user.UserID)
+	}
+	if store.identities[0].Email != "new@example.com" {
+		t.Errorf("应更新绑定记录中的邮箱，实际 %q", store.identities[0].Email)
+	}
+
+	// 绑定的用户已注销
+	store.users = nil
+	if _, apiErr := resolveExternalUser(context.Background(), identity, false, true); apiErr == nil || apiErr.Code != code.NoPermission {
+		t.Errorf("绑定的用户已注销时 error = %v，期望 NoPermission", apiErr)
+	}
+}
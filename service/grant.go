@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// accessLevel 计算用户在班级或其下某个工作表（sheetID 为 0 时只看班级）上的访问级别：
+// 取角色默认级别、班级授权、工作表授权和班级学生身份中的最高者
+func accessLevel(ctx context.Context, userID, classID, sheetID int64) (int, *apiError.ApiError) {
+	role, apiErr := GetUserRole(ctx, userID)
+	if apiErr != nil {
+		return rbac.AccessNone, apiErr
+	}
+	level := rbac.RoleAccessLevel(role)
+	if level == rbac.AccessAdmin {
+		return level, nil
+	}
+	grants, err := dao.ListUserGrantLevels(ctx, userID, classID, sheetID)
+	if err != nil {
+		zap.L().Error("查询用户授权失败",
+			zap.Int64("userID", userID),
+			zap.Int64("classID", classID),
+			zap.Int64("sheetID", sheetID),
+			zap.Error(err))
+		return rbac.AccessNone, &apiError.ApiError{Code: code.ServerError, Msg: "检查权限失败"}
+	}
+	for _, g := range grants {
+		level = max(level, rbac.ParseAccessLevel(g))
+	}
+	// 班级学生可以查看本班级的课表
+	if level < rbac.AccessRead {
+		member, err := dao.IsClassStudent(ctx, classID, userID)
+		if err != nil {
+			zap.L().Error("查询班级学生失败", zap.Int64("userID", userID), zap.Int64("classID", classID), zap.Error(err))
+			return rbac.AccessNone, &apiError.ApiError{Code: code.ServerError, Msg: "检查权限失败"}
+		}
+		if member {
+			level = rbac.AccessRead
+		}
+	}
+	return level, nil
+}
+
+// requireClassAccess 校验用户在班级上至少拥有指定的访问级别
+func requireClassAccess(ctx context.Context, userID, classID int64, required int) *apiError.ApiError {
+	level, apiErr := accessLevel(ctx, userID, classID, 0)
+	if apiErr != nil {
+		return apiErr
+	}
+	if level < required {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有该班级的" + rbac.AccessLevelName(required) + "权限"}
+	}
+	return nil
+}
+
+// requireSheetAccess 校验用户在工作表上至少拥有指定的访问级别
+func requireSheetAccess(ctx context.Context, userID int64, sheet *model.Sheet, required int) *apiError.ApiError {
+	level, apiErr := accessLevel(ctx, userID, sheet.ClassID, sheet.ID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if level < required {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有该工作表的" + rbac.AccessLevelName(required) + "权限"}
+	}
+	return nil
+}
+
+// ListClassGrants 查询班级及其工作表上的全部授权，需要班级 admin 权限
+func ListClassGrants(ctx context.Context, userID, classID int64) ([]DTO.GrantDTO, *apiError.ApiError) {
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
+	perms, err := dao.ListGrantsByClass(ctx, classID)
+	if err != nil {
+		zap.L().Error("查询班级授权失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询授权失败"}
+	}
+	result := make([]DTO.GrantDTO, 0, len(perms))
+	for _, perm := range perms {
+		result = append(result, *toGrantDTO(ctx, perm))
+	}
+	return result, nil
+}
+
+// ShareClass 将班级的访问权限授予用户，班级下的所有工作表继承该权限
+func ShareClass(ctx context.Context, userID, classID int64, dto *DTO.GrantRequestDTO) (*DTO.GrantDTO, *apiError.ApiError) {
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
+	return grantAccess(ctx, userID, classID, 0, dto)
+}
+
+// ShareSheet 将单个工作表的访问权限授予用户
+func ShareSheet(ctx context.Context, userID, classID, sheetID int64, dto *DTO.GrantRequestDTO) (*DTO.GrantDTO, *apiError.ApiError) {
+	sheet, apiErr := getSheetInClass(ctx, classID, sheetID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
+	return grantAccess(ctx, userID, classID, sheetID, dto)
+}
+
+// RevokeGrant 撤销一条授权，需要对授权所在的班级或工作表拥有 admin 权限
+func RevokeGrant(ctx context.Context, userID, classID, grantID int64) *apiError.ApiError {
+	perm, err := dao.GetPermissionByID(ctx, grantID)
+	if err != nil {
+		zap.L().Error("查询授权失败", zap.Int64("grantID", grantID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销授权失败"}
+	}
+	if perm == nil || perm.ClassID != classID {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "授权不存在"}
+	}
+	level, apiErr := accessLevel(ctx, userID, perm.ClassID, perm.SheetID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if level < rbac.AccessAdmin {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限撤销该授权"}
+	}
+	if err := dao.DeletePermission(ctx, grantID); err != nil {
+		zap.L().Error("撤销授权失败", zap.Int64("grantID", grantID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销授权失败"}
+	}
+	return nil
+}
+
+// grantAccess 新增授权，用户已有同一范围的授权时直接修改其级别
+func grantAccess(ctx context.Context, operatorID, classID, sheetID int64, dto *DTO.GrantRequestDTO) (*DTO.GrantDTO, *apiError.ApiError) {
+	if rbac.ParseAccessLevel(dto.AccessLevel) == rbac.AccessNone {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的权限级别"}
+	}
+	if _, apiErr := GetUserRole(ctx, dto.UserID); apiErr != nil {
+		if apiErr.Code == code.InvalidAuth {
+			return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在"}
+		}
+		return nil, apiErr
+	}
+
+	perm, err := dao.GetGrant(ctx, dto.UserID, classID, sheetID)
+	if err != nil {
+		zap.L().Error("查询授权失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "授权失败"}
+	}
+	if perm != nil {
+		if err := dao.UpdatePermissionLevel(ctx, perm.ID, dto.AccessLevel, operatorID); err != nil {
+			zap.L().Error("修改授权级别失败", zap.Int64("grantID", perm.ID), zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "授权失败"}
+		}
+		perm.AccessLevel = dto.AccessLevel
+		perm.GrantedBy = operatorID
+		perm.UpdateTime = time.Now()
+		return toGrantDTO(ctx, perm), nil
+	}
+
+	perm = &model.Permission{
+		UserID:      dto.UserID,
+		ClassID:     classID,
+		SheetID:     sheetID,
+		AccessLevel: dto.AccessLevel,
+		GrantedBy:   operatorID,
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if err := dao.CreatePermission(ctx, perm); err != nil {
+		zap.L().Error("创建授权失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "授权失败"}
+	}
+	return toGrantDTO(ctx, perm), nil
+}
+
+func getClassOrNotFound(ctx context.Context, classID int64) (*model.Class, *apiError.ApiError) {
+	class, err := dao.GetClassByID(ctx, classID)
+	if err != nil {
+		zap.L().Error("查询班级失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询班级失败"}
+	}
+	if class == nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "班级不存在"}
+	}
+	return class, nil
+}
+
+func getSheetInClass(ctx context.Context, classID, sheetID int64) (*model.Sheet, *apiError.ApiError) {
+	sheet, err := dao.GetSheetByID(ctx, sheetID)
+	if err != nil {
+		zap.L().Error("查询工作表失败", zap.Int64("sheetID", sheetID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询工作表失败"}
+	}
+	if sheet == nil || sheet.ClassID != classID {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	return sheet, nil
+}
+
+func toGrantDTO(ctx context.Context, perm *model.Permission) *DTO.GrantDTO {
+	username, err := dao.GetUserNameByID(ctx, perm.UserID)
+	if err != nil {
+		zap.L().Warn("查询授权用户名失败", zap.Int64("userID", perm.UserID), zap.Error(err))
+	}
+	return &DTO.GrantDTO{
+		ID:          perm.ID,
+		UserID:      perm.UserID,
+		Username:    username,
+		ClassID:     perm.ClassID,
+		SheetID:     perm.SheetID,
+		AccessLevel: perm.AccessLevel,
+		GrantedBy:   perm.GrantedBy,
+		CreateTime:  perm.CreateTime.Format(time.RFC3339),
+		UpdateTime:  perm.UpdateTime.Format(time.RFC3339),
+	}
+}
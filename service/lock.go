@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+const (
+	LockResourceDragItem = "drag_item"
+	LockResourceCell     = "cell"
+
+	defaultLockTTL = 30 * time.Second
+)
+
+// NewLockToken 生成锁持有者 token；WebSocket 连接建立时生成一个，该连接获取的锁共用此 token
+func NewLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AcquireLock 获取课程或单元格的锁，锁已被他人持有时返回 Locked；需要对锁定资源所在的工作表/班级拥有编辑权限
+func AcquireLock(ctx context.Context, userID int64, dto *DTO.LockRequestDTO) (*DTO.LockDTO, *apiError.ApiError) {
+	key, apiErr := lockKey(dto)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireLockAccess(ctx, userID, dto); apiErr != nil {
+		return nil, apiErr
+	}
+	token := dto.Token
+	if token == "" {
+		var err error
+		if token, err = NewLockToken(); err != nil {
+			zap.L().Error("生成锁 token 失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "加锁失败"}
+		}
+	}
+
+	ttl := lockTTL(dto.TTL)
+	ok, err := cache.AcquireLock(ctx, key, lockOwner(userID, token), ttl)
+	if err != nil {
+		zap.L().Error("加锁失败", zap.String("key", key), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "加锁失败"}
+	}
+	if !ok {
+		return nil, lockedError(ctx, key)
+	}
+	return toLockDTO(dto, userID, token, ttl), nil
+}
+
+// RenewLock 延长锁的有效期，锁已过期或属于他人时返回 Locked；编辑权限被收回后不能再续期
+func RenewLock(ctx context.Context, userID int64, dto *DTO.LockRequestDTO) (*DTO.LockDTO, *apiError.ApiError) {
+	key, apiErr := lockKey(dto)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if dto.Token == "" {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "缺少锁 token"}
+	}
+	if apiErr := requireLockAccess(ctx, userID, dto); apiErr != nil {
+		return nil, apiErr
+	}
+
+	ttl := lockTTL(dto.TTL)
+	ok, err := cache.RenewLock(ctx, key, lockOwner(userID, dto.Token), ttl)
+	if err != nil {
+		zap.L().Error("续期锁失败", zap.String("key", key), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "续期锁失败"}
+	}
+	if !ok {
+		return nil, &apiError.ApiError{Code: code.Locked, Msg: "锁已过期或不属于当前用户"}
+	}
+	return toLockDTO(dto, userID, dto.Token, ttl), nil
+}
+
+// ReleaseLock 释放锁，锁已过期时视为成功
+func ReleaseLock(ctx context.Context, userID int64, dto *DTO.LockRequestDTO) *apiError.ApiError {
+	key, apiErr := lockKey(dto)
+	if apiErr != nil {
+		return apiErr
+	}
+	if dto.Token == "" {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "缺少锁 token"}
+	}
+	if _, err := cache.ReleaseLock(ctx, key, lockOwner(userID, dto.Token)); err != nil {
+		zap.L().Error("释放锁失败", zap.String("key", key), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "释放锁失败"}
+	}
+	return nil
+}
+
+// checkLocks 校验修改课表前涉及的锁：未被锁定或由当前用户以 token 持有时放行，否则返回 Locked
+func checkLocks(ctx context.Context, userID int64, token string, keys ...string) *apiError.ApiError {
+	for _, key := range keys {
+		owner, err := cache.GetLockOwner(ctx, key)
+		if err != nil {
+			zap.L().Error("查询锁失败", zap.String("key", key), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "系统繁忙，请稍后再试"}
+		}
+		if owner == "" || (token != "" && owner == lockOwner(userID, token)) {
+			continue
+		}
+		return lockedError(ctx, key)
+	}
+	return nil
+}
+
+// requireLockAccess 校验用户能否锁定该资源：单元格需要所在工作表的编辑权限，
+// 课程需要其所属任一班级的编辑权限，避免没有编辑权限的用户通过加锁阻塞排课员
+func requireLockAccess(ctx context.Context, userID int64, dto *DTO.LockRequestDTO) *apiError.ApiError {
+	switch dto.Resource {
+	case LockResourceCell:
+		sheet, err := dao.GetSheetByID(ctx, dto.SheetID)
+		if err != nil {
+			zap.L().Error("查询工作表失败", zap.Int64("sheetID", dto.SheetID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "查询工作表失败"}
+		}
+		if sheet == nil {
+			return &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+		}
+		return requireSheetAccess(ctx, userID, sheet, rbac.AccessEdit)
+	case LockResourceDragItem:
+		classIDs, err := dao.GetDraggableItemClassIDs(ctx, dto.DragItemID)
+		if err != nil {
+			zap.L().Error("查询课程所属班级失败", zap.Int64("dragItemID", dto.DragItemID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "查询课程失败"}
+		}
+		if len(classIDs) == 0 {
+			return &apiError.ApiError{Code: code.NotFound, Msg: "元素不存在"}
+		}
+		for _, classID := range classIDs {
+			apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessEdit)
+			if apiErr == nil || apiErr.Code != code.NoPermission {
+				return apiErr
+			}
+		}
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有该课程所属班级的编辑权限"}
+	default:
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的锁资源类型"}
+	}
+}
+
+func dragItemLockKey(itemID int64) string {
+	return cache.GenerateRedisKey(cache.DragItemLockKeyTemplate, itemID)
+}
+
+func cellLockKey(sheetID int64, row, col int) string {
+	return cache.GenerateRedisKey(cache.CellLockKeyTemplate, sheetID, row, col)
+}
+
+func lockKey(dto *DTO.LockRequestDTO) (string, *apiError.ApiError) {
+	switch dto.Resource {
+	case LockResourceDragItem:
+		if dto.DragItemID <= 0 {
+			return "", &apiError.ApiError{Code: code.InvalidParam, Msg: "缺少 drag_item_id"}
+		}
+		return dragItemLockKey(dto.DragItemID), nil
+	case LockResourceCell:
+		if dto.SheetID <= 0 || dto.Row <= 0 || dto.Col <= 0 {
+			return "", &apiError.ApiError{Code: code.InvalidParam, Msg: "缺少 sheet_id、row 或 col"}
+		}
+		return cellLockKey(dto.SheetID, dto.Row, dto.Col), nil
+	default:
+		return "", &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的锁资源类型"}
+	}
+}
+
+// lockTTL 计算锁的有效期，限制在 5~120 秒之间（WebSocket 消息不经过参数校验）
+func lockTTL(seconds int) time.Duration {
+	switch {
+	case seconds <= 0:
+		return defaultLockTTL
+	case seconds < 5:
+		seconds = 5
+	case seconds > 120:
+		seconds = 120
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// lockOwner 锁的值由用户ID和 token 组成，可从中解析出持有者
+func lockOwner(userID int64, token string) string {
+	return fmt.Sprintf("%d:%s", userID, token)
+}
+
+// lockedError 构造带持有者用户名的 Locked 错误
+func lockedError(ctx context.Context, key string) *apiError.ApiError {
+	msg := "该资源正被其他用户编辑，请稍后再试"
+	owner, err := cache.GetLockOwner(ctx, key)
+	if err != nil || owner == "" {
+		return &apiError.ApiError{Code: code.Locked, Msg: msg}
+	}
+	ownerID, err := strconv.ParseInt(strings.SplitN(owner, ":", 2)[0], 10, 64)
+	if err != nil {
+		return &apiError.ApiError{Code: code.Locked, Msg: msg}
+	}
+	if name, err := dao.GetUserNameByID(ctx, ownerID); err == nil && name != "" {
+		msg = fmt.Sprintf("该资源正被 %s 编辑，请稍后再试", name)
+	}
+	return &apiError.ApiError{Code: code.Locked, Msg: msg}
+}
+
+func toLockDTO(dto *DTO.LockRequestDTO, userID int64, token string, ttl time.Duration) *DTO.LockDTO {
+	lock := &DTO.LockDTO{
+		Resource:   dto.Resource,
+		Token:      token,
+		OwnerID:    userID,
+		ExpireTime: time.Now().Add(ttl).Format(time.RFC3339),
+	}
+	if dto.Resource == LockResourceDragItem {
+		lock.DragItemID = dto.DragItemID
+	} else {
+		lock.SheetID = dto.SheetID
+		lock.Row = dto.Row
+		lock.Col = dto.Col
+	}
+	return lock
+}
@@ -2,55 +2,141 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	dao "github.com/sztu/mutli-table/DAO"
 	"github.com/sztu/mutli-table/DTO"
 	"github.com/sztu/mutli-table/cache"
-	"github.com/sztu/mutli-table/pkg"
+	"github.com/sztu/mutli-table/model"
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
 	"github.com/sztu/mutli-table/pkg/jwt"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
 )
 
-// LoginService 登录服务
-func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO) (*DTO.LoginResponseDTO, *apiError.ApiError) {
-	user, err := dao.FindUserByUsername(ctx, dto.Username)
+// LoginService 登录服务，按配置的顺序尝试各认证方式
+// 用户不存在与密码错误返回相同的错误；同一账号或 IP 连续失败后需要等待递增的时间，超过阈值后临时锁定；
+// 已启用两步验证的用户只返回挑战 token，需要再调用 VerifyTwoFactorLogin 完成登录
+func LoginService(ctx context.Context, dto *DTO.LoginRequestDTO, clientIP, userAgent string) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	if apiErr := checkLoginThrottle(ctx, dto.Username, clientIP); apiErr != nil {
+		return nil, apiErr
+	}
+	user, err := authenticate(ctx, dto.Username, dto.Password)
+	if errors.Is(err, sso.ErrInvalidCredentials) {
+		recordLoginFailure(ctx, dto.Username, clientIP)
+		return nil, errInvalidCredentials
+	}
+	var apiErr *apiError.ApiError
+	if errors.As(err, &apiErr) {
+		return nil, apiErr
+	}
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
-			Msg:  "登录失败",
+			Msg:  "登录服务暂不可用，请稍后再试",
 		}
 	}
-	if user == nil {
+	clearLoginFailures(ctx, dto.Username)
+	if accountConfig().RequireVerifiedEmail && !user.EmailVerified {
 		return nil, &apiError.ApiError{
-			Code: code.UserNotExist,
-			Msg:  "用户不存在",
+			Code: code.NoPermission,
+			Msg:  "邮箱尚未验证，请先完成邮箱验证",
 		}
 	}
-	if pkg.EncryptPassword(dto.Password) != user.Password {
+
+	return completeLogin(ctx, user, clientIP, userAgent)
+}
+
+// issueLoginTokens 用户通过认证后创建新的 token 家族（即一个登录会话）并签发 access token 和 refresh token，
+// twoFactor 表示本次登录是否通过了两步验证
+func issueLoginTokens(ctx context.Context, user *model.User, clientIP, userAgent string, twoFactor bool) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	familyID, err := jwt.NewTokenID()
+	if err != nil {
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "生成token失败",
+		}
+	}
+	generation, err := cache.GetTokenGeneration(ctx, user.UserID)
+	if err != nil {
+		zap.L().Error("查询 token 代数失败", zap.Int64("userID", user.UserID), zap.Error(err))
 		return nil, &apiError.ApiError{
-			Code: code.PasswordError,
-			Msg:  "密码错误",
+			Code: code.ServerError,
+			Msg:  "生成token失败",
 		}
 	}
-	accessToken, err := jwt.GenerateToken(user.UserID, user.Username)
+	session := jwt.TokenSession{FamilyID: familyID, Generation: generation, TwoFactor: twoFactor}
+	pair, err := jwt.GenerateTokenPair(user.UserID, user.Username, session, accessTokenTTL(), refreshTokenTTL())
 	if err != nil {
 		return nil, &apiError.ApiError{
 			Code: code.ServerError,
 			Msg:  "生成token失败",
 		}
 	}
+	now := time.Now().Unix()
+	info := &cache.SessionInfo{
+		Device:    truncateDevice(userAgent),
+		IP:        clientIP,
+		CreatedAt: now,
+		LastSeen:  now,
+	}
+	if err := cache.SetRefreshFamily(ctx, user.UserID, familyID, pair.RefreshTokenID, info, refreshTokenTTL()); err != nil {
+		zap.L().Error("保存 refresh token 失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "生成token失败",
+		}
+	}
 
-	return &DTO.LoginResponseDTO{
-		AccessToken: accessToken,
-		UserID:      user.UserID,
-		Username:    user.Username,
-	}, nil
+	return toLoginResponse(pair, user.UserID, user.Username), nil
 }
 
+// RefreshService 使用 refresh token 换取新的 access token 和 refresh token。
+// 每个 refresh token 只能使用一次；已被轮换掉的 refresh token 再次出现说明可能已泄露，
+// 此时撤销整个 token 家族，持有者需要重新登录
+func RefreshService(ctx context.Context, refreshToken string) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	claims, err := jwt.ParseToken(refreshToken)
+	if err != nil || claims.TokenType != jwt.RefreshTokenName || claims.FamilyID == "" {
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "无效的 refresh token"}
+	}
+
+	session := jwt.TokenSession{FamilyID: claims.FamilyID, Generation: claims.Generation, TwoFactor: claims.TwoFactor}
+	pair, err := jwt.GenerateTokenPair(claims.UserID, claims.Username, session, accessTokenTTL(), refreshTokenTTL())
+	if err != nil {
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "生成token失败"}
+	}
+	result, err := cache.RotateRefreshFamily(ctx, claims.UserID, claims.FamilyID, claims.ID, pair.RefreshTokenID, claims.Generation, refreshTokenTTL())
+	if err != nil {
+		zap.L().Error("轮换 refresh token 失败", zap.Int64("userID", claims.UserID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "刷新token失败"}
+	}
+	switch result {
+	case 0:
+		zap.L().Warn("检测到 refresh token 被重复使用，撤销整个 token 家族",
+			zap.Int64("userID", claims.UserID),
+			zap.String("familyID", claims.FamilyID))
+		if err := cache.RevokeRefreshFamily(ctx, claims.UserID, claims.FamilyID); err != nil {
+			zap.L().Error("撤销 token 家族失败", zap.String("familyID", claims.FamilyID), zap.Error(err))
+		}
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "refresh token 已被使用，请重新登录"}
+	case -1:
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "登录状态已失效，请重新登录"}
+	}
+
+	return toLoginResponse(pair, claims.UserID, claims.Username), nil
+}
+
+// LogoutService 将传入的 token 加入黑名单，并撤销其所属的 token 家族，
+// 同一次登录签发的 access token 和 refresh token 都会失效
 func LogoutService(ctx context.Context, token ...string) *apiError.ApiError {
 	for _, t := range token {
+		if t == "" {
+			continue
+		}
 		myClaims, err := jwt.ParseToken(t)
 		if err != nil {
 			return &apiError.ApiError{
@@ -66,7 +152,51 @@ func LogoutService(ctx context.Context, token ...string) *apiError.ApiError {
 				Msg:  "登出失败",
 			}
 		}
+		if myClaims.FamilyID != "" {
+			if err := cache.RevokeRefreshFamily(ctx, myClaims.UserID, myClaims.FamilyID); err != nil {
+				return &apiError.ApiError{
+					Code: code.ServerError,
+					Msg:  "登出失败",
+				}
+			}
+		}
 	}
 
 	return nil
 }
+
+func toLoginResponse(pair *jwt.TokenPair, userID int64, username string) *DTO.LoginResponseDTO {
+	return &DTO.LoginResponseDTO{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    int64(accessTokenTTL().Seconds()),
+		UserID:       userID,
+		Username:     username,
+	}
+}
+
+func accessTokenTTL() time.Duration {
+	if conf := settings.GetConfig().JWTConfig; conf != nil && conf.AccessTokenTTL > 0 {
+		return time.Duration(conf.AccessTokenTTL) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+func refreshTokenTTL() time.Duration {
+	if conf := settings.GetConfig().JWTConfig; conf != nil && conf.RefreshTokenTTL > 0 {
+		return time.Duration(conf.RefreshTokenTTL) * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// rehashPassword 使用当前配置的算法重新计算密码哈希，失败只记录日志，不影响本次登录
+func rehashPassword(ctx context.Context, userID int64, plain string) {
+	hashed, err := password.Hash(plain)
+	if err != nil {
+		zap.L().Error("重新计算密码哈希失败", zap.Int64("userID", userID), zap.Error(err))
+		return
+	}
+	if err := dao.UpdateUserPassword(ctx, userID, hashed); err != nil {
+		zap.L().Error("更新密码哈希失败", zap.Int64("userID", userID), zap.Error(err))
+	}
+}
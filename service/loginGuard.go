@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// errInvalidCredentials 用户不存在和密码错误统一返回该错误，避免泄露账号是否存在
+var errInvalidCredentials = &apiError.ApiError{
+	Code: code.InvalidAuth,
+	Msg:  "用户名或密码错误",
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// verifyDummyPassword 用户不存在时也计算一次哈希，使响应耗时与密码错误时一致
+func verifyDummyPassword(plain string) {
+	dummyHashOnce.Do(func() {
+		h, err := password.Hash("mutli-table-dummy-password")
+		if err != nil {
+			zap.L().Error("生成占位密码哈希失败", zap.Error(err))
+			return
+		}
+		dummyHash = h
+	})
+	if dummyHash != "" {
+		_, _, _ = password.Verify(plain, dummyHash)
+	}
+}
+
+// loginAccountKey 登录限制按不区分大小写的用户名计数，与 user 表的排序规则一致
+func loginAccountKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// loginGuardPolicy 读取配置中的登录限制策略
+func loginGuardPolicy() cache.LoginGuardPolicy {
+	policy := cache.LoginGuardPolicy{
+		Window:             15 * time.Minute,
+		FreeAttempts:       3,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+		MaxAccountAttempts: 10,
+		MaxIPAttempts:      50,
+		LockoutDuration:    15 * time.Minute,
+	}
+	conf := settings.GetConfig().LoginGuardConfig
+	if conf == nil {
+		return policy
+	}
+	if conf.Window > 0 {
+		policy.Window = time.Duration(conf.Window) * time.Minute
+	}
+	if conf.FreeAttempts >= 0 {
+		policy.FreeAttempts = conf.FreeAttempts
+	}
+	if conf.BaseDelay > 0 {
+		policy.BaseDelay = time.Duration(conf.BaseDelay) * time.Second
+	}
+	if conf.MaxDelay > 0 {
+		policy.MaxDelay = time.Duration(conf.MaxDelay) * time.Second
+	}
+	if conf.MaxAccountAttempts > 0 {
+		policy.MaxAccountAttempts = conf.MaxAccountAttempts
+	}
+	if conf.MaxIPAttempts > 0 {
+		policy.MaxIPAttempts = conf.MaxIPAttempts
+	}
+	if conf.LockoutDuration > 0 {
+		policy.LockoutDuration = time.Duration(conf.LockoutDuration) * time.Minute
+	}
+	return policy
+}
+
+// checkLoginThrottle 账号或 IP 处于等待期或锁定状态时拒绝本次登录。
+// Redis 不可用时放行，只记录日志，避免缓存故障导致所有用户无法登录
+func checkLoginThrottle(ctx context.Context, username, clientIP string) *apiError.ApiError {
+	wait, err := cache.LoginRetryAfter(ctx, loginAccountKey(username), clientIP)
+	if err != nil {
+		zap.L().Error("查询登录限制状态失败", zap.String("username", username), zap.String("ip", clientIP), zap.Error(err))
+		return nil
+	}
+	if wait <= 0 {
+		return nil
+	}
+	return &apiError.ApiError{
+		Code: code.TooManyRequests,
+		Msg:  "登录尝试次数过多，请稍后再试",
+		Data: &DTO.RetryAfterDTO{RetryAfter: int(math.Ceil(wait.Seconds()))},
+	}
+}
+
+// recordLoginFailure 记录登录失败，无论账号是否存在都计数
+func recordLoginFailure(ctx context.Context, username, clientIP string) {
+	fails, err := cache.RecordLoginFailure(ctx, loginAccountKey(username), clientIP, loginGuardPolicy())
+	if err != nil {
+		zap.L().Error("记录登录失败次数失败", zap.String("username", username), zap.String("ip", clientIP), zap.Error(err))
+		return
+	}
+	zap.L().Info("登录失败", zap.String("username", username), zap.String("ip", clientIP), zap.Int("failures", fails))
+}
+
+// clearLoginFailures 登录成功后清除账号的失败记录
+func clearLoginFailures(ctx context.Context, username string) {
+	if err := cache.ClearLoginFailures(ctx, loginAccountKey(username)); err != nil {
+		zap.L().Error("清除登录失败次数失败", zap.String("username", username), zap.Error(err))
+	}
+}
+
+// UnlockUserLogin 管理员解除账号的登录锁定并清空失败次数
+func UnlockUserLogin(ctx context.Context, userID int64) (*DTO.UnlockUserResponseDTO, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "解锁失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在"}
+	}
+	account := loginAccountKey(user.Username)
+	locked, _, err := cache.IsLoginLocked(ctx, account)
+	if err != nil {
+		zap.L().Error("查询登录锁定状态失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "解锁失败"}
+	}
+	if err := cache.ClearLoginFailures(ctx, account); err != nil {
+		zap.L().Error("解除登录锁定失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "解锁失败"}
+	}
+	return &DTO.UnlockUserResponseDTO{
+		UserID:    user.UserID,
+		Username:  user.Username,
+		WasLocked: locked,
+	}, nil
+}
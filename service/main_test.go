@@ -0,0 +1,29 @@
+package service
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/sztu/mutli-table/settings"
+)
+
+// TestMain 切换到项目根目录，使 settings 能读取 ./conf/config.yaml，并让 Redis 连接指向进程内的 miniredis
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	srv, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.Atoi(srv.Port())
+	if err != nil {
+		panic(err)
+	}
+	settings.GetConfig().RedisConfig = &settings.RedisConfig{Host: srv.Host(), Port: port}
+	code := m.Run()
+	srv.Close()
+	os.Exit(code)
+}
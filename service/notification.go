@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"go.uber.org/zap"
+)
+
+const (
+	// NotificationItemPlaced 课程被安排到课表中
+	NotificationItemPlaced = "item_placed"
+	// NotificationItemRemoved 课程被移出课表
+	NotificationItemRemoved = "item_removed"
+)
+
+var weekTypeNames = map[string]string{
+	"single": "单周",
+	"double": "双周",
+	"all":    "每周",
+}
+
+// notifyTeacher 课表变更后通知课程的任课老师（站内通知 + 邮件），操作者本人不会收到通知。
+// 通知失败只记录日志，不影响课表修改的结果
+func notifyTeacher(ctx context.Context, operatorID int64, notifyType string, sheet *model.Sheet, item *model.DraggableItem, row, col int) {
+	teacher, err := dao.FindUserByUsername(ctx, item.Teacher)
+	if err != nil {
+		zap.L().Error("通知任课老师时查询用户失败", zap.String("teacher", item.Teacher), zap.Error(err))
+		return
+	}
+	// 任课老师未注册账号或本人操作时无需通知
+	if teacher == nil || teacher.UserID == operatorID {
+		return
+	}
+
+	operatorName, _ := dao.GetUserNameByID(ctx, operatorID)
+	className, _ := dao.GetClassNameBySheetID(ctx, sheet.ID)
+	position := fmt.Sprintf("%s 第%d周课表第%d行第%d列", className, sheet.Week, row, col)
+
+	var title, content string
+	switch notifyType {
+	case NotificationItemPlaced:
+		title = fmt.Sprintf("课程「%s」已排课", item.Content)
+		content = fmt.Sprintf("%s 将您任教的课程「%s」（%s，%s）安排到了%s。",
+			operatorName, item.Content, weekTypeNames[item.WeekType], item.Classroom, position)
+	case NotificationItemRemoved:
+		title = fmt.Sprintf("课程「%s」已移出课表", item.Content)
+		content = fmt.Sprintf("%s 将您任教的课程「%s」从%s移除。", operatorName, item.Content, position)
+	default:
+		return
+	}
+
+	if err := dao.CreateNotification(ctx, &model.Notification{
+		UserID:     teacher.UserID,
+		Type:       notifyType,
+		Title:      title,
+		Content:    content,
+		ClassID:    sheet.ClassID,
+		SheetID:    sheet.ID,
+		ItemID:     item.ID,
+		CreateTime: time.Now(),
+		UpdateTime: time.Now(),
+	}); err != nil {
+		zap.L().Error("创建站内通知失败", zap.Int64("userID", teacher.UserID), zap.Error(err))
+	}
+
+	if teacher.Email == "" {
+		return
+	}
+	if err := enqueueEmail(ctx, teacher.Email, "[课表变更] "+title, content); err != nil {
+		zap.L().Error("写入通知邮件失败", zap.Int64("userID", teacher.UserID), zap.Error(err))
+	}
+}
+
+// ListNotifications 分页查询当前用户的通知
+func ListNotifications(ctx context.Context, userID int64, unreadOnly bool, page, pageSize int) (*DTO.NotificationListDTO, *apiError.ApiError) {
+	list, total, err := dao.ListNotifications(ctx, userID, unreadOnly, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询通知列表失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询通知失败"}
+	}
+	unread, err := dao.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		zap.L().Error("统计未读通知失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询通知失败"}
+	}
+
+	result := make([]DTO.NotificationDTO, 0, len(list))
+	for _, n := range list {
+		result = append(result, DTO.NotificationDTO{
+			ID:         n.ID,
+			Type:       n.Type,
+			Title:      n.Title,
+			Content:    n.Content,
+			ClassID:    n.ClassID,
+			SheetID:    n.SheetID,
+			ItemID:     n.ItemID,
+			IsRead:     n.IsRead,
+			CreateTime: n.CreateTime.Format(time.RFC3339),
+		})
+	}
+	return &DTO.NotificationListDTO{Total: total, Unread: unread, List: result}, nil
+}
+
+// MarkNotificationRead 将通知标记为已读
+func MarkNotificationRead(ctx context.Context, userID, notificationID int64) *apiError.ApiError {
+	found, err := dao.MarkNotificationRead(ctx, userID, notificationID)
+	if err != nil {
+		zap.L().Error("标记通知已读失败", zap.Int64("notificationID", notificationID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "标记已读失败"}
+	}
+	if !found {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "通知不存在"}
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead 将当前用户的全部通知标记为已读
+func MarkAllNotificationsRead(ctx context.Context, userID int64) *apiError.ApiError {
+	if err := dao.MarkAllNotificationsRead(ctx, userID); err != nil {
+		zap.L().Error("全部标记已读失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "标记已读失败"}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// oidcStateTTL 从跳转到身份提供方到回调完成的最长时间
+const oidcStateTTL = 10 * time.Minute
+
+// StartOIDCLogin 生成跳转到身份提供方的授权地址。
+// state 会出现在授权地址中，不能用来识别发起登录的浏览器；PKCE code_verifier 只返回给发起登录的前端，
+// 回调时必须一并提交，因此拿到他人的 code 和 state 也无法完成登录（防止登录 CSRF）
+func StartOIDCLogin(ctx context.Context) (*DTO.OIDCAuthorizeDTO, *apiError.ApiError) {
+	req, err := sso.NewAuthRequest(ctx)
+	if errors.Is(err, sso.ErrOIDCDisabled) {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "未启用单点登录"}
+	}
+	if err != nil {
+		zap.L().Error("生成 OIDC 授权地址失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "单点登录暂不可用"}
+	}
+	if err := cache.SaveOIDCState(ctx, req.State, &cache.OIDCState{Nonce: req.Nonce, VerifierHash: hashCodeVerifier(req.CodeVerifier)}, oidcStateTTL); err != nil {
+		zap.L().Error("保存 OIDC state 失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "单点登录暂不可用"}
+	}
+	return &DTO.OIDCAuthorizeDTO{AuthorizationURL: req.URL, State: req.State, CodeVerifier: req.CodeVerifier}, nil
+}
+
+func hashCodeVerifier(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return hex.EncodeToString(sum[:])
+}
+
+// FinishOIDCLogin 处理身份提供方回调：校验 state 和发起登录时返回的 code_verifier，用授权码换取身份信息，找到或创建本地用户后签发本系统的 token
+func FinishOIDCLogin(ctx context.Context, dto *DTO.OIDCCallbackRequestDTO, clientIP, userAgent string) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	if !sso.Enabled() {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "未启用单点登录"}
+	}
+	state, err := cache.ConsumeOIDCState(ctx, dto.State)
+	if err != nil {
+		zap.L().Error("读取 OIDC state 失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "单点登录失败"}
+	}
+	if state == nil {
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "登录请求已过期，请重新登录"}
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCodeVerifier(dto.CodeVerifier)), []byte(state.VerifierHash)) != 1 {
+		zap.L().Warn("OIDC 回调的 code_verifier 与发起登录时不一致")
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "登录请求与当前浏览器不匹配，请重新登录"}
+	}
+	identity, err := sso.Exchange(ctx, dto.Code, dto.CodeVerifier, state.Nonce)
+	if err != nil {
+		zap.L().Warn("OIDC 授权码校验失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "单点登录失败"}
+	}
+
+	conf := settings.GetConfig().OIDCConfig
+	user, apiErr := resolveExternalUser(ctx, identity, conf.LinkByEmail, conf.AutoCreate)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return completeLogin(ctx, user, clientIP, userAgent)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/settings"
+)
+
+func TestFinishOIDCLoginRequiresInitiatingVerifier(t *testing.T) {
+	conf := settings.GetConfig()
+	old := conf.OIDCConfig
+	conf.OIDCConfig = &settings.OIDCConfig{Enabled: true, Issuer: "https://idp.example.com", ClientID: "mutli-table"}
+	t.Cleanup(func() { conf.OIDCConfig = old })
+
+	ctx := context.Background()
+	const verifier = "verifier-of-initiating-browser"
+	save := func(state string) {
+		err := cache.SaveOIDCState(ctx, state, &cache.OIDCState{Nonce: "nonce", VerifierHash: hashCodeVerifier(verifier)}, time.Minute)
+		if err != nil {
+			t.Fatalf("SaveOIDCState() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		state    string
+		saved    bool
+		verifier string
+	}{
+		// 攻击者把自己的 code 和 state 交给受害者的浏览器提交，受害者的前端没有对应的 code_verifier
+		{name: "code_verifier 与发起登录时不一致", state: "state-1", saved: true, verifier: "attacker-or-other-browser"},
+		{name: "state 不存在", state: "state-2", verifier: verifier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.saved {
+				save(tt.state)
+			}
+			dto := &DTO.OIDCCallbackRequestDTO{Code: "code", State: tt.state, CodeVerifier: tt.verifier}
+			_, apiErr := FinishOIDCLogin(ctx, dto, "127.0.0.1", "test")
+			if apiErr == nil || apiErr.Code != code.InvalidAuth {
+				t.Fatalf("FinishOIDCLogin() error = %v，期望 InvalidAuth", apiErr)
+			}
+			// 校验失败后 state 也已失效，不能再次尝试
+			if state, err := cache.ConsumeOIDCState(ctx, tt.state); err != nil || state != nil {
+				t.Errorf("ConsumeOIDCState() = %v, %v，期望 state 已被使用", state, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/pkg/sso"
+	"go.uber.org/zap"
+)
+
+// getCurrentUser 查询当前登录用户，用户已注销时返回 InvalidAuth
+func getCurrentUser(ctx context.Context, userID int64) (*model.User, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询用户失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: "用户不存在"}
+	}
+	return user, nil
+}
+
+// verifyCurrentPassword 敏感操作前按登录时的认证方式重新校验当前密码，失败次数与登录共用同一套限制
+func verifyCurrentPassword(ctx context.Context, user *model.User, plain, clientIP string) *apiError.ApiError {
+	if apiErr := checkLoginThrottle(ctx, user.Username, clientIP); apiErr != nil {
+		return apiErr
+	}
+	authed, err := authenticate(ctx, user.Username, plain)
+	if err == nil && authed.UserID == user.UserID {
+		return nil
+	}
+	if err != nil && !errors.Is(err, sso.ErrInvalidCredentials) {
+		zap.L().Error("校验密码失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "校验密码失败"}
+	}
+	recordLoginFailure(ctx, user.Username, clientIP)
+	return &apiError.ApiError{Code: code.PasswordError, Msg: "密码错误"}
+}
+
+func toProfileDTO(user *model.User) *DTO.ProfileDTO {
+	return &DTO.ProfileDTO{
+		UserID:        user.UserID,
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          effectiveRole(user),
+		CreateTime:    user.CreateTime,
+	}
+}
+
+// GetProfile 查询当前用户的个人资料
+func GetProfile(ctx context.Context, userID int64) (*DTO.ProfileDTO, *apiError.ApiError) {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return toProfileDTO(user), nil
+}
+
+// UpdateProfile 修改显示名称和邮箱，邮箱变更后需要重新验证
+func UpdateProfile(ctx context.Context, userID int64, dto *DTO.UpdateProfileRequestDTO) (*DTO.ProfileDTO, *apiError.ApiError) {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if dto.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*dto.DisplayName)
+	}
+	emailChanged := false
+	if dto.Email != nil {
+		email := strings.TrimSpace(*dto.Email)
+		if !strings.EqualFold(email, user.Email) {
+			emailChanged = true
+			user.EmailVerified = false
+		}
+		user.Email = email
+	}
+
+	if err := dao.UpdateUserProfile(ctx, user); err != nil {
+		if contains(err.Error(), "Duplicate entry") {
+			return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "邮箱已被使用"}
+		}
+		zap.L().Error("更新个人资料失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新个人资料失败"}
+	}
+	if emailChanged {
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			zap.L().Error("发送验证邮件失败", zap.Int64("userID", userID), zap.Error(err))
+		}
+	}
+	return toProfileDTO(user), nil
+}
+
+// ChangePassword 校验旧密码后设置新密码，并撤销当前会话以外的全部登录会话
+func ChangePassword(ctx context.Context, userID int64, familyID, clientIP string, dto *DTO.ChangePasswordRequestDTO) *apiError.ApiError {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := verifyCurrentPassword(ctx, user, dto.OldPassword, clientIP); apiErr != nil {
+		if apiErr.Code == code.PasswordError {
+			apiErr.Msg = "原密码错误"
+		}
+		return apiErr
+	}
+	if dto.OldPassword == dto.NewPassword {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "新密码不能与原密码相同"}
+	}
+
+	hashed, err := password.Hash(dto.NewPassword)
+	if err != nil {
+		zap.L().Error("计算密码哈希失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "修改密码失败"}
+	}
+	if err := dao.UpdateUserPassword(ctx, userID, hashed); err != nil {
+		zap.L().Error("更新密码失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "修改密码失败"}
+	}
+	clearLoginFailures(ctx, user.Username)
+	if err := revokeUserSessions(ctx, userID, familyID); err != nil {
+		zap.L().Error("撤销其他登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "密码已修改，但撤销其他登录会话失败，请稍后重试"}
+	}
+	return nil
+}
+
+// DeleteAccount 校验密码后注销当前用户，并撤销全部登录会话和访问令牌。
+// 唯一的管理员不能注销，避免系统中没有人可以管理角色
+func DeleteAccount(ctx context.Context, userID int64, clientIP string, dto *DTO.DeleteAccountRequestDTO) *apiError.ApiError {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := verifyCurrentPassword(ctx, user, dto.Password, clientIP); apiErr != nil {
+		return apiErr
+	}
+	if user.Role == rbac.RoleAdmin {
+		count, err := dao.CountUsersByRole(ctx, rbac.RoleAdmin)
+		if err != nil {
+			zap.L().Error("统计管理员数量失败", zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "注销账号失败"}
+		}
+		if count <= 1 {
+			return &apiError.ApiError{Code: code.InvalidParam, Msg: "唯一的管理员不能注销账号"}
+		}
+	}
+
+	if _, err := dao.SoftDeleteUser(ctx, userID); err != nil {
+		zap.L().Error("注销账号失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "注销账号失败"}
+	}
+	if err := revokeUserSessions(ctx, userID, ""); err != nil {
+		zap.L().Error("撤销登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+	}
+	if err := dao.RevokeAPITokensByUser(ctx, userID); err != nil {
+		zap.L().Error("撤销访问令牌失败", zap.Int64("userID", userID), zap.Error(err))
+	}
+	return nil
+}
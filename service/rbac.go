@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// effectiveRole 返回用户实际生效的角色，配置文件中列出的用户名始终视为管理员
+func effectiveRole(user *model.User) string {
+	if conf := settings.GetConfig().RBACConfig; conf != nil && slices.Contains(conf.Admins, user.Username) {
+		return rbac.RoleAdmin
+	}
+	return user.Role
+}
+
+// defaultUserRole 自行注册或首次通过外部身份登录的用户的角色。
+// 默认为学生：没有任何班级的访问权限，由管理员加入班级或提升角色后才能查看课表
+func defaultUserRole() string {
+	if conf := settings.GetConfig().RBACConfig; conf != nil && rbac.ValidRole(conf.DefaultRole) {
+		return conf.DefaultRole
+	}
+	return rbac.RoleStudent
+}
+
+// GetUserRole 查询用户当前生效的角色
+func GetUserRole(ctx context.Context, userID int64) (string, *apiError.ApiError) {
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户角色失败", zap.Int64("userID", userID), zap.Error(err))
+		return "", &apiError.ApiError{Code: code.ServerError, Msg: "查询用户角色失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return "", &apiError.ApiError{Code: code.InvalidAuth, Msg: "用户不存在"}
+	}
+	return effectiveRole(user), nil
+}
+
+// CheckPermission 校验用户是否拥有指定权限，返回用户角色
+func CheckPermission(ctx context.Context, userID int64, perm rbac.Permission) (string, *apiError.ApiError) {
+	role, apiErr := GetUserRole(ctx, userID)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if !rbac.HasPermission(role, perm) {
+		return role, &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限执行该操作"}
+	}
+	return role, nil
+}
+
+// AssignUserRole 管理员为用户分配角色
+// 不允许修改自己的角色，避免最后一个管理员误操作后无人可以管理角色
+func AssignUserRole(ctx context.Context, operatorID, userID int64, dto *DTO.AssignRoleRequestDTO) (*DTO.UserRoleDTO, *apiError.ApiError) {
+	if !rbac.ValidRole(dto.Role) {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "无效的角色"}
+	}
+	if operatorID == userID {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "不能修改自己的角色"}
+	}
+	user, err := dao.FindUserByID(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询用户失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "分配角色失败"}
+	}
+	if user == nil || user.UserID == 0 {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "用户不存在"}
+	}
+	if err := dao.UpdateUserRole(ctx, userID, dto.Role); err != nil {
+		zap.L().Error("修改用户角色失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "分配角色失败"}
+	}
+	zap.L().Info("修改用户角色",
+		zap.Int64("operatorID", operatorID),
+		zap.Int64("userID", userID),
+		zap.String("from", user.Role),
+		zap.String("to", dto.Role))
+
+	user.Role = dto.Role
+	return &DTO.UserRoleDTO{
+		UserID:   user.UserID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     effectiveRole(user),
+	}, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/settings"
+)
+
+func TestDefaultUserRole(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *settings.RBACConfig
+		want string
+	}{
+		{name: "未配置时为学生", conf: nil, want: rbac.RoleStudent},
+		{name: "未配置默认角色时为学生", conf: &settings.RBACConfig{}, want: rbac.RoleStudent},
+		{name: "无效角色时为学生", conf: &settings.RBACConfig{DefaultRole: "superuser"}, want: rbac.RoleStudent},
+		{name: "使用配置的角色", conf: &settings.RBACConfig{DefaultRole: rbac.RoleViewer}, want: rbac.RoleViewer},
+	}
+	conf := settings.GetConfig()
+	old := conf.RBACConfig
+	t.Cleanup(func() { conf.RBACConfig = old })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.RBACConfig = tt.conf
+			if got := defaultUserRole(); got != tt.want {
+				t.Errorf("defaultUserRole() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRoleHasNoClassAccess(t *testing.T) {
+	if role := settings.GetConfig().RBACConfig.DefaultRole; rbac.RoleAccessLevel(role) != rbac.AccessNone {
+		t.Errorf("默认角色 %q 不应拥有任何班级的访问权限", role)
+	}
+}
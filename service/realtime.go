@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/broadcast"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// AuthorizeSheetSubscription 校验用户能否订阅工作表的实时变更（WebSocket / SSE），需要工作表的查看权限
+func AuthorizeSheetSubscription(ctx context.Context, userID, classID, sheetID int64) *apiError.ApiError {
+	sheet, apiErr := getSheetInClass(ctx, classID, sheetID)
+	if apiErr != nil {
+		return apiErr
+	}
+	return requireSheetAccess(ctx, userID, sheet, rbac.AccessRead)
+}
+
+// AuthorizeClassSubscription 校验用户能否订阅班级下所有工作表的实时变更，需要班级的查看权限
+func AuthorizeClassSubscription(ctx context.Context, userID, classID int64) *apiError.ApiError {
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return apiErr
+	}
+	return requireClassAccess(ctx, userID, classID, rbac.AccessRead)
+}
+
+// publishSheetMessage 将消息推送给正在查看该工作表（WebSocket / SSE）以及订阅了所属班级（SSE）的客户端
+func publishSheetMessage(ctx context.Context, classID, sheetID int64, msgType string, v interface{}) {
+	msg, err := broadcast.NewMessage(msgType, v)
+	if err != nil {
+		zap.L().Error("构造实时消息失败", zap.Int64("sheetID", sheetID), zap.Error(err))
+		return
+	}
+	for _, topic := range []string{broadcast.SheetTopic(sheetID), broadcast.ClassTopic(classID)} {
+		if err := broadcast.GetBus().Publish(ctx, topic, msg); err != nil {
+			zap.L().Error("发布实时消息失败", zap.String("topic", topic), zap.Error(err))
+		}
+	}
+}
+
+// publishItemPlaced 课程被放入各工作表（含按单双周同步的其他周）的同一位置后推送 DRAG_ITEM_MOVED
+func publishItemPlaced(ctx context.Context, operatorID, classID int64, sheetIDs []int64, itemID int64, row, col int) {
+	for _, sheetID := range sheetIDs {
+		publishSheetMessage(ctx, classID, sheetID, DTO.MessageDragItemMoved, &DTO.DragItemMovedMessage{
+			Type:       DTO.MessageDragItemMoved,
+			SheetID:    sheetID,
+			DragItemID: itemID,
+			TargetRow:  row,
+			TargetCol:  col,
+			MovedBy:    operatorID,
+			IsPlaced:   true,
+		})
+	}
+}
+
+// publishCellCleared 课程从各工作表的同一位置移除后推送 CELL_UPDATED
+func publishCellCleared(ctx context.Context, operatorID, classID int64, sheetIDs []int64, row, col int) {
+	for _, sheetID := range sheetIDs {
+		publishSheetMessage(ctx, classID, sheetID, DTO.MessageCellUpdated, &DTO.CellUpdatedMessage{
+			Type:      DTO.MessageCellUpdated,
+			SheetID:   sheetID,
+			Row:       row,
+			Column:    col,
+			ItemID:    nil,
+			UpdatedBy: operatorID,
+		})
+	}
+}
+
+// publishItemUpdated 课程信息修改后推送给所有包含该课程的工作表
+func publishItemUpdated(ctx context.Context, operatorID int64, item *DTO.DragItemResponseDTO) {
+	sheetIDs, err := dao.ListSheetIDsByItemID(ctx, item.ID)
+	if err != nil {
+		zap.L().Error("查询课程所在工作表失败", zap.Int64("itemID", item.ID), zap.Error(err))
+		return
+	}
+	for _, sheetID := range sheetIDs {
+		sheet, err := dao.GetSheetByID(ctx, sheetID)
+		if err != nil || sheet == nil {
+			continue
+		}
+		publishSheetMessage(ctx, sheet.ClassID, sheetID, DTO.MessageDragItemUpdated, &DTO.DragItemUpdatedMessage{
+			Type:      DTO.MessageDragItemUpdated,
+			SheetID:   sheetID,
+			Item:      *item,
+			UpdatedBy: operatorID,
+		})
+	}
+}
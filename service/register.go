@@ -8,17 +8,26 @@ import (
 	dao "github.com/sztu/mutli-table/DAO"
 	"github.com/sztu/mutli-table/DTO"
 	"github.com/sztu/mutli-table/model"
-	"github.com/sztu/mutli-table/pkg"
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/password"
 	"github.com/sztu/mutli-table/pkg/snowflake"
+	"go.uber.org/zap"
 )
 
 func RegisterSerivce(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.ApiError {
-	dto.Password = pkg.EncryptPassword(dto.Password)
+	hashed, err := password.Hash(dto.Password)
+	if err != nil {
+		zap.L().Error("计算密码哈希失败", zap.Error(err))
+		return &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "注册失败",
+		}
+	}
+	dto.Password = hashed
 	var user model.User
 
-	err := copier.Copy(&user, dto)
+	err = copier.Copy(&user, dto)
 	if err != nil {
 		return &apiError.ApiError{
 			Code: code.ServerError,
@@ -26,6 +35,8 @@ func RegisterSerivce(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.A
 		}
 	}
 
+	user.Role = defaultUserRole()
+
 	user.UserID, err = snowflake.GetID()
 	if err != nil {
 		return &apiError.ApiError{
@@ -52,6 +63,10 @@ func RegisterSerivce(ctx context.Context, dto *DTO.SignUpRequestDTO) *apiError.A
 			Msg:  "注册失败",
 		}
 	}
+	// 验证邮件发送失败不影响注册，用户可以登录后重新发送
+	if err := sendVerificationEmail(ctx, &user); err != nil {
+		zap.L().Error("发送验证邮件失败", zap.Int64("userID", user.UserID), zap.Error(err))
+	}
 	return nil
 }
 
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"go.uber.org/zap"
+)
+
+const (
+	maxDeviceLength      = 255
+	sessionTouchInterval = time.Minute // 会话最后活跃时间的更新间隔，避免每个请求都写 Redis
+)
+
+var (
+	// errSessionRevoked 会话已被撤销或 token 代数已失效
+	errSessionRevoked = &apiError.ApiError{Code: code.InvalidAuth, Msg: "登录状态已失效，请重新登录"}
+	// errUserDisabled 用户已被管理员停用
+	errUserDisabled = &apiError.ApiError{Code: code.NoPermission, Msg: "账号已被停用，请联系管理员"}
+)
+
+// CheckSession 校验用户未被停用，且 access token 所属的会话和 token 代数仍然有效，并记录会话的活跃时间和 IP。
+// 没有 token 家族的旧 token 只校验停用状态和代数
+func CheckSession(ctx context.Context, userID int64, familyID string, generation int64, clientIP string) *apiError.ApiError {
+	if familyID == "" {
+		disabled, err := cache.IsUserDisabled(ctx, userID)
+		if err != nil {
+			zap.L().Error("查询用户停用状态失败", zap.Int64("userID", userID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "校验登录状态失败"}
+		}
+		if disabled {
+			return errUserDisabled
+		}
+		current, err := cache.GetTokenGeneration(ctx, userID)
+		if err != nil {
+			zap.L().Error("查询 token 代数失败", zap.Int64("userID", userID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "校验登录状态失败"}
+		}
+		if generation < current {
+			return errSessionRevoked
+		}
+		return nil
+	}
+	result, err := cache.TouchSession(ctx, userID, familyID, generation, clientIP, sessionTouchInterval)
+	if err != nil {
+		zap.L().Error("校验登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "校验登录状态失败"}
+	}
+	switch result {
+	case 1:
+		return nil
+	case -2:
+		return errUserDisabled
+	default:
+		return errSessionRevoked
+	}
+}
+
+// ListSessions 查询当前用户的全部登录会话，按最后活跃时间倒序
+func ListSessions(ctx context.Context, userID int64, currentFamilyID string) ([]*DTO.SessionDTO, *apiError.ApiError) {
+	sessions, err := cache.ListUserSessions(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询登录会话失败"}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen > sessions[j].LastSeen
+	})
+	result := make([]*DTO.SessionDTO, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, &DTO.SessionDTO{
+			SessionID:  s.FamilyID,
+			Device:     s.Device,
+			IP:         s.IP,
+			CreateTime: formatUnix(s.CreatedAt),
+			LastSeen:   formatUnix(s.LastSeen),
+			Current:    s.FamilyID == currentFamilyID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 撤销当前用户的单个登录会话，该会话签发的 access token 和 refresh token 立即失效
+func RevokeSession(ctx context.Context, userID int64, sessionID string) *apiError.ApiError {
+	ok, err := cache.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		zap.L().Error("撤销登录会话失败", zap.Int64("userID", userID), zap.String("sessionID", sessionID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销登录会话失败"}
+	}
+	if !ok {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "登录会话不存在"}
+	}
+	return nil
+}
+
+// RevokeAllSessions 退出全部设备：撤销当前用户的全部登录会话（包括当前会话），
+// 并递增 token 代数使此前签发的所有 token 失效
+func RevokeAllSessions(ctx context.Context, userID int64) *apiError.ApiError {
+	if err := revokeUserSessions(ctx, userID, ""); err != nil {
+		zap.L().Error("撤销全部登录会话失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "退出全部设备失败"}
+	}
+	return nil
+}
+
+// revokeUserSessions 撤销用户除 keepFamilyID 以外的全部登录会话；
+// keepFamilyID 为空时同时递增 token 代数，连同没有 token 家族的旧 token 一起失效
+func revokeUserSessions(ctx context.Context, userID int64, keepFamilyID string) error {
+	if keepFamilyID == "" {
+		if _, err := cache.IncrTokenGeneration(ctx, userID); err != nil {
+			return err
+		}
+	}
+	n, err := cache.RevokeUserRefreshFamilies(ctx, userID, keepFamilyID)
+	if err != nil {
+		return err
+	}
+	zap.L().Info("已撤销用户登录会话", zap.Int64("userID", userID), zap.Int("count", n))
+	return nil
+}
+
+// truncateDevice 截断过长的 User-Agent，避免占用过多存储
+func truncateDevice(userAgent string) string {
+	runes := []rune(userAgent)
+	if len(runes) > maxDeviceLength {
+		return string(runes[:maxDeviceLength])
+	}
+	return userAgent
+}
+
+func formatUnix(sec int64) string {
+	if sec <= 0 {
+		return ""
+	}
+	return time.Unix(sec, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"go.uber.org/zap"
+)
+
+// shareTokenPrefixLen 列表中展示的 token 前缀长度
+const shareTokenPrefixLen = 8
+
+// CreateShareLink 创建班级或单个工作表的公开分享链接，需要对应范围的 admin 权限
+func CreateShareLink(ctx context.Context, userID, classID int64, dto *DTO.CreateShareLinkRequestDTO) (*DTO.ShareLinkDTO, *apiError.ApiError) {
+	if dto.SheetID > 0 {
+		sheet, apiErr := getSheetInClass(ctx, classID, dto.SheetID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessAdmin); apiErr != nil {
+			return nil, apiErr
+		}
+	} else {
+		if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+			return nil, apiErr
+		}
+		if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		zap.L().Error("生成分享 token 失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建分享链接失败"}
+	}
+	token := hex.EncodeToString(b)
+
+	link := &model.ShareLink{
+		TokenHash:   hashShareToken(token),
+		TokenPrefix: token[:shareTokenPrefixLen],
+		ClassID:     classID,
+		SheetID:     dto.SheetID,
+		CreatorID:   userID,
+		Description: dto.Description,
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if dto.ExpiresIn > 0 {
+		expire := time.Now().Add(time.Duration(dto.ExpiresIn) * time.Hour)
+		link.ExpireTime = &expire
+	}
+	if err := dao.CreateShareLink(ctx, link); err != nil {
+		zap.L().Error("创建分享链接失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建分享链接失败"}
+	}
+	result := toShareLinkDTO(link)
+	result.Token = token
+	return result, nil
+}
+
+// ListShareLinks 查询班级下的分享链接，需要班级 admin 权限
+func ListShareLinks(ctx context.Context, userID, classID int64) ([]DTO.ShareLinkDTO, *apiError.ApiError) {
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
+	links, err := dao.ListShareLinksByClass(ctx, classID)
+	if err != nil {
+		zap.L().Error("查询分享链接失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询分享链接失败"}
+	}
+	result := make([]DTO.ShareLinkDTO, 0, len(links))
+	for _, link := range links {
+		result = append(result, *toShareLinkDTO(link))
+	}
+	return result, nil
+}
+
+// RevokeShareLink 撤销分享链接，撤销后立即失效
+func RevokeShareLink(ctx context.Context, userID, classID, linkID int64) *apiError.ApiError {
+	link, err := dao.GetShareLinkByID(ctx, linkID)
+	if err != nil {
+		zap.L().Error("查询分享链接失败", zap.Int64("linkID", linkID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销分享链接失败"}
+	}
+	if link == nil || link.ClassID != classID {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "分享链接不存在"}
+	}
+	level, apiErr := accessLevel(ctx, userID, link.ClassID, link.SheetID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if level < rbac.AccessAdmin {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "没有权限撤销该分享链接"}
+	}
+	if err := dao.RevokeShareLink(ctx, linkID); err != nil {
+		zap.L().Error("撤销分享链接失败", zap.Int64("linkID", linkID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "撤销分享链接失败"}
+	}
+	return nil
+}
+
+// GetPublicShare 访客查看分享链接对应的班级及可查看的工作表
+func GetPublicShare(ctx context.Context, token string) (*DTO.PublicShareDTO, *apiError.ApiError) {
+	link, apiErr := resolveShareLink(ctx, token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	class, apiErr := getClassOrNotFound(ctx, link.ClassID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var sheets []*model.Sheet
+	if link.SheetID > 0 {
+		sheet, apiErr := getSheetInClass(ctx, link.ClassID, link.SheetID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		sheets = append(sheets, sheet)
+	} else {
+		var err error
+		if sheets, err = dao.ListSheetsByClassID(ctx, link.ClassID); err != nil {
+			zap.L().Error("查询班级工作表失败", zap.Int64("classID", link.ClassID), zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询工作表失败"}
+		}
+	}
+
+	result := &DTO.PublicShareDTO{
+		ClassID:   class.ID,
+		ClassName: class.Name,
+		SheetID:   link.SheetID,
+		Sheets:    make([]DTO.SheetDetailResponseDTO, 0, len(sheets)),
+	}
+	for _, sheet := range sheets {
+		result.Sheets = append(result.Sheets, DTO.SheetDetailResponseDTO{
+			ID:      sheet.ID,
+			Name:    sheet.Name,
+			Week:    int(sheet.Week),
+			Row:     int(sheet.Row),
+			Col:     int(sheet.Col),
+			ClassID: sheet.ClassID,
+		})
+	}
+	return result, nil
+}
+
+// GetPublicCells 访客查看分享范围内某个工作表的单元格
+func GetPublicCells(ctx context.Context, token string, sheetID int64) ([]DTO.CellDTO, *apiError.ApiError) {
+	sheet, apiErr := resolveSharedSheet(ctx, token, sheetID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return sheetCells(ctx, sheet.ID)
+}
+
+// ExportPublicSheetCSV 访客导出分享范围内某个工作表
+func ExportPublicSheetCSV(ctx context.Context, token string, sheetID int64) (string, []byte, *apiError.ApiError) {
+	sheet, apiErr := resolveSharedSheet(ctx, token, sheetID)
+	if apiErr != nil {
+		return "", nil, apiErr
+	}
+	return exportSheetCSV(ctx, sheet)
+}
+
+// resolveSharedSheet 校验工作表是否在分享范围内
+func resolveSharedSheet(ctx context.Context, token string, sheetID int64) (*model.Sheet, *apiError.ApiError) {
+	link, apiErr := resolveShareLink(ctx, token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if link.SheetID > 0 && link.SheetID != sheetID {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	return getSheetInClass(ctx, link.ClassID, sheetID)
+}
+
+// resolveShareLink 根据 token 查询有效的分享链接，并记录一次访问
+func resolveShareLink(ctx context.Context, token string) (*model.ShareLink, *apiError.ApiError) {
+	link, err := dao.GetShareLinkByTokenHash(ctx, hashShareToken(token))
+	if err != nil {
+		zap.L().Error("查询分享链接失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询分享链接失败"}
+	}
+	if link == nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "分享链接不存在或已被撤销"}
+	}
+	if link.ExpireTime != nil && link.ExpireTime.Before(time.Now()) {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "分享链接已过期"}
+	}
+	// 访问计数失败不影响查看
+	if err := dao.IncreaseShareLinkAccess(ctx, link.ID); err != nil {
+		zap.L().Warn("记录分享链接访问次数失败", zap.Int64("linkID", link.ID), zap.Error(err))
+	}
+	return link, nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toShareLinkDTO(link *model.ShareLink) *DTO.ShareLinkDTO {
+	result := &DTO.ShareLinkDTO{
+		ID:          link.ID,
+		TokenPrefix: link.TokenPrefix,
+		ClassID:     link.ClassID,
+		SheetID:     link.SheetID,
+		CreatorID:   link.CreatorID,
+		Description: link.Description,
+		AccessCount: link.AccessCount,
+		CreateTime:  link.CreateTime.Format(time.RFC3339),
+	}
+	if link.ExpireTime != nil {
+		result.ExpireTime = link.ExpireTime.Format(time.RFC3339)
+	}
+	if link.LastAccessTime != nil {
+		result.LastAccessTime = link.LastAccessTime.Format(time.RFC3339)
+	}
+	return result
+}
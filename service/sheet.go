@@ -10,6 +10,7 @@ import (
 	"github.com/sztu/mutli-table/model"
 	"github.com/sztu/mutli-table/pkg/apiError"
 	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +21,9 @@ func CreateSheet(ctx context.Context, userID, classID int64, dto *DTO.CreateShee
 		zap.L().Error("CreateSheet 查询班级失败", zap.Error(err))
 		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建工作表失败"}
 	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
 	// 获取数据库句柄，并开启事务
 	db := mysql.GetDB().WithContext(ctx)
 	tx := db.Begin()
@@ -56,6 +60,23 @@ func CreateSheet(ctx context.Context, userID, classID int64, dto *DTO.CreateShee
 		}
 	}
 
+	if err := dao.CreatePermissionTx(tx, &model.Permission{
+		UserID:      userID,
+		ClassID:     classID,
+		SheetID:     sheet.ID,
+		AccessLevel: rbac.AccessLevelName(rbac.AccessAdmin),
+		GrantedBy:   userID,
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}); err != nil {
+		tx.Rollback()
+		zap.L().Error("CreateSheet 失败：插入权限记录错误", zap.Error(err))
+		return nil, &apiError.ApiError{
+			Code: code.ServerError,
+			Msg:  "创建工作表失败",
+		}
+	}
+
 	var cells []model.Cell
 	for row := 1; row <= dto.Row; row++ {
 		for col := 1; col <= dto.Col; col++ {
@@ -91,7 +112,7 @@ func CreateSheet(ctx context.Context, userID, classID int64, dto *DTO.CreateShee
 	}
 
 	// 返回成功的响应 DTO
-	return &DTO.SheetResponseDTO{
+	result := &DTO.SheetResponseDTO{
 		ID:         sheet.ID,
 		Name:       sheet.Name,
 		CreatorID:  sheet.CreatorID,
@@ -101,7 +122,12 @@ func CreateSheet(ctx context.Context, userID, classID int64, dto *DTO.CreateShee
 		ClassID:    classID,
 		CreateTime: time.Now().String(),
 		UpdateTime: time.Now().String(),
-	}, nil
+	}
+	emitWebhookEvent(ctx, WebhookEventSheetCreated, classID, &DTO.WebhookSheetEventDTO{
+		OperatorID: userID,
+		Sheet:      *result,
+	})
+	return result, nil
 }
 
 // ListSheets 获取所有的工作表列表
@@ -111,6 +137,9 @@ func ListSheets(ctx context.Context, userID, classID int64, page, pageSize int)
 		zap.L().Error("GetClassByID 查询班级失败", zap.Error(err))
 		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "获取工作表列表失败"}
 	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessRead); apiErr != nil {
+		return nil, apiErr
+	}
 	sheets, total, err := dao.ListSheets(ctx, userID, classID, page, pageSize)
 	if err != nil {
 		zap.L().Error("ListSheets 查询失败", zap.Error(err))
@@ -147,6 +176,9 @@ func GetSheet(ctx context.Context, userID int64, sheetID int64) (*DTO.SheetDetai
 	if sheet == nil {
 		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
 	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessRead); apiErr != nil {
+		return nil, apiErr
+	}
 
 	// 构造返回的 DTO
 	return &DTO.SheetDetailResponseDTO{
@@ -169,6 +201,9 @@ func UpdateSheet(ctx context.Context, userID, sheetID int64, dto *DTO.UpdateShee
 	if sheet == nil {
 		return &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
 	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessAdmin); apiErr != nil {
+		return apiErr
+	}
 
 	// 根据传入非 nil 的字段更新工作表
 	if dto.Name != nil {
@@ -184,7 +219,18 @@ func UpdateSheet(ctx context.Context, userID, sheetID int64, dto *DTO.UpdateShee
 }
 
 // DeleteSheet 逻辑删除工作表
-func DeleteSheet(ctx context.Context, sheetID int64) *apiError.ApiError {
+func DeleteSheet(ctx context.Context, userID, classID, sheetID int64) *apiError.ApiError {
+	sheet, err := dao.GetSheetByID(ctx, sheetID)
+	if err != nil {
+		zap.L().Error("DeleteSheet 查询失败", zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "删除工作表失败"}
+	}
+	if sheet == nil || sheet.ClassID != classID {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "工作表不存在"}
+	}
+	if apiErr := requireSheetAccess(ctx, userID, sheet, rbac.AccessAdmin); apiErr != nil {
+		return apiErr
+	}
 	if err := dao.DeleteSheet(ctx, sheetID); err != nil {
 		zap.L().Error("DeleteSheet 删除失败", zap.Error(err))
 		return &apiError.ApiError{Code: code.ServerError, Msg: "删除工作表失败"}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/rbac"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// EnrollStudents 批量将学生加入班级，需要班级 admin 权限
+func EnrollStudents(ctx context.Context, userID, classID int64, dto *DTO.EnrollStudentsRequestDTO) (*DTO.EnrollStudentsResponseDTO, *apiError.ApiError) {
+	if len(dto.UserIDs) == 0 && len(dto.Usernames) == 0 {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "请指定要加入班级的学生"}
+	}
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return nil, apiErr
+	}
+
+	result := &DTO.EnrollStudentsResponseDTO{NotFound: make([]string, 0)}
+	found := make(map[int64]bool)
+	if len(dto.UserIDs) > 0 {
+		users, err := dao.FindUsersByIDs(ctx, dto.UserIDs)
+		if err != nil {
+			zap.L().Error("批量查询用户失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "加入班级失败"}
+		}
+		byID := make(map[int64]bool, len(users))
+		for _, u := range users {
+			byID[u.UserID] = true
+			found[u.UserID] = true
+		}
+		for _, id := range dto.UserIDs {
+			if !byID[id] {
+				result.NotFound = append(result.NotFound, strconv.FormatInt(id, 10))
+			}
+		}
+	}
+	if len(dto.Usernames) > 0 {
+		users, err := dao.FindUsersByUsernames(ctx, dto.Usernames)
+		if err != nil {
+			zap.L().Error("批量查询用户失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "加入班级失败"}
+		}
+		byName := make(map[string]bool, len(users))
+		for _, u := range users {
+			byName[u.Username] = true
+			found[u.UserID] = true
+		}
+		for _, name := range dto.Usernames {
+			if !byName[name] {
+				result.NotFound = append(result.NotFound, name)
+			}
+		}
+	}
+
+	students := make([]*model.ClassStudent, 0, len(found))
+	for id := range found {
+		students = append(students, &model.ClassStudent{
+			ClassID:    classID,
+			UserID:     id,
+			CreateTime: time.Now(),
+			UpdateTime: time.Now(),
+		})
+	}
+	enrolled, err := dao.CreateClassStudents(ctx, students)
+	if err != nil {
+		zap.L().Error("批量加入班级失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "加入班级失败"}
+	}
+	result.Enrolled = enrolled
+	return result, nil
+}
+
+// ListClassStudents 分页查询班级学生，需要班级 edit 权限
+func ListClassStudents(ctx context.Context, userID, classID int64, page, pageSize int) (*DTO.ClassStudentListDTO, *apiError.ApiError) {
+	if _, apiErr := getClassOrNotFound(ctx, classID); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessEdit); apiErr != nil {
+		return nil, apiErr
+	}
+	students, total, err := dao.ListClassStudents(ctx, classID, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询班级学生失败", zap.Int64("classID", classID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询班级学生失败"}
+	}
+
+	userIDs := make([]int64, 0, len(students))
+	for _, s := range students {
+		userIDs = append(userIDs, s.UserID)
+	}
+	users := make(map[int64]*model.User, len(students))
+	if len(userIDs) > 0 {
+		list, err := dao.FindUsersByIDs(ctx, userIDs)
+		if err != nil {
+			zap.L().Error("批量查询用户失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询班级学生失败"}
+		}
+		for _, u := range list {
+			users[u.UserID] = u
+		}
+	}
+
+	list := make([]DTO.ClassStudentDTO, 0, len(students))
+	for _, s := range students {
+		item := DTO.ClassStudentDTO{
+			UserID:   s.UserID,
+			JoinTime: s.CreateTime.Format(time.RFC3339),
+		}
+		if u, ok := users[s.UserID]; ok {
+			item.Username = u.Username
+			item.Email = u.Email
+		}
+		list = append(list, item)
+	}
+	return &DTO.ClassStudentListDTO{Total: total, List: list}, nil
+}
+
+// RemoveClassStudent 将学生移出班级，需要班级 admin 权限
+func RemoveClassStudent(ctx context.Context, userID, classID, studentID int64) *apiError.ApiError {
+	if apiErr := requireClassAccess(ctx, userID, classID, rbac.AccessAdmin); apiErr != nil {
+		return apiErr
+	}
+	ok, err := dao.DeleteClassStudent(ctx, classID, studentID)
+	if err != nil {
+		zap.L().Error("移出班级失败", zap.Int64("classID", classID), zap.Int64("studentID", studentID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "移出班级失败"}
+	}
+	if !ok {
+		return &apiError.ApiError{Code: code.NotFound, Msg: "该学生不在班级中"}
+	}
+	return nil
+}
+
+// ListMyClasses 查询当前用户作为学生所在的班级
+func ListMyClasses(ctx context.Context, userID int64) ([]DTO.ClassSimpleItemDTO, *apiError.ApiError) {
+	classIDs, err := dao.ListClassIDsByStudent(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询学生班级失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询班级失败"}
+	}
+	result := make([]DTO.ClassSimpleItemDTO, 0, len(classIDs))
+	for _, classID := range classIDs {
+		class, err := dao.GetClassByID(ctx, classID)
+		if err != nil || class == nil {
+			continue
+		}
+		result = append(result, DTO.ClassSimpleItemDTO{ID: class.ID, Name: class.Name})
+	}
+	return result, nil
+}
+
+// GetMyTimetable 查询学生所在班级的课表
+// week 大于 0 时返回该周的完整课表；否则按 date（为空时为今天）换算出周次，只返回当天的课程
+func GetMyTimetable(ctx context.Context, userID int64, week int, date string) (*DTO.MyTimetableDTO, *apiError.ApiError) {
+	result := &DTO.MyTimetableDTO{Week: week, Cells: make([]DTO.CourseCell, 0)}
+	if week <= 0 {
+		day := time.Now()
+		if date != "" {
+			var err error
+			if day, err = time.ParseInLocation(time.DateOnly, date, time.Local); err != nil {
+				return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "日期格式错误，应为 2006-01-02"}
+			}
+		}
+		w, weekday, apiErr := semesterWeek(day)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		result.Week = w
+		result.Date = day.Format(time.DateOnly)
+		result.Weekday = weekday
+	}
+
+	classIDs, err := dao.ListClassIDsByStudent(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询学生班级失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询课表失败"}
+	}
+	items := make(map[int64]*model.DraggableItem)
+	for _, classID := range classIDs {
+		class, err := dao.GetClassByID(ctx, classID)
+		if err != nil || class == nil {
+			continue
+		}
+		sheet, err := dao.GetSheetByClassIDandWeek(ctx, classID, result.Week)
+		if err != nil {
+			zap.L().Error("查询班级课表失败", zap.Int64("classID", classID), zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询课表失败"}
+		}
+		if sheet == nil {
+			continue
+		}
+		cells, err := dao.GetCellsBySheetID(ctx, sheet.ID)
+		if err != nil {
+			zap.L().Error("查询单元格失败", zap.Int64("sheetID", sheet.ID), zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询课表失败"}
+		}
+		for _, cell := range cells {
+			if cell.ItemID == nil || (result.Weekday > 0 && int(cell.ColIndex) != result.Weekday) {
+				continue
+			}
+			item, ok := items[*cell.ItemID]
+			if !ok {
+				item, err = dao.GetDraggableItemByID(ctx, *cell.ItemID)
+				if err != nil {
+					continue
+				}
+				items[*cell.ItemID] = item
+			}
+			if item == nil {
+				continue
+			}
+			result.Cells = append(result.Cells, DTO.CourseCell{
+				Row:       int(cell.RowIndex),
+				Col:       int(cell.ColIndex),
+				Content:   item.Content,
+				Classroom: item.Classroom,
+				Teacher:   item.Teacher,
+				ClassName: class.Name,
+			})
+		}
+	}
+	return result, nil
+}
+
+// semesterWeek 根据配置的学期开始日期计算某天是第几周、星期几（1~7 对应周一至周日）
+func semesterWeek(day time.Time) (int, int, *apiError.ApiError) {
+	conf := settings.GetConfig().TimetableConfig
+	if conf == nil || conf.SemesterStart == "" {
+		return 0, 0, &apiError.ApiError{Code: code.InvalidParam, Msg: "未配置学期开始日期，请指定周次"}
+	}
+	start, err := time.ParseInLocation(time.DateOnly, conf.SemesterStart, time.Local)
+	if err != nil {
+		zap.L().Error("学期开始日期配置错误", zap.String("semesterStart", conf.SemesterStart), zap.Error(err))
+		return 0, 0, &apiError.ApiError{Code: code.ServerError, Msg: "学期开始日期配置错误"}
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	// 按天取整，避免夏令时切换造成的误差
+	days := int(math.Round(day.Sub(start).Hours() / 24))
+	if days < 0 {
+		return 0, 0, &apiError.ApiError{Code: code.InvalidParam, Msg: "该日期早于学期开始日期"}
+	}
+	weekday := int(day.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return days/7 + 1, weekday, nil
+}
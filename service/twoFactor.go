@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	mysql "github.com/sztu/mutli-table/DAO/MySQL"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/cache"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/totp"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+// totpSkew 允许验证器与服务器之间前后各一个时间步的时钟偏差
+const totpSkew = 1
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// twoFactorConfig 返回两步验证配置，未配置的字段使用默认值
+func twoFactorConfig() settings.TwoFactorConfig {
+	conf := settings.TwoFactorConfig{
+		Issuer:        "MutliTable",
+		ChallengeTTL:  5,
+		MaxAttempts:   5,
+		RecoveryCodes: 10,
+	}
+	if c := settings.GetConfig().TwoFactorConfig; c != nil {
+		if c.Issuer != "" {
+			conf.Issuer = c.Issuer
+		}
+		conf.RequiredRoles = c.RequiredRoles
+		if c.ChallengeTTL > 0 {
+			conf.ChallengeTTL = c.ChallengeTTL
+		}
+		if c.MaxAttempts > 0 {
+			conf.MaxAttempts = c.MaxAttempts
+		}
+		if c.RecoveryCodes > 0 {
+			conf.RecoveryCodes = c.RecoveryCodes
+		}
+	}
+	return conf
+}
+
+// TwoFactorRequired 判断角色是否要求启用两步验证
+func TwoFactorRequired(role string) bool {
+	return slices.Contains(twoFactorConfig().RequiredRoles, role)
+}
+
+// GetTwoFactorStatus 查询当前用户的两步验证状态
+func GetTwoFactorStatus(ctx context.Context, userID int64) (*DTO.TwoFactorStatusDTO, *apiError.ApiError) {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	status := &DTO.TwoFactorStatusDTO{Required: TwoFactorRequired(effectiveRole(user))}
+	enabled, apiErr := twoFactorEnabled(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if enabled {
+		remaining, err := dao.CountUnusedRecoveryCodes(ctx, userID)
+		if err != nil {
+			zap.L().Error("统计恢复码失败", zap.Int64("userID", userID), zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询两步验证状态失败"}
+		}
+		status.Enabled = true
+		status.RecoveryCodesRemaining = remaining
+	}
+	return status, nil
+}
+
+// EnrollTwoFactor 生成新的 TOTP 密钥，用户使用验证器扫码后需要调用 ConfirmTwoFactor 提交验证码才会生效；
+// 重复调用会替换尚未确认的密钥
+func EnrollTwoFactor(ctx context.Context, userID int64) (*DTO.TwoFactorEnrollDTO, *apiError.ApiError) {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	existing, err := dao.GetUserTOTP(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if existing != nil && existing.Enabled {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "已启用两步验证，如需更换验证器请先关闭"}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		zap.L().Error("生成 TOTP 密钥失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if existing == nil {
+		err = dao.CreateUserTOTP(ctx, &model.UserTOTP{
+			UserID:     userID,
+			Secret:     secret,
+			CreateTime: time.Now(),
+			UpdateTime: time.Now(),
+		})
+	} else {
+		_, err = dao.UpdatePendingTOTPSecret(ctx, userID, secret)
+	}
+	if err != nil {
+		zap.L().Error("保存 TOTP 密钥失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+
+	conf := twoFactorConfig()
+	return &DTO.TwoFactorEnrollDTO{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(conf.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor 校验验证器生成的验证码，通过后启用两步验证并返回恢复码
+func ConfirmTwoFactor(ctx context.Context, userID int64, dto *DTO.TwoFactorCodeRequestDTO) (*DTO.RecoveryCodesDTO, *apiError.ApiError) {
+	pending, err := dao.GetUserTOTP(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if pending == nil {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "请先获取两步验证密钥"}
+	}
+	if pending.Enabled {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "已启用两步验证"}
+	}
+	step, ok, err := totp.Validate(pending.Secret, dto.Code, time.Now(), totpSkew)
+	if err != nil {
+		zap.L().Error("校验 TOTP 验证码失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if !ok {
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "验证码错误，请确认设备时间准确后重试"}
+	}
+
+	codes, hashes, err := generateRecoveryCodes(twoFactorConfig().RecoveryCodes)
+	if err != nil {
+		zap.L().Error("生成恢复码失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	tx := mysql.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	enabled, err := dao.EnableUserTOTPTx(ctx, tx, userID, step)
+	if err != nil {
+		tx.Rollback()
+		zap.L().Error("启用两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if !enabled {
+		tx.Rollback()
+		return nil, &apiError.ApiError{Code: code.InvalidParam, Msg: "已启用两步验证"}
+	}
+	if err := dao.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes); err != nil {
+		tx.Rollback()
+		zap.L().Error("保存恢复码失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "启用两步验证失败"}
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
+	}
+	zap.L().Info("用户已启用两步验证", zap.Int64("userID", userID))
+	return &DTO.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor 校验密码和验证码（或恢复码）后关闭两步验证，角色要求启用两步验证时不允许关闭
+func DisableTwoFactor(ctx context.Context, userID int64, clientIP string, dto *DTO.DisableTwoFactorRequestDTO) *apiError.ApiError {
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if TwoFactorRequired(effectiveRole(user)) {
+		return &apiError.ApiError{Code: code.NoPermission, Msg: "当前角色必须启用两步验证"}
+	}
+	if apiErr := verifyCurrentPassword(ctx, user, dto.Password, clientIP); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := verifySecondFactor(ctx, userID, dto.Code, dto.RecoveryCode); apiErr != nil {
+		return apiErr
+	}
+
+	tx := mysql.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := dao.DeleteUserTOTPTx(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		zap.L().Error("关闭两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "关闭两步验证失败"}
+	}
+	if err := dao.DeleteRecoveryCodesTx(ctx, tx, userID); err != nil {
+		tx.Rollback()
+		zap.L().Error("删除恢复码失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "关闭两步验证失败"}
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
+	}
+	zap.L().Info("用户已关闭两步验证", zap.Int64("userID", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验验证码后生成新的恢复码，之前的恢复码全部作废
+func RegenerateRecoveryCodes(ctx context.Context, userID int64, dto *DTO.TwoFactorCodeRequestDTO) (*DTO.RecoveryCodesDTO, *apiError.ApiError) {
+	if apiErr := verifySecondFactor(ctx, userID, dto.Code, ""); apiErr != nil {
+		return nil, apiErr
+	}
+	codes, hashes, err := generateRecoveryCodes(twoFactorConfig().RecoveryCodes)
+	if err != nil {
+		zap.L().Error("生成恢复码失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "生成恢复码失败"}
+	}
+	tx := mysql.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := dao.ReplaceRecoveryCodesTx(ctx, tx, userID, hashes); err != nil {
+		tx.Rollback()
+		zap.L().Error("保存恢复码失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "生成恢复码失败"}
+	}
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "事务提交失败"}
+	}
+	return &DTO.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// completeLogin 第一步认证通过后：已停用的用户拒绝登录，已启用两步验证的用户返回挑战 token，否则直接签发 token
+func completeLogin(ctx context.Context, user *model.User, clientIP, userAgent string) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	if user.Disabled {
+		return nil, errUserDisabled
+	}
+	enabled, apiErr := twoFactorEnabled(ctx, user.UserID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !enabled {
+		resp, apiErr := issueLoginTokens(ctx, user, clientIP, userAgent, false)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		resp.TwoFactorSetupRequired = TwoFactorRequired(effectiveRole(user))
+		return resp, nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		zap.L().Error("生成登录挑战失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "登录失败"}
+	}
+	challenge := hex.EncodeToString(b)
+	ttl := time.Duration(twoFactorConfig().ChallengeTTL) * time.Minute
+	if err := cache.SaveTwoFactorChallenge(ctx, hashAccountToken(challenge), user.UserID, ttl); err != nil {
+		zap.L().Error("保存登录挑战失败", zap.Int64("userID", user.UserID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "登录失败"}
+	}
+	return &DTO.LoginResponseDTO{
+		UserID:            user.UserID,
+		Username:          user.Username,
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	}, nil
+}
+
+// VerifyTwoFactorLogin 登录第二步：校验挑战 token 和验证码（或恢复码），通过后签发 token。
+// 同一个挑战输错次数达到上限后作废，需要重新输入密码
+func VerifyTwoFactorLogin(ctx context.Context, dto *DTO.TwoFactorLoginRequestDTO, clientIP, userAgent string) (*DTO.LoginResponseDTO, *apiError.ApiError) {
+	expired := &apiError.ApiError{Code: code.InvalidAuth, Msg: "登录请求已过期，请重新登录"}
+	challengeHash := hashAccountToken(dto.ChallengeToken)
+	userID, err := cache.GetTwoFactorChallenge(ctx, challengeHash)
+	if err != nil {
+		zap.L().Error("读取登录挑战失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "登录失败"}
+	}
+	if userID == 0 {
+		return nil, expired
+	}
+	user, apiErr := getCurrentUser(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.Disabled {
+		return nil, errUserDisabled
+	}
+
+	if apiErr := verifySecondFactor(ctx, userID, dto.Code, dto.RecoveryCode); apiErr != nil {
+		if apiErr.Code == code.ServerError {
+			return nil, apiErr
+		}
+		recordLoginFailure(ctx, user.Username, clientIP)
+		attempts, err := cache.IncrTwoFactorChallengeAttempts(ctx, challengeHash)
+		if err != nil {
+			zap.L().Error("记录验证码输错次数失败", zap.Error(err))
+		}
+		if attempts < 0 || attempts >= int64(twoFactorConfig().MaxAttempts) {
+			if _, err := cache.DeleteTwoFactorChallenge(ctx, challengeHash); err != nil {
+				zap.L().Error("作废登录挑战失败", zap.Error(err))
+			}
+			return nil, expired
+		}
+		return nil, &apiError.ApiError{Code: code.InvalidAuth, Msg: apiErr.Msg}
+	}
+
+	// 挑战只能使用一次，并发提交时只有删除成功的请求可以继续
+	ok, err := cache.DeleteTwoFactorChallenge(ctx, challengeHash)
+	if err != nil {
+		zap.L().Error("作废登录挑战失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "登录失败"}
+	}
+	if !ok {
+		return nil, expired
+	}
+	return issueLoginTokens(ctx, user, clientIP, userAgent, true)
+}
+
+// twoFactorEnabled 判断用户是否已启用两步验证
+func twoFactorEnabled(ctx context.Context, userID int64) (bool, *apiError.ApiError) {
+	t, err := dao.GetUserTOTP(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return false, &apiError.ApiError{Code: code.ServerError, Msg: "查询两步验证状态失败"}
+	}
+	return t != nil && t.Enabled, nil
+}
+
+// verifySecondFactor 校验验证码或恢复码，验证码和恢复码都只能使用一次
+func verifySecondFactor(ctx context.Context, userID int64, totpCode, recoveryCode string) *apiError.ApiError {
+	wrong := &apiError.ApiError{Code: code.InvalidParam, Msg: "验证码错误"}
+	if totpCode == "" {
+		if recoveryCode == "" {
+			return wrong
+		}
+		ok, err := dao.UseRecoveryCode(ctx, userID, hashAccountToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			zap.L().Error("校验恢复码失败", zap.Int64("userID", userID), zap.Error(err))
+			return &apiError.ApiError{Code: code.ServerError, Msg: "校验验证码失败"}
+		}
+		if !ok {
+			return wrong
+		}
+		zap.L().Info("用户使用恢复码完成两步验证", zap.Int64("userID", userID))
+		return nil
+	}
+
+	t, err := dao.GetUserTOTP(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询两步验证失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "校验验证码失败"}
+	}
+	if t == nil || !t.Enabled {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "未启用两步验证"}
+	}
+	step, ok, err := totp.ValidateAfter(t.Secret, totpCode, time.Now(), totpSkew, t.LastUsedStep)
+	if errors.Is(err, totp.ErrReused) {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "验证码已使用，请等待下一个验证码"}
+	}
+	if err != nil {
+		zap.L().Error("校验 TOTP 验证码失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "校验验证码失败"}
+	}
+	if !ok {
+		return wrong
+	}
+	// 原子地记录时间步，并发请求使用同一验证码时只有一个能通过
+	ok, err = dao.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		zap.L().Error("记录 TOTP 时间步失败", zap.Int64("userID", userID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "校验验证码失败"}
+	}
+	if !ok {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "验证码已使用，请等待下一个验证码"}
+	}
+	return nil
+}
+
+// generateRecoveryCodes 生成 n 个 xxxxx-xxxxx 格式的恢复码，返回明文和用于存储的哈希
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashAccountToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode 忽略用户输入恢复码时的大小写、空格和连字符
+func normalizeRecoveryCode(s string) string {
+	s = strings.ToLower(s)
+	return strings.NewReplacer("-", "", " ", "").Replace(s)
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	dao "github.com/sztu/mutli-table/DAO"
+	"github.com/sztu/mutli-table/DTO"
+	"github.com/sztu/mutli-table/model"
+	"github.com/sztu/mutli-table/pkg/apiError"
+	"github.com/sztu/mutli-table/pkg/code"
+	"github.com/sztu/mutli-table/pkg/snowflake"
+	"github.com/sztu/mutli-table/pkg/webhook"
+	"github.com/sztu/mutli-table/settings"
+	"go.uber.org/zap"
+)
+
+const (
+	WebhookEventItemPlaced   = "item.placed"
+	WebhookEventItemRemoved  = "item.removed"
+	WebhookEventSheetCreated = "sheet.created"
+	WebhookEventClassDeleted = "class.deleted"
+	WebhookEventPing         = "ping"
+
+	webhookBatchSize    = 50
+	webhookStaleTimeout = 10 * time.Minute // 超过该时间仍处于 sending 的记录视为投递中断
+	webhookRetryBase    = 30 * time.Second
+)
+
+// webhookEventTypes 可订阅的事件类型，ping 只能通过测试接口手动触发
+var webhookEventTypes = map[string]bool{
+	WebhookEventItemPlaced:   true,
+	WebhookEventItemRemoved:  true,
+	WebhookEventSheetCreated: true,
+	WebhookEventClassDeleted: true,
+}
+
+// CreateWebhook 创建 webhook 订阅，未指定密钥时自动生成；密钥只在本次响应中返回
+func CreateWebhook(ctx context.Context, userID int64, dto *DTO.CreateWebhookRequestDTO) (*DTO.WebhookDTO, *apiError.ApiError) {
+	if apiErr := validateWebhook(ctx, dto.URL, dto.EventTypes, dto.ClassID); apiErr != nil {
+		return nil, apiErr
+	}
+	secret := dto.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			zap.L().Error("生成 webhook 密钥失败", zap.Error(err))
+			return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建 webhook 失败"}
+		}
+	}
+
+	sub := &model.WebhookSubscription{
+		CreatorID:   userID,
+		URL:         dto.URL,
+		Secret:      secret,
+		EventTypes:  strings.Join(dto.EventTypes, ","),
+		ClassID:     dto.ClassID,
+		Description: dto.Description,
+		IsActive:    true,
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if err := dao.CreateWebhookSubscription(ctx, sub); err != nil {
+		zap.L().Error("创建 webhook 订阅失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "创建 webhook 失败"}
+	}
+	result := toWebhookDTO(sub)
+	result.Secret = sub.Secret
+	return result, nil
+}
+
+// ListWebhooks 查询当前用户创建的 webhook 订阅
+func ListWebhooks(ctx context.Context, userID int64) ([]DTO.WebhookDTO, *apiError.ApiError) {
+	subs, err := dao.ListWebhookSubscriptionsByCreator(ctx, userID)
+	if err != nil {
+		zap.L().Error("查询 webhook 订阅失败", zap.Int64("userID", userID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询 webhook 失败"}
+	}
+	list := make([]DTO.WebhookDTO, 0, len(subs))
+	for _, sub := range subs {
+		list = append(list, *toWebhookDTO(sub))
+	}
+	return list, nil
+}
+
+// GetWebhook 查询单个 webhook 订阅
+func GetWebhook(ctx context.Context, userID, webhookID int64) (*DTO.WebhookDTO, *apiError.ApiError) {
+	sub, apiErr := loadOwnWebhook(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return toWebhookDTO(sub), nil
+}
+
+// UpdateWebhook 更新 webhook 订阅，更换密钥时在响应中返回新密钥
+func UpdateWebhook(ctx context.Context, userID, webhookID int64, dto *DTO.UpdateWebhookRequestDTO) (*DTO.WebhookDTO, *apiError.ApiError) {
+	sub, apiErr := loadOwnWebhook(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if dto.URL != nil {
+		sub.URL = *dto.URL
+	}
+	if dto.EventTypes != nil {
+		sub.EventTypes = strings.Join(dto.EventTypes, ",")
+	}
+	if dto.ClassID != nil {
+		sub.ClassID = *dto.ClassID
+	}
+	if dto.Description != nil {
+		sub.Description = *dto.Description
+	}
+	if dto.IsActive != nil {
+		sub.IsActive = *dto.IsActive
+	}
+	if dto.Secret != nil {
+		sub.Secret = *dto.Secret
+	}
+	if apiErr := validateWebhook(ctx, sub.URL, strings.Split(sub.EventTypes, ","), sub.ClassID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	sub.UpdateTime = time.Now()
+	if err := dao.UpdateWebhookSubscription(ctx, sub); err != nil {
+		zap.L().Error("更新 webhook 订阅失败", zap.Int64("webhookID", webhookID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "更新 webhook 失败"}
+	}
+	result := toWebhookDTO(sub)
+	if dto.Secret != nil {
+		result.Secret = sub.Secret
+	}
+	return result, nil
+}
+
+// DeleteWebhook 删除 webhook 订阅，尚未投递的记录会在投递时被丢弃
+func DeleteWebhook(ctx context.Context, userID, webhookID int64) *apiError.ApiError {
+	if _, apiErr := loadOwnWebhook(ctx, userID, webhookID); apiErr != nil {
+		return apiErr
+	}
+	if err := dao.DeleteWebhookSubscription(ctx, webhookID); err != nil {
+		zap.L().Error("删除 webhook 订阅失败", zap.Int64("webhookID", webhookID), zap.Error(err))
+		return &apiError.ApiError{Code: code.ServerError, Msg: "删除 webhook 失败"}
+	}
+	return nil
+}
+
+// ListWebhookDeliveries 分页查询 webhook 的投递记录
+func ListWebhookDeliveries(ctx context.Context, userID, webhookID int64, page, pageSize int) (*DTO.WebhookDeliveryListDTO, *apiError.ApiError) {
+	if _, apiErr := loadOwnWebhook(ctx, userID, webhookID); apiErr != nil {
+		return nil, apiErr
+	}
+	deliveries, total, err := dao.ListWebhookDeliveries(ctx, webhookID, page, pageSize)
+	if err != nil {
+		zap.L().Error("查询 webhook 投递记录失败", zap.Int64("webhookID", webhookID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询投递记录失败"}
+	}
+	list := make([]DTO.WebhookDeliveryDTO, 0, len(deliveries))
+	for _, d := range deliveries {
+		list = append(list, *toWebhookDeliveryDTO(d))
+	}
+	return &DTO.WebhookDeliveryListDTO{Total: total, List: list}, nil
+}
+
+// PingWebhook 立即向订阅地址发送一个 ping 事件，用于验证地址和签名校验是否配置正确。
+// ping 只尝试一次，结果同样记录在投递记录中；只返回状态码和错误信息，不返回接收方的响应内容
+func PingWebhook(ctx context.Context, userID, webhookID int64) (*DTO.WebhookDeliveryDTO, *apiError.ApiError) {
+	sub, apiErr := loadOwnWebhook(ctx, userID, webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	eventID, payload, err := buildWebhookEvent(WebhookEventPing, &DTO.WebhookPingEventDTO{WebhookID: sub.ID})
+	if err != nil {
+		zap.L().Error("构造 ping 事件失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "发送 ping 失败"}
+	}
+	delivery := newWebhookDelivery(sub.ID, eventID, WebhookEventPing, payload)
+	delivery.Status = "sending"
+	if err := dao.CreateWebhookDelivery(ctx, delivery); err != nil {
+		zap.L().Error("写入 ping 投递记录失败", zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "发送 ping 失败"}
+	}
+
+	deliverWebhook(ctx, newWebhookClient(), sub, delivery, 1)
+	return toWebhookDeliveryDTO(delivery), nil
+}
+
+// emitWebhookEvent 为订阅了该事件的每个 webhook 写入一条待投递记录，由后台协程异步发送。
+// 写入失败只记录日志，不影响触发事件的业务操作
+func emitWebhookEvent(ctx context.Context, eventType string, classID int64, data interface{}) {
+	subs, err := dao.ListWebhookSubscriptionsForEvent(ctx, eventType, classID)
+	if err != nil {
+		zap.L().Error("查询 webhook 订阅失败", zap.String("eventType", eventType), zap.Error(err))
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	eventID, payload, err := buildWebhookEvent(eventType, data)
+	if err != nil {
+		zap.L().Error("构造 webhook 事件失败", zap.String("eventType", eventType), zap.Error(err))
+		return
+	}
+	for _, sub := range subs {
+		if err := dao.CreateWebhookDelivery(ctx, newWebhookDelivery(sub.ID, eventID, eventType, payload)); err != nil {
+			zap.L().Error("写入 webhook 投递记录失败", zap.Int64("webhookID", sub.ID), zap.Error(err))
+		}
+	}
+}
+
+// emitItemWebhookEvent 发送 item.placed / item.removed 事件
+func emitItemWebhookEvent(ctx context.Context, eventType string, operatorID int64, sheet *model.Sheet, item *model.DraggableItem, row, col int) {
+	emitWebhookEvent(ctx, eventType, sheet.ClassID, &DTO.WebhookItemEventDTO{
+		ClassID:    sheet.ClassID,
+		SheetID:    sheet.ID,
+		Week:       int(sheet.Week),
+		Row:        row,
+		Col:        col,
+		OperatorID: operatorID,
+		Item: DTO.DragItemResponseDTO{
+			ID:         item.ID,
+			WeekType:   item.WeekType,
+			Classroom:  item.Classroom,
+			Teacher:    item.Teacher,
+			Content:    item.Content,
+			CreatorID:  item.CreatorID,
+			CreateTime: item.CreateTime.Format(time.RFC3339),
+			UpdateTime: item.UpdateTime.Format(time.RFC3339),
+		},
+	})
+}
+
+// StartWebhookWorker 启动后台协程定期投递 webhook 事件，ctx 取消时退出
+func StartWebhookWorker(ctx context.Context) {
+	cfg := settings.GetConfig().WebhookConfig
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	client := newWebhookClient()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deliverWebhooks(ctx, client, cfg.MaxAttempts)
+			}
+		}
+	}()
+}
+
+// deliverWebhooks 投递一批到期的记录
+func deliverWebhooks(ctx context.Context, client *http.Client, maxAttempts int) {
+	defer func() {
+		if r := recover(); r != nil {
+			zap.L().Error("投递 webhook 时发生 panic", zap.Any("panic", r))
+		}
+	}()
+
+	if err := dao.ResetStaleWebhookDeliveries(ctx, time.Now().Add(-webhookStaleTimeout)); err != nil {
+		zap.L().Error("重置中断的 webhook 投递失败", zap.Error(err))
+	}
+	deliveries, err := dao.ListDueWebhookDeliveries(ctx, webhookBatchSize)
+	if err != nil {
+		zap.L().Error("查询待投递 webhook 失败", zap.Error(err))
+		return
+	}
+
+	subs := make(map[int64]*model.WebhookSubscription)
+	for _, d := range deliveries {
+		claimed, err := dao.ClaimWebhookDelivery(ctx, d.ID)
+		if err != nil || !claimed {
+			continue
+		}
+
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = dao.GetWebhookSubscriptionByID(ctx, d.SubscriptionID); err != nil {
+				zap.L().Error("查询 webhook 订阅失败", zap.Int64("webhookID", d.SubscriptionID), zap.Error(err))
+				d.Status = "pending"
+				if err := dao.UpdateWebhookDeliveryResult(ctx, d); err != nil {
+					zap.L().Error("更新 webhook 投递结果失败", zap.Int64("deliveryID", d.ID), zap.Error(err))
+				}
+				continue
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		if sub == nil || !sub.IsActive {
+			// 订阅已删除或停用，不再投递
+			d.Status = "failed"
+			d.LastError = "订阅已删除或停用"
+			if err := dao.UpdateWebhookDeliveryResult(ctx, d); err != nil {
+				zap.L().Error("更新 webhook 投递结果失败", zap.Int64("deliveryID", d.ID), zap.Error(err))
+			}
+			continue
+		}
+
+		deliverWebhook(ctx, client, sub, d, maxAttempts)
+	}
+}
+
+// deliverWebhook 发送一次并记录结果
+// 失败的投递按 30 秒、1、2、4... 分钟退避重试，达到最大次数后标记为 failed
+func deliverWebhook(ctx context.Context, client *http.Client, sub *model.WebhookSubscription, d *model.WebhookDelivery, maxAttempts int) {
+	resp, err := webhook.Send(ctx, client, &webhook.Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		EventType:  d.EventType,
+		DeliveryID: d.ID,
+		Body:       []byte(d.Payload),
+	})
+
+	d.Attempts++
+	d.ResponseStatus = int32(resp.StatusCode)
+	d.ResponseBody = truncate(resp.Body, 1024)
+	if err == nil {
+		d.Status = "success"
+		d.LastError = ""
+	} else {
+		zap.L().Warn("webhook 投递失败",
+			zap.Int64("deliveryID", d.ID),
+			zap.Int64("webhookID", sub.ID),
+			zap.Int32("attempts", d.Attempts),
+			zap.Error(err))
+		d.LastError = truncate(err.Error(), 512)
+		if int(d.Attempts) >= maxAttempts {
+			d.Status = "failed"
+		} else {
+			d.Status = "pending"
+			d.NextAttemptTime = time.Now().Add(webhookRetryBase << (d.Attempts - 1))
+		}
+	}
+	if err := dao.UpdateWebhookDeliveryResult(ctx, d); err != nil {
+		zap.L().Error("更新 webhook 投递结果失败", zap.Int64("deliveryID", d.ID), zap.Error(err))
+	}
+}
+
+// newWebhookClient 创建投递客户端，不跟随重定向，建立连接时拒绝内网和保留地址
+func newWebhookClient() *http.Client {
+	conf := settings.GetConfig().WebhookConfig
+	timeout := time.Duration(conf.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return webhook.NewClient(timeout, conf.AllowPrivateNetwork)
+}
+
+func newWebhookDelivery(subscriptionID int64, eventID, eventType, payload string) *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		SubscriptionID:  subscriptionID,
+		EventID:         eventID,
+		EventType:       eventType,
+		Payload:         payload,
+		Status:          "pending",
+		NextAttemptTime: time.Now(),
+		CreateTime:      time.Now(),
+		UpdateTime:      time.Now(),
+	}
+}
+
+// buildWebhookEvent 生成事件ID并序列化请求体
+func buildWebhookEvent(eventType string, data interface{}) (string, string, error) {
+	id, err := snowflake.GetID()
+	if err != nil {
+		return "", "", err
+	}
+	eventID := strconv.FormatInt(id, 10)
+	payload, err := json.Marshal(&DTO.WebhookEventDTO{
+		ID:         eventID,
+		Type:       eventType,
+		CreateTime: time.Now().Format(time.RFC3339),
+		Data:       data,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return eventID, string(payload), nil
+}
+
+func loadOwnWebhook(ctx context.Context, userID, webhookID int64) (*model.WebhookSubscription, *apiError.ApiError) {
+	sub, err := dao.GetWebhookSubscriptionByID(ctx, webhookID)
+	if err != nil {
+		zap.L().Error("查询 webhook 订阅失败", zap.Int64("webhookID", webhookID), zap.Error(err))
+		return nil, &apiError.ApiError{Code: code.ServerError, Msg: "查询 webhook 失败"}
+	}
+	if sub == nil {
+		return nil, &apiError.ApiError{Code: code.NotFound, Msg: "webhook 不存在"}
+	}
+	if sub.CreatorID != userID {
+		return nil, &apiError.ApiError{Code: code.NoPermission, Msg: "无权操作该 webhook"}
+	}
+	return sub, nil
+}
+
+func validateWebhook(ctx context.Context, rawURL string, eventTypes []string, classID int64) *apiError.ApiError {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return &apiError.ApiError{Code: code.InvalidParam, Msg: "回调地址必须是 http 或 https 地址"}
+	}
+	// 域名解析结果可能变化，这里只提前拒绝明显的内网地址，投递时建立连接前还会再次校验
+	if !settings.GetConfig().WebhookConfig.AllowPrivateNetwork {
+		host := strings.ToLower(u.Hostname())
+		if ip := net.ParseIP(host); (ip != nil && webhook.IsForbiddenIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return &apiError.ApiError{Code: code.InvalidParam, Msg: webhook.ErrForbiddenAddress.Error()}
+		}
+	}
+	for _, t := range eventTypes {
+		if !webhookEventTypes[t] {
+			return &apiError.ApiError{Code: code.InvalidParam, Msg: "不支持的事件类型: " + t}
+		}
+	}
+	if classID != 0 {
+		if class, err := dao.GetClassByID(ctx, classID); err != nil || class == nil {
+			return &apiError.ApiError{Code: code.NotFound, Msg: "班级不存在"}
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toWebhookDTO(sub *model.WebhookSubscription) *DTO.WebhookDTO {
+	return &DTO.WebhookDTO{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		EventTypes:  strings.Split(sub.EventTypes, ","),
+		ClassID:     sub.ClassID,
+		Description: sub.Description,
+		IsActive:    sub.IsActive,
+		CreateTime:  sub.CreateTime.Format(time.RFC3339),
+		UpdateTime:  sub.UpdateTime.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryDTO(d *model.WebhookDelivery) *DTO.WebhookDeliveryDTO {
+	return &DTO.WebhookDeliveryDTO{
+		ID:              d.ID,
+		EventID:         d.EventID,
+		EventType:       d.EventType,
+		Payload:         d.Payload,
+		Status:          d.Status,
+		Attempts:        int(d.Attempts),
+		ResponseStatus:  int(d.ResponseStatus),
+		LastError:       d.LastError,
+		NextAttemptTime: d.NextAttemptTime.Format(time.RFC3339),
+		CreateTime:      d.CreateTime.Format(time.RFC3339),
+		UpdateTime:      d.UpdateTime.Format(time.RFC3339),
+	}
+}
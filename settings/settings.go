@@ -47,15 +47,155 @@ type LoggerConfig struct {
 	Compress         bool     `mapstructure:"compress"`
 }
 
-type Settings struct {
+type MailConfig struct {
+	Driver         string `mapstructure:"driver"` // smtp 或 log，log 仅写入本地文件用于开发调试
 	Host           string `mapstructure:"host"`
 	Port           int    `mapstructure:"port"`
-	Timeout        int    `mapstructure:"timeout"`
-	PasswordSecret string `mapstructure:"password_secret"`
-	Mode           string `mapstructure:"mode"`
-	*MysqlConfig   `mapstructure:"mysql"`
-	*RedisConfig   `mapstructure:"redis"`
-	*LoggerConfig  `mapstructure:"logger"`
+	Username       string `mapstructure:"username"`
+	Password       string `mapstructure:"password"`
+	From           string `mapstructure:"from"`
+	LogPath        string `mapstructure:"logPath"`
+	OutboxInterval int    `mapstructure:"outboxInterval"` // 发件箱轮询间隔，单位秒
+	MaxAttempts    int    `mapstructure:"maxAttempts"`    // 单封邮件最大投递次数
+}
+
+type WebhookConfig struct {
+	Interval    int `mapstructure:"interval"`    // 投递队列轮询间隔，单位秒
+	Timeout     int `mapstructure:"timeout"`     // 单次请求超时时间，单位秒
+	MaxAttempts int `mapstructure:"maxAttempts"` // 单次投递最大尝试次数
+	// AllowPrivateNetwork 允许回调地址指向回环、内网等地址，仅用于开发环境调试本地接收方
+	AllowPrivateNetwork bool `mapstructure:"allowPrivateNetwork"`
+}
+
+type BroadcastConfig struct {
+	Driver        string `mapstructure:"driver"`        // memory 或 redis，多实例部署时必须使用 redis
+	ChannelPrefix string `mapstructure:"channelPrefix"` // redis 频道名及历史 stream 键名前缀
+}
+
+type RBACConfig struct {
+	DefaultRole string   `mapstructure:"defaultRole"` // 新注册用户的默认角色，默认为没有任何班级访问权限的 student
+	Admins      []string `mapstructure:"admins"`      // 始终视为管理员的用户名，用于初始化第一个管理员
+}
+
+type TimetableConfig struct {
+	SemesterStart string `mapstructure:"semesterStart"` // 第一周周一的日期，格式 2006-01-02，用于计算某天属于第几周
+}
+
+type JWTConfig struct {
+	AccessTokenTTL  int            `mapstructure:"accessTokenTTL"`  // access token 有效期，单位分钟
+	RefreshTokenTTL int            `mapstructure:"refreshTokenTTL"` // refresh token 有效期，单位小时，每次刷新后重新计算
+	Secret          string         `mapstructure:"secret"`          // 未配置 keys 时使用的 HS256 密钥，应通过环境变量 JWT_SECRET 设置
+	SigningKid      string         `mapstructure:"signingKid"`      // 用于签发新 token 的密钥 kid，为空时使用 keys 中第一个带私钥的密钥
+	Keys            []JWTKeyConfig `mapstructure:"keys"`            // 全部密钥，轮换期间旧密钥只保留公钥用于校验
+}
+
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`      // HS256、RS256 或 EdDSA
+	Secret         string `mapstructure:"secret"`         // HS256 密钥，支持 ${ENV} 形式引用环境变量
+	PrivateKeyFile string `mapstructure:"privateKeyFile"` // PEM 格式私钥文件，只用于校验的旧密钥可以不配置
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`  // PEM 格式公钥文件，配置了私钥时可以省略
+}
+
+type PasswordConfig struct {
+	Algorithm     string `mapstructure:"algorithm"`     // argon2id 或 bcrypt，修改后旧哈希会在用户下次登录时自动重新计算
+	BcryptCost    int    `mapstructure:"bcryptCost"`    // bcrypt 计算成本
+	Argon2Memory  uint32 `mapstructure:"argon2Memory"`  // argon2id 内存开销，单位 KiB
+	Argon2Time    uint32 `mapstructure:"argon2Time"`    // argon2id 迭代次数
+	Argon2Threads uint8  `mapstructure:"argon2Threads"` // argon2id 并行度
+}
+
+type LoginGuardConfig struct {
+	Window             int `mapstructure:"window"`             // 失败次数统计窗口，单位分钟
+	FreeAttempts       int `mapstructure:"freeAttempts"`       // 同一账号在窗口内允许的无延迟失败次数
+	BaseDelay          int `mapstructure:"baseDelay"`          // 超过 freeAttempts 后的首次等待时间，单位秒，之后每次失败翻倍
+	MaxDelay           int `mapstructure:"maxDelay"`           // 单次等待时间上限，单位秒
+	MaxAccountAttempts int `mapstructure:"maxAccountAttempts"` // 同一账号在窗口内的失败次数达到该值后锁定
+	MaxIPAttempts      int `mapstructure:"maxIPAttempts"`      // 同一 IP 在窗口内的失败次数达到该值后锁定
+	LockoutDuration    int `mapstructure:"lockoutDuration"`    // 锁定时长，单位分钟
+}
+
+type AccountConfig struct {
+	PublicURL            string `mapstructure:"publicURL"`            // 前端地址，用于拼接邮件中的验证和重置链接
+	EmailVerifyTTL       int    `mapstructure:"emailVerifyTTL"`       // 邮箱验证链接有效期，单位小时
+	PasswordResetTTL     int    `mapstructure:"passwordResetTTL"`     // 重置密码链接有效期，单位分钟
+	MailCooldown         int    `mapstructure:"mailCooldown"`         // 同一用户两次发送验证或重置邮件的最小间隔，单位秒
+	RequireVerifiedEmail bool   `mapstructure:"requireVerifiedEmail"` // 为 true 时邮箱未验证的用户不能登录
+}
+
+type OIDCConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Issuer        string   `mapstructure:"issuer"` // 身份提供方地址，启动后通过 {issuer}/.well-known/openid-configuration 发现端点
+	ClientID      string   `mapstructure:"clientId"`
+	ClientSecret  string   `mapstructure:"clientSecret"`  // 可通过环境变量 OIDC_CLIENT_SECRET 覆盖；公开客户端只使用 PKCE 时可以为空
+	RedirectURL   string   `mapstructure:"redirectURL"`   // 前端回调页地址，需要与身份提供方登记的一致
+	Scopes        []string `mapstructure:"scopes"`        // 额外申请的 scope，openid 总是会申请
+	UsernameClaim string   `mapstructure:"usernameClaim"` // 首次登录创建用户时用作用户名的 claim
+	AutoCreate    bool     `mapstructure:"autoCreate"`    // 为 false 时只允许已绑定或邮箱匹配的已有用户登录
+	LinkByEmail   bool     `mapstructure:"linkByEmail"`   // 身份提供方确认邮箱已验证时，按邮箱绑定邮箱同样已验证的已有用户
+}
+
+type AuthConfig struct {
+	Providers []string `mapstructure:"providers"` // 用户名密码登录依次尝试的认证方式：local、ldap
+}
+
+type LDAPConfig struct {
+	Name               string `mapstructure:"name"`     // 认证方式标识，记录在 user_identity.provider 中
+	URL                string `mapstructure:"url"`      // ldap://host:389 或 ldaps://host:636
+	StartTLS           bool   `mapstructure:"startTLS"` // ldap:// 连接建立后升级为 TLS
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"`
+	Timeout            int    `mapstructure:"timeout"`      // 连接和请求超时，单位秒
+	BindDN             string `mapstructure:"bindDN"`       // 用于查找用户的服务账号，为空时匿名查找
+	BindPassword       string `mapstructure:"bindPassword"` // 可通过环境变量 LDAP_BIND_PASSWORD 覆盖
+	BaseDN             string `mapstructure:"baseDN"`
+	UserFilter         string `mapstructure:"userFilter"`        // 查找用户的过滤条件，%s 替换为转义后的用户名
+	SubjectAttribute   string `mapstructure:"subjectAttribute"`  // 用户唯一标识属性，如 entryUUID，为空时使用 DN
+	UsernameAttribute  string `mapstructure:"usernameAttribute"` // 首次登录创建用户时用作用户名的属性，为空时使用登录名
+	EmailAttribute     string `mapstructure:"emailAttribute"`
+	NameAttribute      string `mapstructure:"nameAttribute"`
+	TrustEmail         bool   `mapstructure:"trustEmail"`  // 目录中的邮箱视为已验证
+	AutoCreate         bool   `mapstructure:"autoCreate"`  // 首次登录时自动创建用户
+	LinkByEmail        bool   `mapstructure:"linkByEmail"` // 邮箱可信时按邮箱绑定邮箱已验证的已有用户，默认关闭
+}
+
+type TwoFactorConfig struct {
+	Issuer        string   `mapstructure:"issuer"`        // 验证器 App 中显示的服务名称
+	RequiredRoles []string `mapstructure:"requiredRoles"` // 必须启用两步验证的角色，未启用时只能访问个人账号相关接口
+	ChallengeTTL  int      `mapstructure:"challengeTTL"`  // 登录第二步的有效期，单位分钟
+	MaxAttempts   int      `mapstructure:"maxAttempts"`   // 同一次登录允许输错验证码的次数
+	RecoveryCodes int      `mapstructure:"recoveryCodes"` // 每次生成的恢复码数量
+}
+
+type AuditConfig struct {
+	Enabled       bool `mapstructure:"enabled"`       // 是否记录审计日志
+	RetentionDays int  `mapstructure:"retentionDays"` // 审计日志保留天数，超过后由后台任务删除
+	BufferSize    int  `mapstructure:"bufferSize"`    // 待写入队列长度，队列满时丢弃新日志并打印告警
+	SummaryLength int  `mapstructure:"summaryLength"` // 请求摘要最大长度，单位字符
+}
+
+type Settings struct {
+	Host              string `mapstructure:"host"`
+	Port              int    `mapstructure:"port"`
+	Timeout           int    `mapstructure:"timeout"`
+	PasswordSecret    string `mapstructure:"password_secret"`
+	Mode              string `mapstructure:"mode"`
+	*MysqlConfig      `mapstructure:"mysql"`
+	*RedisConfig      `mapstructure:"redis"`
+	*LoggerConfig     `mapstructure:"logger"`
+	*MailConfig       `mapstructure:"mail"`
+	*WebhookConfig    `mapstructure:"webhook"`
+	*BroadcastConfig  `mapstructure:"broadcast"`
+	*RBACConfig       `mapstructure:"rbac"`
+	*TimetableConfig  `mapstructure:"timetable"`
+	*JWTConfig        `mapstructure:"jwt"`
+	*PasswordConfig   `mapstructure:"password"`
+	*LoginGuardConfig `mapstructure:"loginGuard"`
+	*AccountConfig    `mapstructure:"account"`
+	*OIDCConfig       `mapstructure:"oidc"`
+	*AuthConfig       `mapstructure:"auth"`
+	*LDAPConfig       `mapstructure:"ldap"`
+	*TwoFactorConfig  `mapstructure:"twoFactor"`
+	*AuditConfig      `mapstructure:"audit"`
 }
 
 // initConfig 用于初始化配置文件
@@ -81,6 +221,74 @@ func initConfig() error {
 	viper.SetDefault("timeout", 10)
 	viper.SetDefault("mode", "release")
 
+	viper.SetDefault("mail.driver", "log")
+	viper.SetDefault("mail.port", 25)
+	viper.SetDefault("mail.from", "noreply@localhost")
+	viper.SetDefault("mail.logPath", "./logs/mail.log")
+	viper.SetDefault("mail.outboxInterval", 10)
+	viper.SetDefault("mail.maxAttempts", 5)
+
+	viper.SetDefault("webhook.interval", 5)
+	viper.SetDefault("webhook.timeout", 10)
+	viper.SetDefault("webhook.maxAttempts", 6)
+	viper.SetDefault("webhook.allowPrivateNetwork", false)
+
+	viper.SetDefault("broadcast.driver", "memory")
+	viper.SetDefault("broadcast.channelPrefix", "mutli-table:broadcast:")
+
+	viper.SetDefault("rbac.defaultRole", "student")
+
+	viper.SetDefault("jwt.accessTokenTTL", 15)
+	viper.SetDefault("jwt.refreshTokenTTL", 168)
+	_ = viper.BindEnv("jwt.secret", "JWT_SECRET")
+	_ = viper.BindEnv("jwt.signingKid", "JWT_SIGNING_KID")
+
+	viper.SetDefault("password.algorithm", "argon2id")
+	viper.SetDefault("password.bcryptCost", 12)
+	viper.SetDefault("password.argon2Memory", 64*1024)
+	viper.SetDefault("password.argon2Time", 3)
+	viper.SetDefault("password.argon2Threads", 2)
+
+	viper.SetDefault("loginGuard.window", 15)
+	viper.SetDefault("loginGuard.freeAttempts", 3)
+	viper.SetDefault("loginGuard.baseDelay", 1)
+	viper.SetDefault("loginGuard.maxDelay", 30)
+	viper.SetDefault("loginGuard.maxAccountAttempts", 10)
+	viper.SetDefault("loginGuard.maxIPAttempts", 50)
+	viper.SetDefault("loginGuard.lockoutDuration", 15)
+
+	viper.SetDefault("account.publicURL", "http://localhost:5173")
+	viper.SetDefault("account.emailVerifyTTL", 24)
+	viper.SetDefault("account.passwordResetTTL", 30)
+	viper.SetDefault("account.mailCooldown", 60)
+
+	viper.SetDefault("oidc.scopes", []string{"profile", "email"})
+	viper.SetDefault("oidc.usernameClaim", "preferred_username")
+	viper.SetDefault("oidc.autoCreate", true)
+	viper.SetDefault("oidc.linkByEmail", true)
+	_ = viper.BindEnv("oidc.clientSecret", "OIDC_CLIENT_SECRET")
+
+	viper.SetDefault("auth.providers", []string{"local"})
+	viper.SetDefault("ldap.name", "ldap")
+	viper.SetDefault("ldap.timeout", 5)
+	viper.SetDefault("ldap.userFilter", "(uid=%s)")
+	viper.SetDefault("ldap.emailAttribute", "mail")
+	viper.SetDefault("ldap.nameAttribute", "cn")
+	viper.SetDefault("ldap.trustEmail", true)
+	viper.SetDefault("ldap.autoCreate", true)
+	viper.SetDefault("ldap.linkByEmail", false)
+	_ = viper.BindEnv("ldap.bindPassword", "LDAP_BIND_PASSWORD")
+
+	viper.SetDefault("twoFactor.issuer", "MutliTable")
+	viper.SetDefault("twoFactor.challengeTTL", 5)
+	viper.SetDefault("twoFactor.maxAttempts", 5)
+	viper.SetDefault("twoFactor.recoveryCodes", 10)
+
+	viper.SetDefault("audit.enabled", true)
+	viper.SetDefault("audit.retentionDays", 180)
+	viper.SetDefault("audit.bufferSize", 1000)
+	viper.SetDefault("audit.summaryLength", 1024)
+
 	// 用于判断配置文件是否被修改
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {